@@ -1,13 +1,36 @@
 -- 1. ПОЛЬЗОВАТЕЛИ (основа системы)
 CREATE TABLE users (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-    email VARCHAR(255) UNIQUE NOT NULL,
-    username VARCHAR(50) UNIQUE NOT NULL,
+    -- email и username уникальны без учета регистра (индексы по LOWER в конце файла).
+    -- API хранит email в нижнем регистре, а username - в NFKC
+    email VARCHAR(255) NOT NULL,
+    username VARCHAR(50) NOT NULL,
+    -- Скелет username (internal/profile.UsernameSkeleton): похожие до неразличимости
+    -- имена ("admin" и "аdmin" с кириллической "а") дают одинаковый скелет
+    username_skeleton VARCHAR(50),
     password_hash VARCHAR(255) NOT NULL,
     full_name VARCHAR(100),
+    -- Баланс баллов, доступных к трате (кэш суммы points_ledger)
     score INTEGER DEFAULT 0,
     current_streak INTEGER DEFAULT 0,
     best_streak INTEGER DEFAULT 0,
+    -- Дневная цель: N видео (videos) или N правильных ответов (correct_answers) в день
+    daily_goal_type VARCHAR(20) NOT NULL DEFAULT 'videos'
+        CHECK (daily_goal_type IN ('videos', 'correct_answers')),
+    daily_goal_target INTEGER NOT NULL DEFAULT 3 CHECK (daily_goal_target BETWEEN 1 AND 50),
+    -- Роль: moderator и admin модерируют видео и комментарии
+    role VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'moderator', 'admin')),
+    -- Пока email не подтвержден, нельзя комментировать и становиться автором
+    email_verified_at TIMESTAMP,
+    -- Аватар: публичный адрес и ключ файла в хранилище (internal/storage)
+    avatar_url VARCHAR(500),
+    avatar_key VARCHAR(255),
+    -- Последняя смена username (для паузы между сменами)
+    username_changed_at TIMESTAMP,
+    -- Удаление учетной записи: до deletion_scheduled_at его можно отменить,
+    -- после - строка удаляется каскадом или обезличивается (deleted_at)
+    deletion_scheduled_at TIMESTAMP,
+    deleted_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
@@ -19,9 +42,17 @@ CREATE TABLE authors (
     user_id UUID UNIQUE REFERENCES users(id) ON DELETE CASCADE,
     full_name VARCHAR(255) NOT NULL,
     expertise_area VARCHAR(100), -- 'IT', 'Психология', 'Финансы'
-    -- Уровень доверия: для презентации можно назначать вручную или по упрощенной логике
+    -- Уровень доверия: 'bronze' | 'silver' | 'gold' | 'platinum'.
+    -- Пересчитывается фоновой задачей по измеримым сигналам (см. author_trust_evaluations)
     trust_tier VARCHAR(20) DEFAULT 'silver',
+    -- Балл 0..100, из которого получен уровень
+    trust_score NUMERIC(5, 1),
+    trust_evaluated_at TIMESTAMP,
+    -- Закрепить уровень вручную: задача продолжит считать балл, но не изменит trust_tier
+    trust_tier_manual BOOLEAN NOT NULL DEFAULT FALSE,
     is_verified BOOLEAN DEFAULT FALSE,
+    -- Кэш числа подписчиков, обновляется вместе с author_follows
+    followers_count INTEGER NOT NULL DEFAULT 0,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -39,21 +70,59 @@ CREATE TABLE videos (
     -- Теги для рекомендаций (например, ['python', 'программирование', 'для начинающих'])
     tags TEXT[] DEFAULT '{}',
     -- Для презентации: статус модерации можно ставить 'approved' вручную
+    -- 'pending' | 'approved' | 'rejected', те же статусы у комментариев
     moderation_status VARCHAR(20) DEFAULT 'approved',
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    -- Кэш числа лайков, обновляется вместе с video_likes
+    likes_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    -- Полнотекстовый поиск: заголовок важнее описания, контент бывает на русском и английском
+    search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', COALESCE(title, '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+        setweight(to_tsvector('russian', COALESCE(description, '')), 'B') ||
+        setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+    ) STORED
 );
 
 -- 4. ВОПРОСЫ (интерактив после видео)
-CREATE TABLE quizzes (
+-- К видео может быть несколько вопросов разных типов, они показываются по position
+CREATE TABLE quiz_questions (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-    -- Один вопрос к одному видео
-    video_id UUID UNIQUE NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    -- 'single_choice' | 'multiple_select' | 'true_false' | 'ordering' | 'numeric' | 'short_text'
+    question_type VARCHAR(30) NOT NULL DEFAULT 'single_choice',
     question TEXT NOT NULL,
-    -- Для простоты храним один правильный и несколько неправильных вариантов
-    correct_answer VARCHAR(255) NOT NULL,
-    wrong_answers VARCHAR(255)[] DEFAULT '{}',
-    -- Баллы за правильный ответ (основа геймификации)
-    points_awarded INTEGER DEFAULT 1
+    -- Варианты ответа в порядке показа (для типов с вариантами)
+    options JSONB NOT NULL DEFAULT '[]',
+    -- Правильный ответ в формате типа: индекс, список индексов, true/false,
+    -- {"value": .., "tolerance": ..} или {"accepted": [..]}
+    answer JSONB NOT NULL,
+    -- Пояснение, которое показывается после ответа
+    explanation TEXT,
+    -- Базовые баллы за правильный ответ, заданные автором (основа геймификации).
+    -- Фактически начисляемые баллы масштабируются по калиброванной сложности
+    points_awarded INTEGER NOT NULL DEFAULT 1,
+    -- Калибровка по модели IRT 2PL (фоновая задача): сложность b и различающая способность a.
+    -- NULL, пока первых попыток меньше порога
+    difficulty NUMERIC(6, 3),
+    discrimination NUMERIC(6, 3),
+    calibration_samples INTEGER NOT NULL DEFAULT 0,
+    correct_rate NUMERIC(5, 4),
+    calibrated_at TIMESTAMP,
+    UNIQUE (video_id, position)
+);
+
+-- 4.1. ОТВЕТЫ НА ВОПРОСЫ (каждая попытка, для статистики и повторений)
+CREATE TABLE quiz_answers (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    question_id UUID NOT NULL REFERENCES quiz_questions(id) ON DELETE CASCADE,
+    answer JSONB NOT NULL,
+    is_correct BOOLEAN NOT NULL,
+    -- Баллы начисляются, только если первая попытка правильная (запись в points_ledger)
+    points_earned INTEGER NOT NULL DEFAULT 0,
+    answered_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
 -- 5. ПРОГРЕСС ПОЛЬЗОВАТЕЛЯ (самая важная таблица для аналитики и геймификации)
@@ -64,13 +133,13 @@ CREATE TABLE user_video_progress (
     -- Факт просмотра
     is_watched BOOLEAN DEFAULT FALSE,
     watched_at TIMESTAMP,
-    -- Результат прохождения теста
+    -- Результат прохождения теста: quiz_correct = на все вопросы к видео есть правильный ответ
     quiz_attempted BOOLEAN DEFAULT FALSE,
     quiz_correct BOOLEAN DEFAULT FALSE,
-    -- Начисленные баллы за это видео
+    -- Начисленные баллы за это видео (кэш, источник - points_ledger)
     points_earned INTEGER DEFAULT 0,
-    -- КРИТИЧЕСКИ ВАЖНОЕ поле для подсчета серий (streak)
-    -- Будем считать streak по дням, когда было любое взаимодействие
+    -- День последнего взаимодействия с видео
+    -- (серия (streak) считается по дням выполнения дневной цели, см. user_daily_activity)
     interaction_date DATE NOT NULL DEFAULT CURRENT_DATE,
     PRIMARY KEY (user_id, video_id)
 );
@@ -78,19 +147,468 @@ CREATE TABLE user_video_progress (
 -- 6. ОБЩИЕ БАЛЛЫ И СТАТИСТИКА ПОЛЬЗОВАТЕЛЯ (для личного кабинета)
 CREATE TABLE user_stats (
     user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
-    -- Суммарные баллы (можно пересчитывать из user_video_progress, но для скорости храним отдельно)
+    -- Баланс баллов, доступных к трате, как users.score: траты на заморозки и восстановление
+    -- серии его уменьшают. Это не сумма заработанных баллов (ее дает points_ledger без трат).
+    -- Кэш, источник - points_ledger; сверяется командой points-reconcile
     total_points INTEGER DEFAULT 0,
     -- Текущая серия дней (streak)
     current_streak_days INTEGER DEFAULT 0,
     -- Максимальная достигнутая серия
     max_streak_days INTEGER DEFAULT 0,
-    -- Дата последней активности для проверки сброса streak
+    -- Дата последней активности
     last_activity_date DATE DEFAULT CURRENT_DATE,
+    -- Последний день, засчитанный в streak: цель выполнена или день закрыт заморозкой
+    last_streak_date DATE,
+    -- Заморозки серии в запасе: тратятся автоматически на пропущенные дни
+    streak_freezes INTEGER NOT NULL DEFAULT 0,
+    -- Последняя прерванная серия и момент обрыва: ее можно восстановить в течение ограниченного времени
+    broken_streak INTEGER NOT NULL DEFAULT 0,
+    streak_broken_at TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- 7. ИСТОРИЯ ПЕРЕСЧЕТА УРОВНЯ ДОВЕРИЯ АВТОРОВ
+-- Запись появляется, только когда пересчет изменил уровень или балл автора
+CREATE TABLE author_trust_evaluations (
+    id BIGSERIAL PRIMARY KEY,
+    author_id UUID NOT NULL REFERENCES authors(id) ON DELETE CASCADE,
+    previous_tier VARCHAR(20),
+    tier VARCHAR(20) NOT NULL,
+    score NUMERIC(5, 1) NOT NULL,
+    -- Входные сигналы на момент расчета (верификация, модерация, жалобы, тесты, досмотры)
+    inputs JSONB NOT NULL,
+    -- Человекочитаемое объяснение каждого слагаемого балла
+    reasons TEXT[] NOT NULL DEFAULT '{}',
+    evaluated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 8. РАСПИСАНИЕ ИНТЕРВАЛЬНЫХ ПОВТОРЕНИЙ (SM-2)
+-- Видео попадает сюда после ошибки в тесте и возвращается пользователю в GET /api/review
+CREATE TABLE review_schedule (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    -- Число успешных повторений подряд
+    repetitions INTEGER NOT NULL DEFAULT 0,
+    -- "Легкость" материала для пользователя, не меньше 1.3
+    ease_factor NUMERIC(4, 2) NOT NULL DEFAULT 2.5,
+    interval_days INTEGER NOT NULL DEFAULT 1,
+    due_date DATE NOT NULL,
+    -- Оценка последнего повторения 0..5
+    last_quality INTEGER,
+    last_reviewed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, video_id)
+);
+
+-- 9. ДРУЗЬЯ (для рейтинга среди друзей)
+CREATE TABLE user_friends (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    friend_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, friend_id),
+    CHECK (user_id <> friend_id)
+);
+
+-- 10. ДОСТИЖЕНИЯ (БЕЙДЖИ)
+-- Правило выдачи задается декларативно в rule, новые достижения добавляются без изменения кода:
+--   {"metric": "streak_days", "gte": 7}
+--   {"metric": "correct_answers", "params": {"expertise_area": "IT"}, "gte": 10}
+--   {"all": [...]} / {"any": [...]} - комбинации правил
+-- Метрики: total_points (заработанные баллы), streak_days, best_streak, videos_watched, quizzes_completed,
+--          distinct_authors_watched, correct_answers (params: expertise_area, tag)
+CREATE TABLE achievements (
+    code VARCHAR(50) PRIMARY KEY,
+    title VARCHAR(100) NOT NULL,
+    description TEXT,
+    icon VARCHAR(100),
+    rule JSONB NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    -- Баллы за получение достижения (начисляются через points_ledger)
+    points INTEGER NOT NULL DEFAULT 0,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE user_achievements (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    achievement_code VARCHAR(50) NOT NULL REFERENCES achievements(code) ON DELETE CASCADE,
+    unlocked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Первичный ключ гарантирует, что бейдж выдается один раз
+    PRIMARY KEY (user_id, achievement_code)
+);
+
+INSERT INTO achievements (code, title, description, icon, rule, points, sort_order) VALUES
+    ('first_correct', 'Первый шаг', 'Правильно ответить на первый вопрос', '🎯',
+        '{"metric": "correct_answers", "gte": 1}', 5, 10),
+    ('streak_7', 'Неделя знаний', 'Заниматься 7 дней подряд', '🔥',
+        '{"metric": "streak_days", "gte": 7}', 30, 20),
+    ('it_correct_10', 'Айтишник', '10 правильных ответов по теме IT', '💻',
+        '{"metric": "correct_answers", "params": {"expertise_area": "IT"}, "gte": 10}', 20, 30),
+    ('authors_5', 'Широкий кругозор', 'Посмотреть видео 5 разных авторов', '🧭',
+        '{"metric": "distinct_authors_watched", "gte": 5}', 20, 40),
+    ('points_100', 'Сотня', 'Набрать 100 баллов', '💯',
+        '{"metric": "total_points", "gte": 100}', 10, 50);
+
+-- 11. ДНЕВНАЯ АКТИВНОСТЬ И ВЫПОЛНЕНИЕ ДНЕВНОЙ ЦЕЛИ
+CREATE TABLE user_daily_activity (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    activity_date DATE NOT NULL,
+    -- Видео, впервые досмотренные за день
+    videos_watched INTEGER NOT NULL DEFAULT 0,
+    -- Первые правильные ответы на вопросы за день
+    correct_answers INTEGER NOT NULL DEFAULT 0,
+    -- Цель, действовавшая в этот день (смена цели после ее выполнения не отменяет день серии)
+    goal_type VARCHAR(20) NOT NULL,
+    goal_target INTEGER NOT NULL,
+    goal_met_at TIMESTAMP,
+    PRIMARY KEY (user_id, activity_date)
+);
+
+-- 12. ЖУРНАЛ СЕРИИ (streak): каждое изменение серии и заморозок
+-- current_streak_days и users.current_streak можно восстановить по этому журналу
+CREATE TABLE streak_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event_type VARCHAR(30) NOT NULL CHECK (event_type IN (
+        'goal_met', 'freeze_earned', 'freeze_purchased', 'freeze_used', 'broken', 'repaired'
+    )),
+    -- День серии, к которому относится событие
+    event_date DATE NOT NULL,
+    streak_before INTEGER NOT NULL,
+    streak_after INTEGER NOT NULL,
+    freezes_after INTEGER NOT NULL,
+    -- Баллы, потраченные на покупку заморозки или восстановление
+    points_spent INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 13. ЖУРНАЛ БАЛЛОВ (только добавление записей)
+-- Единственный источник баллов: users.score, user_stats.total_points и
+-- user_video_progress.points_earned - кэши, которые обновляются в той же транзакции
+CREATE TABLE points_ledger (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- Положительное - начисление, отрицательное - списание
+    amount INTEGER NOT NULL CHECK (amount <> 0),
+    reason VARCHAR(30) NOT NULL CHECK (reason IN (
+        'quiz_correct', 'achievement', 'streak_bonus', 'admin_adjustment', 'freeze_purchase', 'streak_repair'
+    )),
+    -- За что начислено: ID вопроса, код достижения, день серии. Одна причина - одно начисление
+    reference VARCHAR(100),
+    -- Видео, к которому относится начисление за ответ (кэш user_video_progress.points_earned)
+    video_id UUID REFERENCES videos(id) ON DELETE SET NULL,
+    note TEXT,
+    -- Баланс после записи: balance_after предыдущей записи пользователя плюс amount
+    -- (не кэш users.score, чтобы сверка находила его расхождение с журналом)
+    balance_after INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 14. ТАКСОНОМИЯ ТЕГОВ: канонические теги и их синонимы
+-- Синонимы хранятся в нормализованной форме (нижний регистр, ё → е, одиночные пробелы).
+-- Теги видео без записи здесь считаются самостоятельными
+CREATE TABLE tags (
+    slug VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL
+);
+
+CREATE TABLE tag_aliases (
+    alias VARCHAR(100) PRIMARY KEY,
+    tag_slug VARCHAR(50) NOT NULL REFERENCES tags(slug) ON DELETE CASCADE
+);
+
+INSERT INTO tags (slug, name) VALUES
+    ('go', 'Go'),
+    ('postgresql', 'PostgreSQL'),
+    ('sql', 'SQL'),
+    ('api', 'API'),
+    ('http', 'HTTP'),
+    ('веб', 'Веб'),
+    ('программирование', 'Программирование'),
+    ('базы данных', 'Базы данных'),
+    ('безопасность', 'Безопасность'),
+    ('психология', 'Психология'),
+    ('продуктивность', 'Продуктивность'),
+    ('саморазвитие', 'Саморазвитие');
+
+INSERT INTO tag_aliases (alias, tag_slug) VALUES
+    ('golang', 'go'),
+    ('го', 'go'),
+    ('голанг', 'go'),
+    ('postgres', 'postgresql'),
+    ('постгрес', 'postgresql'),
+    ('web', 'веб'),
+    ('https', 'http'),
+    ('programming', 'программирование'),
+    ('databases', 'базы данных'),
+    ('бд', 'базы данных'),
+    ('security', 'безопасность'),
+    ('psychology', 'психология'),
+    ('productivity', 'продуктивность');
+
+-- 15. ПОДПИСКИ НА АВТОРОВ
+CREATE TABLE author_follows (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    author_id UUID NOT NULL REFERENCES authors(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, author_id)
+);
+
+-- 16. УВЕДОМЛЕНИЯ
+-- Получатель - учетная запись пользователя (для автора - authors.user_id)
+CREATE TABLE notifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    notification_type VARCHAR(30) NOT NULL CHECK (notification_type IN ('new_follower', 'report_resolved')),
+    -- Кто вызвал событие (подписчик, модератор)
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    -- Данные события, например {"author_id": "..."}
+    payload JSONB NOT NULL DEFAULT '{}',
+    read_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 17. ЛАЙКИ И ЗАКЛАДКИ
+CREATE TABLE video_likes (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, video_id)
+);
+
+-- Сохраненные видео (библиотека пользователя)
+CREATE TABLE video_bookmarks (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, video_id)
+);
+
+-- 18. КОММЕНТАРИИ: один уровень ответов, закрепление автором видео, модерация
+CREATE TABLE comments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    -- Ответ всегда привязан к комментарию верхнего уровня
+    parent_id UUID REFERENCES comments(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    -- Комментарий со словами из фильтра ждет модератора ('pending')
+    moderation_status VARCHAR(20) NOT NULL DEFAULT 'approved'
+        CHECK (moderation_status IN ('pending', 'approved', 'rejected')),
+    flagged_keywords TEXT[] NOT NULL DEFAULT '{}',
+    moderated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    moderated_at TIMESTAMP,
+    -- Закрепляет автор видео
+    is_pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    edited_at TIMESTAMP,
+    -- Удаленный комментарий с ответами остается в ветке без текста
+    deleted_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 19. ЖАЛОБЫ НА ВИДЕО И КОММЕНТАРИИ
+-- Одна жалоба от пользователя на объект; при достижении порога открытых жалоб
+-- объект скрывается (moderation_status = 'pending') до решения модератора
+CREATE TABLE reports (
+    id BIGSERIAL PRIMARY KEY,
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_type VARCHAR(20) NOT NULL CHECK (target_type IN ('video', 'comment')),
+    target_id UUID NOT NULL,
+    reason VARCHAR(30) NOT NULL CHECK (reason IN ('misinformation', 'spam', 'offensive', 'copyright')),
+    details TEXT,
+    -- open - ждет модератора, upheld - контент отклонен, dismissed - жалоба отклонена
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'upheld', 'dismissed')),
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (reporter_id, target_type, target_id)
+);
+
+-- 20. ТОКЕНЫ ПОДТВЕРЖДЕНИЯ EMAIL
+-- Хранится только SHA-256 токена из ссылки
+CREATE TABLE email_verification_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    -- Адрес, на который ушло письмо: после смены email старые ссылки недействительны
+    email VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 21. СЕССИИ ВХОДА: одна строка - одно устройство (семейство refresh-токенов)
+-- Клиент передает access-токен в Authorization: Bearer и обновляет его refresh-токеном.
+-- Хранятся только SHA-256 токенов
+CREATE TABLE sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    access_token_hash VARCHAR(64) NOT NULL UNIQUE,
+    access_expires_at TIMESTAMP NOT NULL,
+    device_name VARCHAR(100),
+    platform VARCHAR(20) NOT NULL DEFAULT 'other' CHECK (platform IN ('web', 'ios', 'android', 'desktop', 'other')),
+    user_agent TEXT,
+    ip_address VARCHAR(45),
+    -- Срок семейства: продлевается при каждом обновлении токенов
+    expires_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    -- Вход без второго фактора, хотя роль требует 2FA: сессия годится только для включения 2FA
+    -- (session.RestrictEnrollment) и становится полной, когда 2FA включена
+    enrollment_only BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Refresh-токены семейства. Каждый используется один раз (rotated_at);
+-- повторное предъявление уже замененного токена отзывает всю сессию
+CREATE TABLE refresh_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    rotated_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 22. ТОКЕНЫ СБРОСА ПАРОЛЯ (одноразовые, хранится только SHA-256)
+CREATE TABLE password_reset_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 23. ДВУХФАКТОРНАЯ АУТЕНТИФИКАЦИЯ (TOTP)
+-- enabled_at IS NULL - секрет выдан, но первый код еще не подтвержден
+CREATE TABLE user_totp (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret VARCHAR(64) NOT NULL,
+    -- Последний принятый интервал: код нельзя использовать повторно
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    enabled_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Одноразовые резервные коды (хранится только SHA-256)
+CREATE TABLE totp_recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, code_hash)
+);
+
+-- Вход, ожидающий второй фактор: пароль проверен, сессия еще не выдана
+CREATE TABLE login_challenges (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Роли, для которых 2FA обязательна (задают модераторы и администраторы)
+CREATE TABLE two_factor_requirements (
+    role VARCHAR(20) PRIMARY KEY CHECK (role IN ('user', 'moderator', 'admin')),
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 24. ВХОД ЧЕРЕЗ ВНЕШНИХ ПРОВАЙДЕРОВ (OpenID Connect)
+-- Начатый вход: state (хранится SHA-256), PKCE code_verifier и nonce до возврата от провайдера
+CREATE TABLE oidc_login_states (
+    id BIGSERIAL PRIMARY KEY,
+    state_hash VARCHAR(64) NOT NULL UNIQUE,
+    provider VARCHAR(50) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    nonce VARCHAR(128) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Внешние учетные записи, привязанные к пользователям (по подтвержденному email)
+CREATE TABLE user_identities (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    last_login_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, subject),
+    UNIQUE (user_id, provider)
+);
+
+-- 25. ЗАРЕЗЕРВИРОВАННЫЕ ИМЕНА ПОЛЬЗОВАТЕЛЕЙ
+-- Старый username после смены какое-то время не может занять никто, кроме прежнего владельца
+CREATE TABLE username_reservations (
+    username VARCHAR(50) PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reserved_until TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 26. ВЫГРУЗКА ПЕРСОНАЛЬНЫХ ДАННЫХ
+-- Архив (JSON и CSV) собирается фоновой задачей и хранится до expires_at
+CREATE TABLE data_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'processing', 'ready', 'failed', 'expired')),
+    -- Ключ архива в хранилище (internal/storage)
+    file_key VARCHAR(255),
+    size_bytes BIGINT,
+    error TEXT,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
 -- Индексы для ускорения ключевых запросов (лента, прогресс)
+-- Уникальность email и username без учета регистра и похожих символов
+CREATE UNIQUE INDEX idx_users_email_lower ON users(LOWER(email));
+CREATE UNIQUE INDEX idx_users_username_lower ON users(LOWER(username));
+CREATE UNIQUE INDEX idx_users_username_skeleton ON users(username_skeleton);
 CREATE INDEX idx_videos_created_at ON videos(created_at DESC);
 CREATE INDEX idx_videos_tags ON videos USING GIN(tags);
+CREATE INDEX idx_videos_search ON videos USING GIN(search_vector);
 CREATE INDEX idx_user_progress_user_interaction ON user_video_progress(user_id, interaction_date DESC);
-CREATE INDEX idx_user_progress_video ON user_video_progress(video_id);
\ No newline at end of file
+CREATE INDEX idx_user_progress_video ON user_video_progress(video_id);
+CREATE INDEX idx_author_trust_evaluations_author ON author_trust_evaluations(author_id, evaluated_at DESC);
+CREATE INDEX idx_quiz_answers_user_question ON quiz_answers(user_id, question_id);
+CREATE INDEX idx_quiz_answers_question ON quiz_answers(question_id);
+CREATE INDEX idx_review_schedule_due ON review_schedule(user_id, due_date);
+CREATE INDEX idx_streak_events_user ON streak_events(user_id, id DESC);
+CREATE UNIQUE INDEX idx_points_ledger_reference ON points_ledger(user_id, reason, reference) WHERE reference IS NOT NULL;
+CREATE INDEX idx_points_ledger_user ON points_ledger(user_id, id DESC);
+-- Баллы за период для восстановления недельных и месячных рейтингов
+CREATE INDEX idx_points_ledger_created ON points_ledger(created_at);
+CREATE INDEX idx_author_follows_author ON author_follows(author_id);
+-- Лента подписок: курсор (created_at, id) по видео авторов
+CREATE INDEX idx_videos_author_created ON videos(author_id, created_at DESC, id DESC);
+CREATE INDEX idx_notifications_user ON notifications(user_id, id DESC);
+CREATE INDEX idx_video_likes_video ON video_likes(video_id);
+-- Библиотека сохраненных: курсор (created_at, video_id)
+CREATE INDEX idx_video_bookmarks_user ON video_bookmarks(user_id, created_at DESC, video_id DESC);
+-- Комментарии к видео и ответы в ветке: курсор (created_at, id)
+CREATE INDEX idx_comments_video ON comments(video_id, created_at DESC, id DESC) WHERE parent_id IS NULL;
+CREATE INDEX idx_comments_parent ON comments(parent_id, created_at, id);
+CREATE INDEX idx_comments_pending ON comments(created_at) WHERE moderation_status = 'pending';
+CREATE INDEX idx_reports_target ON reports(target_type, target_id) WHERE status = 'open';
+CREATE INDEX idx_email_verification_tokens_user ON email_verification_tokens(user_id, created_at DESC);
+CREATE INDEX idx_sessions_user ON sessions(user_id) WHERE revoked_at IS NULL;
+CREATE INDEX idx_password_reset_tokens_user ON password_reset_tokens(user_id, created_at DESC);
+CREATE INDEX idx_totp_recovery_codes_user ON totp_recovery_codes(user_id) WHERE used_at IS NULL;
+CREATE INDEX idx_refresh_tokens_session ON refresh_tokens(session_id);
+CREATE UNIQUE INDEX idx_username_reservations_lower ON username_reservations(LOWER(username));
+CREATE INDEX idx_data_exports_user ON data_exports(user_id, created_at DESC);
+-- Очередь выгрузок и учетные записи, ожидающие удаления (фоновая задача account.Job)
+CREATE INDEX idx_data_exports_pending ON data_exports(created_at) WHERE status IN ('pending', 'processing');
+CREATE INDEX idx_users_deletion_scheduled ON users(deletion_scheduled_at) WHERE deletion_scheduled_at IS NOT NULL;
@@ -10,8 +10,24 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/mindly/api/internal/account"
+	"github.com/mindly/api/internal/calibration"
 	"github.com/mindly/api/internal/database"
 	"github.com/mindly/api/internal/handlers"
+	"github.com/mindly/api/internal/leaderboard"
+	"github.com/mindly/api/internal/mailer"
+	"github.com/mindly/api/internal/moderation"
+	"github.com/mindly/api/internal/oidc"
+	"github.com/mindly/api/internal/password"
+	"github.com/mindly/api/internal/profile"
+	"github.com/mindly/api/internal/quiz"
+	"github.com/mindly/api/internal/session"
+	"github.com/mindly/api/internal/storage"
+	"github.com/mindly/api/internal/streak"
+	"github.com/mindly/api/internal/tokens"
+	"github.com/mindly/api/internal/totp"
+	"github.com/mindly/api/internal/trust"
+	"github.com/mindly/api/internal/verification"
 )
 
 // CORS middleware
@@ -19,7 +35,7 @@ func enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Разрешаем запросы с любого origin (для разработки)
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 		w.Header().Set("Access-Control-Max-Age", "3600")
@@ -47,9 +63,85 @@ func main() {
 
 	log.Println("✅ Database connected successfully")
 
+	// Redis нужен только для рейтингов: без него API продолжает работать
+	rdb, err := database.ConnectRedis(context.Background(), database.DefaultRedisConfig())
+	if err != nil {
+		log.Printf("⚠️ Redis недоступен, рейтинги временно не работают: %v", err)
+	}
+	defer rdb.Close()
+
+	lb := leaderboard.NewService(rdb)
+
+	// Контекст фоновых задач: отменяется при остановке сервера
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	// Пересчет уровней доверия авторов раз в сутки
+	trustJob := trust.NewJob(database.NewAuthorRepository(db), trust.DefaultConfig())
+	go trustJob.Start(jobsCtx, 24*time.Hour)
+
+	// Калибровка сложности вопросов по статистике ответов
+	calibrationJob := calibration.NewJob(database.NewQuizRepository(db), calibration.DefaultConfig())
+	go calibrationJob.Start(jobsCtx, 6*time.Hour)
+
+	// Пропущенные дни: списание заморозок или обрыв серии
+	streakJob := streak.NewJob(database.NewStreakRepository(db), lb)
+	go streakJob.Start(jobsCtx, time.Hour)
+
+	// Письма: в разработке пишутся в лог
+	mail, err := mailer.New(mailer.DefaultConfig())
+	if err != nil {
+		log.Fatalf("❌ Failed to configure mailer: %v", err)
+	}
+	verifyCfg := verification.DefaultConfig()
+	// Пароли: новые хэши - argon2id; bcrypt и старый MD5 из seed пересчитываются при входе
+	hashCfg := password.DefaultHashConfig()
+	hasher, err := password.NewHasher(hashCfg)
+	if err != nil {
+		log.Fatalf("❌ Failed to configure password hasher: %v", err)
+	}
+	passwordPolicy := password.DefaultPolicy()
+	passwordPolicy.MaxBytes = hashCfg.MaxPasswordBytes()
+	totpCfg := totp.DefaultConfig()
+
 	// Создаем обработчики
-	authHandler := handlers.NewAuthHandler(db)
+	authHandler := handlers.NewAuthHandler(db, mail, verifyCfg, passwordPolicy, hasher)
+	verificationHandler := handlers.NewVerificationHandler(db, mail, verifyCfg)
+	authorHandler := handlers.NewAuthorHandler(db)
+	sessionHandler := handlers.NewSessionHandler(db, session.DefaultConfig(), totpCfg, hasher)
+	twoFactorHandler := handlers.NewTwoFactorHandler(db, totpCfg)
+	oidcProviders, err := oidc.NewRegistry(oidc.DefaultConfig())
+	if err != nil {
+		log.Fatalf("❌ Failed to configure OIDC providers: %v", err)
+	}
+	oidcHandler := handlers.NewOIDCHandler(db, oidcProviders, sessionHandler)
+	// Загруженные файлы (аватары): локальный каталог, раздается по /uploads/
+	store, err := storage.New(storage.DefaultConfig())
+	if err != nil {
+		log.Fatalf("❌ Failed to configure file storage: %v", err)
+	}
+	profileHandler := handlers.NewProfileHandler(db, store, profile.DefaultConfig())
+	// Выгрузки данных и удаление учетных записей по истечении срока на отмену
+	accountCfg := account.DefaultConfig()
+	accountJob := account.NewJob(database.NewAccountRepository(db), store, lb, accountCfg)
+	go accountJob.Start(jobsCtx, 10*time.Minute)
+	accountHandler := handlers.NewAccountHandler(db, store, hasher, accountJob, accountCfg)
+	passwordHandler := handlers.NewPasswordHandler(db, mail, password.DefaultResetConfig(), passwordPolicy, hasher)
 	videoHandler := handlers.NewVideoHandler(db) // ДОБАВЛЕНО: создаём обработчик видео
+	quizHandler := handlers.NewQuizHandler(db, lb, quiz.DefaultConfig())
+	reviewHandler := handlers.NewReviewHandler(db, lb)
+	leaderboardHandler := handlers.NewLeaderboardHandler(db, lb)
+	achievementHandler := handlers.NewAchievementHandler(db)
+	progressHandler := handlers.NewProgressHandler(db, lb)
+	streakHandler := handlers.NewStreakHandler(db, lb)
+	searchHandler := handlers.NewSearchHandler(db)
+	topicHandler := handlers.NewTopicHandler(db)
+	followHandler := handlers.NewFollowHandler(db)
+	notificationHandler := handlers.NewNotificationHandler(db)
+	engagementHandler := handlers.NewEngagementHandler(db)
+	commentHandler := handlers.NewCommentHandler(db, moderation.NewFilter(moderation.DefaultConfig()))
+	moderationHandler := handlers.NewModerationHandler(db)
+	reportHandler := handlers.NewReportHandler(db, moderation.DefaultReportConfig())
 
 	// Настраиваем маршруты
 	mux := http.NewServeMux()
@@ -59,12 +151,128 @@ func main() {
 
 	// Auth endpoints
 	mux.HandleFunc("POST /api/auth/register", authHandler.Register)
+	mux.HandleFunc("GET /api/auth/verify-email", verificationHandler.Verify)
+	mux.HandleFunc("POST /api/auth/verify-email", verificationHandler.Verify)
+	mux.HandleFunc("POST /api/auth/verify-email/resend", verificationHandler.Resend)
+	mux.HandleFunc("POST /api/auth/login", sessionHandler.Login)
+	mux.HandleFunc("POST /api/auth/login/2fa", sessionHandler.LoginTwoFactor)
+	mux.HandleFunc("POST /api/auth/refresh", sessionHandler.Refresh)
+	mux.HandleFunc("GET /api/auth/oidc/{provider}/start", oidcHandler.Start)
+	mux.HandleFunc("POST /api/auth/oidc/{provider}/callback", oidcHandler.Callback)
+	mux.HandleFunc("POST /api/auth/logout", sessionHandler.Logout)
+	mux.HandleFunc("GET /api/me/sessions", sessionHandler.Sessions)
+	mux.HandleFunc("DELETE /api/me/sessions/{id}", sessionHandler.RevokeSession)
+	mux.HandleFunc("POST /api/me/sessions/revoke-others", sessionHandler.RevokeOtherSessions)
+	mux.HandleFunc("POST /api/auth/password/forgot", passwordHandler.Forgot)
+	mux.HandleFunc("POST /api/auth/password/reset", passwordHandler.Reset)
+	mux.HandleFunc("PUT /api/me/password", passwordHandler.Change)
+	mux.HandleFunc("GET /api/me", profileHandler.Get)
+	mux.HandleFunc("PATCH /api/me", profileHandler.Update)
+	mux.HandleFunc("PUT /api/me/avatar", profileHandler.UploadAvatar)
+	mux.HandleFunc("DELETE /api/me/avatar", profileHandler.DeleteAvatar)
+	// Публично раздаются только аватары; архивы выгрузок - через /api/me/exports/{id}/download
+	if local, ok := store.(*storage.LocalStorage); ok {
+		mux.Handle("GET /uploads/avatars/", http.StripPrefix("/uploads", local.Handler()))
+	}
+	mux.HandleFunc("POST /api/me/export", accountHandler.RequestExport)
+	mux.HandleFunc("GET /api/me/exports", accountHandler.ListExports)
+	mux.HandleFunc("GET /api/me/exports/{id}/download", accountHandler.DownloadExport)
+	mux.HandleFunc("DELETE /api/me", accountHandler.Delete)
+	mux.HandleFunc("POST /api/me/deletion/cancel", accountHandler.CancelDeletion)
+	mux.HandleFunc("POST /api/me/2fa/enroll", twoFactorHandler.Enroll)
+	mux.HandleFunc("POST /api/me/2fa/verify", twoFactorHandler.Verify)
+	mux.HandleFunc("DELETE /api/me/2fa", twoFactorHandler.Disable)
+	mux.HandleFunc("POST /api/me/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
 
 	// Video endpoints (добавлено)
 	mux.HandleFunc("GET /api/feed", videoHandler.GetFeed)
 
-	// Добавляем middleware
-	handler := enableCORS(mux)
+	// Подписки на авторов; лента подписок - GET /api/feed?source=following
+	mux.HandleFunc("POST /api/me/author", authorHandler.BecomeAuthor)
+	mux.HandleFunc("POST /api/authors/{id}/follow", followHandler.Follow)
+	mux.HandleFunc("DELETE /api/authors/{id}/follow", followHandler.Unfollow)
+
+	// Лайки и закладки: PUT ставит, DELETE снимает
+	mux.HandleFunc("PUT /api/videos/{id}/like", engagementHandler.Like)
+	mux.HandleFunc("DELETE /api/videos/{id}/like", engagementHandler.Like)
+	mux.HandleFunc("PUT /api/videos/{id}/bookmark", engagementHandler.Bookmark)
+	mux.HandleFunc("DELETE /api/videos/{id}/bookmark", engagementHandler.Bookmark)
+	mux.HandleFunc("GET /api/me/saved", engagementHandler.GetSaved)
+
+	// Комментарии: один уровень ответов, закрепление автором видео
+	mux.HandleFunc("GET /api/videos/{id}/comments", commentHandler.List)
+	mux.HandleFunc("POST /api/videos/{id}/comments", commentHandler.Create)
+	mux.HandleFunc("GET /api/comments/{id}/replies", commentHandler.Replies)
+	mux.HandleFunc("PUT /api/comments/{id}", commentHandler.Edit)
+	mux.HandleFunc("DELETE /api/comments/{id}", commentHandler.Delete)
+	mux.HandleFunc("PUT /api/comments/{id}/pin", commentHandler.Pin)
+
+	// Жалобы на видео и комментарии
+	mux.HandleFunc("POST /api/reports", reportHandler.Create)
+
+	// Модерация (роли moderator и admin)
+	mux.HandleFunc("GET /api/moderation/reports", moderationHandler.ReportedTargets)
+	mux.HandleFunc("GET /api/moderation/comments", moderationHandler.PendingComments)
+	mux.HandleFunc("POST /api/moderation/comments/{id}", moderationHandler.DecideComment)
+	mux.HandleFunc("POST /api/moderation/videos/{id}", moderationHandler.DecideVideo)
+	mux.HandleFunc("GET /api/moderation/2fa/roles", moderationHandler.TwoFactorRequirements)
+	// Требование 2FA для ролей меняет только администратор
+	mux.HandleFunc("PUT /api/moderation/2fa/roles/{role}", moderationHandler.SetTwoFactorRequirement)
+
+	// Notifications
+	mux.HandleFunc("GET /api/me/notifications", notificationHandler.List)
+	mux.HandleFunc("POST /api/me/notifications/read", notificationHandler.MarkRead)
+
+	// Search endpoints: полнотекстовый поиск и подсказки тегов
+	mux.HandleFunc("GET /api/search", searchHandler.Search)
+	mux.HandleFunc("GET /api/search/tags", searchHandler.SuggestTags)
+
+	// Topics: области экспертизы и таксономия тегов
+	mux.HandleFunc("GET /api/topics", topicHandler.ListTopics)
+	mux.HandleFunc("GET /api/topics/{area}/feed", topicHandler.GetTopicFeed)
+	mux.HandleFunc("GET /api/tags", topicHandler.ListTags)
+	mux.HandleFunc("GET /api/tags/{tag}/feed", topicHandler.GetTagFeed)
+
+	// Quiz endpoints
+	mux.HandleFunc("GET /api/videos/{id}/quiz", quizHandler.GetQuiz)
+	mux.HandleFunc("PUT /api/videos/{id}/quiz", quizHandler.SaveQuiz)
+	mux.HandleFunc("POST /api/videos/{id}/quiz/answers", quizHandler.SubmitAnswer)
+	mux.HandleFunc("GET /api/authors/{id}/quiz-stats", quizHandler.GetAuthorQuizStats)
+
+	// Review endpoints (интервальные повторения)
+	mux.HandleFunc("GET /api/review", reviewHandler.GetDue)
+	mux.HandleFunc("POST /api/review/{video_id}", reviewHandler.SubmitReview)
+
+	// Leaderboard endpoints: board = points | streak, ?window=all|week|month&scope=global|friends
+	mux.HandleFunc("GET /api/leaderboards/{board}", leaderboardHandler.GetLeaderboard)
+	mux.HandleFunc("GET /api/leaderboards/{board}/me", leaderboardHandler.GetMyRank)
+	mux.HandleFunc("POST /api/friends/{id}", leaderboardHandler.AddFriend)
+	mux.HandleFunc("DELETE /api/friends/{id}", leaderboardHandler.RemoveFriend)
+
+	// Achievements
+	mux.HandleFunc("GET /api/me/achievements", achievementHandler.GetMine)
+
+	// Progress: просмотры, дневная цель, серия и уровень
+	mux.HandleFunc("POST /api/videos/{id}/watched", progressHandler.MarkWatched)
+	mux.HandleFunc("GET /api/me/today", progressHandler.GetToday)
+	mux.HandleFunc("PUT /api/me/goal", progressHandler.UpdateGoal)
+
+	// Streak: заморозки и восстановление серии
+	mux.HandleFunc("GET /api/me/streak", streakHandler.GetStreak)
+	mux.HandleFunc("POST /api/me/streak/freezes", streakHandler.BuyFreeze)
+	mux.HandleFunc("POST /api/me/streak/repair", streakHandler.Repair)
+
+	// Сессия без обязательной для роли 2FA открывает только ее включение
+	enrollmentRoutes := []string{
+		"GET /api/me",
+		"POST /api/me/2fa/enroll",
+		"POST /api/me/2fa/verify",
+		"POST /api/auth/logout",
+	}
+
+	// Добавляем middleware: сессия из Authorization: Bearer, затем CORS
+	handler := enableCORS(session.Middleware(database.NewSessionRepository(db), tokens.Hash,
+		session.RestrictEnrollment(enrollmentRoutes, mux)))
 
 	// Настраиваем сервер
 	server := &http.Server{
@@ -80,7 +288,18 @@ func main() {
 		log.Printf("🌐 Server listening on http://%s", server.Addr)
 		log.Printf("📊 Health check: http://%s/health", "localhost:8081")
 		log.Printf("👤 Register endpoint: POST http://%s/api/auth/register", "localhost:8081")
+		log.Printf("🔑 Login endpoint: POST http://%s/api/auth/login", "localhost:8081")
 		log.Printf("🎬 Video feed endpoint: GET http://%s/api/feed", "localhost:8081") // ДОБАВЛЕНО: логируем новый endpoint
+		log.Printf("➕ Follow: POST http://%s/api/authors/{id}/follow", "localhost:8081")
+		log.Printf("❤️ Likes and bookmarks: PUT/DELETE http://%s/api/videos/{id}/like", "localhost:8081")
+		log.Printf("💬 Comments: GET/POST http://%s/api/videos/{id}/comments", "localhost:8081")
+		log.Printf("🔔 Notifications: GET http://%s/api/me/notifications", "localhost:8081")
+		log.Printf("🔎 Search endpoint: GET http://%s/api/search?q=...", "localhost:8081")
+		log.Printf("🗂 Topics: GET http://%s/api/topics", "localhost:8081")
+		log.Printf("🧠 Quiz endpoints: GET/PUT http://%s/api/videos/{id}/quiz", "localhost:8081")
+		log.Printf("🔁 Review endpoint: GET http://%s/api/review", "localhost:8081")
+		log.Printf("🏆 Leaderboards: GET http://%s/api/leaderboards/{points|streak}", "localhost:8081")
+		log.Printf("🎯 Daily goal: GET http://%s/api/me/today", "localhost:8081")
 
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("❌ Server error: %v", err)
@@ -96,6 +315,8 @@ func main() {
 	log.Printf("🛑 Received signal: %v", sig)
 	log.Println("Shutting down server...")
 
+	stopJobs()
+
 	// Создаем контекст с таймаутом для graceful shutdown
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
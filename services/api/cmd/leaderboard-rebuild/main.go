@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/leaderboard"
+)
+
+// Восстановление рейтингов в Redis из Postgres:
+//
+//	go run ./cmd/leaderboard-rebuild
+func main() {
+	log.Println("🏆 Rebuilding leaderboards from PostgreSQL...")
+
+	ctx := context.Background()
+
+	db, err := database.Connect(ctx, database.DefaultConfig())
+	if err != nil {
+		log.Fatalf("❌ Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	rdb, err := database.ConnectRedis(ctx, database.DefaultRedisConfig())
+	if err != nil {
+		log.Fatalf("❌ Failed to connect to redis: %v", err)
+	}
+	defer rdb.Close()
+
+	lb := leaderboard.NewService(rdb)
+	if err := lb.Rebuild(ctx, database.NewLeaderboardRepository(db), time.Now()); err != nil {
+		log.Fatalf("❌ Rebuild failed: %v", err)
+	}
+
+	log.Println("✅ Leaderboards rebuilt")
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/mindly/api/internal/oidc/mockissuer"
+)
+
+// Локальный издатель OpenID Connect для входа через провайдера "mock" (oidc.DefaultConfig):
+//
+//	go run ./cmd/mock-oidc -addr :9090
+//
+// Затем GET /api/auth/oidc/mock/start, переход по authorization_url
+// (можно добавить &login_hint=user@example.com) и POST /api/auth/oidc/mock/callback
+// с code и state из адреса возврата.
+func main() {
+	addr := flag.String("addr", ":9090", "listen address")
+	issuer := flag.String("issuer", "http://localhost:9090", "issuer URL as seen by the API")
+	clientID := flag.String("client", "mindly-dev", "accepted client_id")
+	email := flag.String("email", "demo@mindly.ru", "default user email")
+	flag.Parse()
+
+	iss, err := mockissuer.New(*issuer, *clientID, mockissuer.Identity{
+		Subject:       "mock|" + *email,
+		Email:         *email,
+		EmailVerified: true,
+		Name:          "Mock User",
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to create issuer: %v", err)
+	}
+
+	log.Printf("🪪 Mock OIDC issuer %s listening on %s", *issuer, *addr)
+	if err := http.ListenAndServe(*addr, iss.Handler()); err != nil {
+		log.Fatalf("❌ Server error: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"strings"
+
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/leaderboard"
+)
+
+// Ручное начисление или списание баллов через журнал (admin_adjustment):
+//
+//	go run ./cmd/points-adjust -user <uuid> -amount -50 -note "отмена баллов за накрутку"
+//
+// Корректировка сразу применяется к рейтингам в Redis; если Redis недоступен,
+// рейтинги восстанавливаются командой leaderboard-rebuild.
+func main() {
+	userID := flag.String("user", "", "user id")
+	amount := flag.Int("amount", 0, "points to add (negative to deduct)")
+	note := flag.String("note", "", "reason of the adjustment")
+	flag.Parse()
+
+	if *userID == "" || *amount == 0 || strings.TrimSpace(*note) == "" {
+		flag.Usage()
+		log.Fatal("❌ -user, -amount and -note are required")
+	}
+
+	ctx := context.Background()
+
+	db, err := database.Connect(ctx, database.DefaultConfig())
+	if err != nil {
+		log.Fatalf("❌ Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	entry, err := database.NewPointsRepository(db).Adjust(ctx, *userID, *amount, strings.TrimSpace(*note))
+	if err != nil {
+		log.Fatalf("❌ Adjustment failed: %v", err)
+	}
+
+	rdb, err := database.ConnectRedis(ctx, database.DefaultRedisConfig())
+	if err != nil {
+		log.Printf("⚠️ Failed to connect to redis, run leaderboard-rebuild: %v", err)
+	} else {
+		defer rdb.Close()
+		lb := leaderboard.NewService(rdb)
+		if err := lb.AddPoints(ctx, entry.UserID, entry.Amount, entry.CreatedAt); err != nil {
+			log.Printf("⚠️ Failed to update leaderboards, run leaderboard-rebuild: %v", err)
+		}
+	}
+
+	log.Printf("✅ user=%s %+d баллов, баланс %d", entry.UserID, entry.Amount, entry.BalanceAfter)
+}
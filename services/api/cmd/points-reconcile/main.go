@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/mindly/api/internal/database"
+)
+
+// Сверка кэшированных баллов (users.score, user_stats.total_points,
+// user_video_progress.points_earned) с журналом points_ledger:
+//
+//	go run ./cmd/points-reconcile        # только показать расхождения
+//	go run ./cmd/points-reconcile -fix   # исправить кэши по журналу
+func main() {
+	fix := flag.Bool("fix", false, "rewrite cached counters from the ledger")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	db, err := database.Connect(ctx, database.DefaultConfig())
+	if err != nil {
+		log.Fatalf("❌ Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	repo := database.NewPointsRepository(db)
+
+	drift, err := repo.Drift(ctx)
+	if err != nil {
+		log.Fatalf("❌ Failed to check balances: %v", err)
+	}
+	for _, d := range drift {
+		log.Printf("⚠️ user=%s ledger=%d users.score=%d user_stats.total_points=%d",
+			d.UserID, d.LedgerBalance, d.Score, d.StatsTotal)
+	}
+
+	videoDrift, err := repo.VideoDrift(ctx)
+	if err != nil {
+		log.Fatalf("❌ Failed to check video points: %v", err)
+	}
+	for _, d := range videoDrift {
+		log.Printf("⚠️ user=%s video=%s ledger=%d points_earned=%d", d.UserID, d.VideoID, d.Ledger, d.Cached)
+	}
+
+	log.Printf("📒 Расхождений: пользователей %d, видео %d", len(drift), len(videoDrift))
+	if !*fix || len(drift)+len(videoDrift) == 0 {
+		return
+	}
+
+	result, err := repo.Reconcile(ctx)
+	if err != nil {
+		log.Fatalf("❌ Reconcile failed: %v", err)
+	}
+	log.Printf("✅ Исправлено: users %d, user_stats %d, user_video_progress %d",
+		result.Users, result.Stats, result.Videos)
+}
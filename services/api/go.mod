@@ -6,5 +6,13 @@ toolchain go1.24.11
 
 require (
 	github.com/lib/pq v1.10.9
+	github.com/redis/go-redis/v9 v9.9.0
 	golang.org/x/crypto v0.37.0
+	golang.org/x/text v0.24.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	golang.org/x/sys v0.32.0 // indirect
 )
@@ -0,0 +1,30 @@
+package account
+
+import "time"
+
+// Config - выгрузка персональных данных и удаление учетной записи
+type Config struct {
+	// Сколько ждать после запроса удаления: в это время удаление можно отменить
+	DeletionGracePeriod time.Duration
+	// Сколько хранится готовый архив выгрузки
+	ExportTTL time.Duration
+	// Новая выгрузка не чаще, чем раз в ExportInterval
+	ExportInterval time.Duration
+	// Выгрузка, которая собирается дольше, считается брошенной (перезапуск сервера)
+	// и собирается заново
+	ExportStaleAfter time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		DeletionGracePeriod: 30 * 24 * time.Hour,
+		ExportTTL:           7 * 24 * time.Hour,
+		ExportInterval:      24 * time.Hour,
+		ExportStaleAfter:    15 * time.Minute,
+	}
+}
+
+// ExportKey - ключ архива выгрузки в хранилище
+func ExportKey(userID, exportID string) string {
+	return "exports/" + userID + "/" + exportID + ".zip"
+}
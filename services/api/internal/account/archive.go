@@ -0,0 +1,130 @@
+package account
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+)
+
+// WriteArchive записывает выгрузку в zip: data.json со всеми данными
+// и CSV по каждому разделу для открытия в таблицах
+func WriteArchive(w io.Writer, data models.AccountData) error {
+	zw := zip.NewWriter(w)
+
+	f, err := create(zw, "data.json", data.GeneratedAt)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		return fmt.Errorf("write data.json: %w", err)
+	}
+
+	u := data.Profile
+	profile := [][]string{
+		{"id", "email", "username", "full_name", "role", "score", "current_streak", "best_streak", "email_verified_at", "avatar_url", "created_at"},
+		{u.ID, u.Email, u.Username, deref(u.FullName), string(u.Role), strconv.Itoa(u.Score), strconv.Itoa(u.CurrentStreak),
+			strconv.Itoa(u.BestStreak), timeOrEmpty(u.EmailVerifiedAt), deref(u.AvatarURL), formatTime(u.CreatedAt)},
+	}
+	if err := writeCSV(zw, "profile.csv", data.GeneratedAt, profile); err != nil {
+		return err
+	}
+
+	progress := [][]string{{"video_id", "video_title", "is_watched", "watched_at", "quiz_attempted", "quiz_correct", "points_earned", "interaction_date"}}
+	for _, p := range data.Progress {
+		progress = append(progress, []string{
+			p.VideoID, p.VideoTitle, strconv.FormatBool(p.IsWatched), timeOrEmpty(p.WatchedAt),
+			strconv.FormatBool(p.QuizAttempted), strconv.FormatBool(p.QuizCorrect), strconv.Itoa(p.PointsEarned), p.InteractionDate,
+		})
+	}
+	if err := writeCSV(zw, "progress.csv", data.GeneratedAt, progress); err != nil {
+		return err
+	}
+
+	answers := [][]string{{"id", "question_id", "video_id", "answer", "is_correct", "points_earned", "answered_at"}}
+	for _, a := range data.QuizAnswers {
+		answers = append(answers, []string{
+			strconv.FormatInt(a.ID, 10), a.QuestionID, a.VideoID, string(a.Answer),
+			strconv.FormatBool(a.IsCorrect), strconv.Itoa(a.PointsEarned), formatTime(a.AnsweredAt),
+		})
+	}
+	if err := writeCSV(zw, "quiz_answers.csv", data.GeneratedAt, answers); err != nil {
+		return err
+	}
+
+	points := [][]string{{"id", "amount", "reason", "reference", "video_id", "note", "balance_after", "created_at"}}
+	for _, p := range data.Points {
+		points = append(points, []string{
+			strconv.FormatInt(p.ID, 10), strconv.Itoa(p.Amount), string(p.Reason), p.Reference, p.VideoID, p.Note,
+			strconv.Itoa(p.BalanceAfter), formatTime(p.CreatedAt),
+		})
+	}
+	if err := writeCSV(zw, "points.csv", data.GeneratedAt, points); err != nil {
+		return err
+	}
+
+	comments := [][]string{{"id", "video_id", "parent_id", "body", "status", "edited_at", "deleted_at", "created_at"}}
+	for _, c := range data.Comments {
+		comments = append(comments, []string{
+			c.ID, c.VideoID, c.ParentID, c.Body, string(c.Status),
+			timeOrEmpty(c.EditedAt), timeOrEmpty(c.DeletedAt), formatTime(c.CreatedAt),
+		})
+	}
+	if err := writeCSV(zw, "comments.csv", data.GeneratedAt, comments); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("close archive: %w", err)
+	}
+	return nil
+}
+
+func create(zw *zip.Writer, name string, modified time.Time) (io.Writer, error) {
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return nil, fmt.Errorf("create %s: %w", name, err)
+	}
+	return f, nil
+}
+
+func writeCSV(zw *zip.Writer, name string, modified time.Time, records [][]string) error {
+	f, err := create(zw, name, modified)
+	if err != nil {
+		return err
+	}
+	// Сигнатура UTF-8: иначе Excel открывает кириллицу в неверной кодировке
+	if _, err := io.WriteString(f, "\ufeff"); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	cw := csv.NewWriter(f)
+	if err := cw.WriteAll(records); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func timeOrEmpty(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
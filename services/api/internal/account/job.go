@@ -0,0 +1,177 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/password"
+	"github.com/mindly/api/internal/storage"
+)
+
+// Store - очередь выгрузок и учетные записи, ожидающие удаления
+type Store interface {
+	// ClaimExport берет в работу следующую выгрузку; ok=false - очередь пуста.
+	// Выгрузки, начатые раньше staleBefore, берутся повторно
+	ClaimExport(ctx context.Context, staleBefore, at time.Time) (e models.DataExport, ok bool, err error)
+	AccountData(ctx context.Context, userID string, at time.Time) (models.AccountData, error)
+	CompleteExport(ctx context.Context, exportID, fileKey string, size int64, expiresAt, at time.Time) error
+	FailExport(ctx context.Context, exportID, reason string, at time.Time) error
+	// ExpireExports помечает просроченные архивы и возвращает их ключи в хранилище
+	ExpireExports(ctx context.Context, now time.Time) ([]string, error)
+	DueDeletions(ctx context.Context, now time.Time, limit int) ([]string, error)
+	// DeleteAccount удаляет или обезличивает учетную запись; ok=false - удаление
+	// успели отменить
+	DeleteAccount(ctx context.Context, userID, lockedHash string, at time.Time) (d models.DeletedAccount, ok bool, err error)
+}
+
+// Leaderboard - рейтинги, из которых убираются удаленные пользователи
+type Leaderboard interface {
+	Remove(ctx context.Context, userID string) error
+}
+
+// Job собирает запрошенные выгрузки, удаляет просроченные архивы
+// и учетные записи, у которых истек срок на отмену удаления
+type Job struct {
+	store Store
+	files storage.Storage
+	lb    Leaderboard
+	cfg   Config
+	wake  chan struct{}
+}
+
+func NewJob(store Store, files storage.Storage, lb Leaderboard, cfg Config) *Job {
+	return &Job{store: store, files: files, lb: lb, cfg: cfg, wake: make(chan struct{}, 1)}
+}
+
+// Trigger запускает обработку, не дожидаясь интервала (новая выгрузка в очереди)
+func (j *Job) Trigger() {
+	select {
+	case j.wake <- struct{}{}:
+	default:
+	}
+}
+
+// RunOnce обрабатывает очередь выгрузок, просроченные архивы и удаления
+func (j *Job) RunOnce(ctx context.Context) error {
+	for {
+		built, err := j.buildNextExport(ctx)
+		if err != nil {
+			return err
+		}
+		if !built {
+			break
+		}
+	}
+
+	now := time.Now()
+	keys, err := j.store.ExpireExports(ctx, now)
+	if err != nil {
+		return fmt.Errorf("expire exports: %w", err)
+	}
+	for _, key := range keys {
+		j.deleteFile(ctx, key)
+	}
+
+	ids, err := j.store.DueDeletions(ctx, now, 100)
+	if err != nil {
+		return fmt.Errorf("load due deletions: %w", err)
+	}
+	for _, id := range ids {
+		d, ok, err := j.store.DeleteAccount(ctx, id, password.NoPassword, time.Now())
+		if err != nil {
+			return fmt.Errorf("delete account %s: %w", id, err)
+		}
+		if !ok {
+			continue
+		}
+		for _, key := range d.FileKeys {
+			j.deleteFile(ctx, key)
+		}
+		// Рейтинг восстанавливается командой leaderboard-rebuild, поэтому ошибка не фатальна
+		if err := j.lb.Remove(ctx, id); err != nil {
+			log.Printf("⚠️ Failed to remove user %s from leaderboards: %v", id, err)
+		}
+		log.Printf("🗑 Учетная запись %s удалена (%s)", id, d.Mode)
+	}
+	return nil
+}
+
+// buildNextExport собирает одну выгрузку; false - очередь пуста
+func (j *Job) buildNextExport(ctx context.Context) (bool, error) {
+	now := time.Now()
+	e, ok, err := j.store.ClaimExport(ctx, now.Add(-j.cfg.ExportStaleAfter), now)
+	if err != nil {
+		return false, fmt.Errorf("claim export: %w", err)
+	}
+	if !ok {
+		return false, nil
+	}
+
+	key, size, err := j.writeExport(ctx, e, now)
+	if err != nil {
+		// Ошибка одной выгрузки не останавливает очередь: пользователь запросит новую
+		log.Printf("⚠️ Export %s for user %s failed: %v", e.ID, e.UserID, err)
+		if err := j.store.FailExport(ctx, e.ID, err.Error(), time.Now()); err != nil {
+			return false, fmt.Errorf("fail export %s: %w", e.ID, err)
+		}
+		return true, nil
+	}
+
+	done := time.Now()
+	if err := j.store.CompleteExport(ctx, e.ID, key, size, done.Add(j.cfg.ExportTTL), done); err != nil {
+		j.deleteFile(ctx, key)
+		return false, fmt.Errorf("complete export %s: %w", e.ID, err)
+	}
+	log.Printf("📦 Выгрузка %s готова: user=%s, %d байт", e.ID, e.UserID, size)
+	return true, nil
+}
+
+func (j *Job) writeExport(ctx context.Context, e models.DataExport, now time.Time) (string, int64, error) {
+	data, err := j.store.AccountData(ctx, e.UserID, now)
+	if err != nil {
+		return "", 0, fmt.Errorf("load account data: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteArchive(&buf, data); err != nil {
+		return "", 0, err
+	}
+	size := int64(buf.Len())
+
+	key := ExportKey(e.UserID, e.ID)
+	if err := j.files.Put(ctx, key, "application/zip", &buf); err != nil {
+		return "", 0, fmt.Errorf("store archive: %w", err)
+	}
+	return key, size, nil
+}
+
+// deleteFile удаляет файл из хранилища; ошибка только логируется
+func (j *Job) deleteFile(ctx context.Context, key string) {
+	if err := j.files.Delete(ctx, key); err != nil {
+		log.Printf("⚠️ Failed to delete stored file %s: %v", key, err)
+	}
+}
+
+// Start запускает обработку сразу и затем с заданным интервалом или по Trigger,
+// пока не отменен ctx
+func (j *Job) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.RunOnce(ctx); err != nil {
+			log.Printf("⚠️ Account job error: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-j.wake:
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package achievements
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+)
+
+// Store - хранилище достижений и выданных бейджей
+type Store interface {
+	ActiveAchievements(ctx context.Context) ([]models.Achievement, error)
+	UnlockedCodes(ctx context.Context, userID string) (map[string]bool, error)
+	// Unlock выдает достижение вместе с его баллами; false, если оно уже было выдано
+	Unlock(ctx context.Context, userID string, a models.Achievement, at time.Time) (bool, error)
+}
+
+// Engine проверяет правила достижений после событий прогресса пользователя
+type Engine struct {
+	store   Store
+	metrics Metrics
+}
+
+func NewEngine(store Store, metrics Metrics) *Engine {
+	return &Engine{store: store, metrics: metrics}
+}
+
+// Evaluate проверяет еще не полученные достижения и выдает выполненные.
+// Повторный вызов ничего не выдает повторно. Возвращает новые достижения.
+func (e *Engine) Evaluate(ctx context.Context, userID string) ([]models.Achievement, error) {
+	all, err := e.store.ActiveAchievements(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load achievements: %w", err)
+	}
+	unlocked, err := e.store.UnlockedCodes(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("load unlocked achievements: %w", err)
+	}
+
+	ev := &evaluator{metrics: e.metrics, userID: userID, cache: map[string]float64{}}
+	now := time.Now().UTC()
+	var awarded []models.Achievement
+	for _, a := range all {
+		if unlocked[a.Code] {
+			continue
+		}
+
+		rule, err := ParseRule(a.Rule)
+		if err != nil {
+			// Ошибка в одном правиле не должна мешать остальным
+			log.Printf("⚠️ Achievement %s: %v", a.Code, err)
+			continue
+		}
+		ok, err := ev.eval(ctx, rule)
+		if err != nil {
+			log.Printf("⚠️ Achievement %s: %v", a.Code, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		isNew, err := e.store.Unlock(ctx, userID, a, now)
+		if err != nil {
+			return awarded, fmt.Errorf("unlock %s: %w", a.Code, err)
+		}
+		if isNew {
+			unlockedAt := now
+			a.UnlockedAt = &unlockedAt
+			awarded = append(awarded, a)
+			log.Printf("🏅 Достижение %s получено пользователем %s", a.Code, userID)
+		}
+	}
+	return awarded, nil
+}
@@ -0,0 +1,118 @@
+package achievements
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrUnknownMetric = errors.New("unknown metric")
+
+// Rule - декларативное правило выдачи достижения, хранится в achievements.rule:
+//
+//	{"metric": "streak_days", "gte": 7}
+//	{"metric": "correct_answers", "params": {"expertise_area": "IT"}, "gte": 10}
+//	{"all": [{"metric": "videos_watched", "gte": 50}, {"metric": "total_points", "gte": 100}]}
+//
+// Правило с metric выполняется, когда значение метрики не меньше gte.
+// all/any объединяют вложенные правила через И/ИЛИ.
+type Rule struct {
+	Metric string            `json:"metric,omitempty"`
+	Params map[string]string `json:"params,omitempty"`
+	Gte    float64           `json:"gte,omitempty"`
+	All    []Rule            `json:"all,omitempty"`
+	Any    []Rule            `json:"any,omitempty"`
+}
+
+// Metrics вычисляет метрики пользователя по имени и параметрам
+type Metrics interface {
+	Metric(ctx context.Context, userID, name string, params map[string]string) (float64, error)
+}
+
+// ParseRule разбирает и проверяет правило
+func ParseRule(raw json.RawMessage) (Rule, error) {
+	var r Rule
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return r, fmt.Errorf("invalid rule: %w", err)
+	}
+	return r, r.validate()
+}
+
+func (r Rule) validate() error {
+	kinds := 0
+	if r.Metric != "" {
+		kinds++
+	}
+	if len(r.All) > 0 {
+		kinds++
+	}
+	if len(r.Any) > 0 {
+		kinds++
+	}
+	if kinds != 1 {
+		return errors.New("invalid rule: exactly one of metric, all, any is required")
+	}
+	for _, sub := range r.All {
+		if err := sub.validate(); err != nil {
+			return err
+		}
+	}
+	for _, sub := range r.Any {
+		if err := sub.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evaluator вычисляет правила, запоминая уже посчитанные метрики
+type evaluator struct {
+	metrics Metrics
+	userID  string
+	cache   map[string]float64
+}
+
+func (e *evaluator) eval(ctx context.Context, r Rule) (bool, error) {
+	switch {
+	case len(r.All) > 0:
+		for _, sub := range r.All {
+			ok, err := e.eval(ctx, sub)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case len(r.Any) > 0:
+		for _, sub := range r.Any {
+			ok, err := e.eval(ctx, sub)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	default:
+		value, err := e.metric(ctx, r.Metric, r.Params)
+		if err != nil {
+			return false, err
+		}
+		return value >= r.Gte, nil
+	}
+}
+
+func (e *evaluator) metric(ctx context.Context, name string, params map[string]string) (float64, error) {
+	key := name
+	if len(params) > 0 {
+		p, _ := json.Marshal(params) // ключи map сериализуются отсортированными
+		key += string(p)
+	}
+	if v, ok := e.cache[key]; ok {
+		return v, nil
+	}
+	v, err := e.metrics.Metric(ctx, e.userID, name, params)
+	if err != nil {
+		return 0, err
+	}
+	e.cache[key] = v
+	return v, nil
+}
@@ -0,0 +1,139 @@
+package calibration
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+)
+
+// Config - пороги калибровки
+type Config struct {
+	// Минимум первых попыток, после которого оценке вопроса можно доверять
+	MinSamples int
+	// Сложность выше/ниже порога помечается как слишком сложный/легкий вопрос
+	TooHardDifficulty float64
+	TooEasyDifficulty float64
+	// Различающая способность ниже порога - вопрос двусмысленный:
+	// сильные пользователи отвечают на него не лучше слабых
+	AmbiguousDiscrimination float64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MinSamples:              30,
+		TooHardDifficulty:       2.0,
+		TooEasyDifficulty:       -2.0,
+		AmbiguousDiscrimination: 0.3,
+	}
+}
+
+// Флаги вопросов для автора
+const (
+	FlagTooEasy   = "too_easy"
+	FlagTooHard   = "too_hard"
+	FlagAmbiguous = "ambiguous"
+)
+
+// Points масштабирует базовые баллы автора по калиброванной сложности:
+// сложность 0 - базовые баллы, каждые +2 удваивают, каждые -2 уменьшают вдвое (от x0.5 до x2).
+// Без калибровки возвращаются базовые баллы.
+func Points(base int, difficulty *float64) int {
+	if difficulty == nil || base <= 0 {
+		return base
+	}
+	multiplier := clamp(math.Pow(2, *difficulty/2), 0.5, 2)
+	return max(1, int(math.Round(float64(base)*multiplier)))
+}
+
+// Flags возвращает замечания к вопросу по его калибровке
+func Flags(cfg Config, c models.QuestionCalibration) []string {
+	flags := []string{}
+	if c.Samples < cfg.MinSamples || c.Difficulty == nil || c.Discrimination == nil {
+		return flags
+	}
+	if *c.Difficulty <= cfg.TooEasyDifficulty {
+		flags = append(flags, FlagTooEasy)
+	}
+	if *c.Difficulty >= cfg.TooHardDifficulty {
+		flags = append(flags, FlagTooHard)
+	}
+	if *c.Discrimination < cfg.AmbiguousDiscrimination {
+		flags = append(flags, FlagAmbiguous)
+	}
+	return flags
+}
+
+// Store - источник ответов и хранилище результатов калибровки
+type Store interface {
+	ListFirstAttempts(ctx context.Context) ([]models.AnswerObservation, error)
+	SaveCalibrations(ctx context.Context, calibrations []models.QuestionCalibration) error
+}
+
+// Job периодически пересчитывает сложность вопросов
+type Job struct {
+	store Store
+	cfg   Config
+}
+
+func NewJob(store Store, cfg Config) *Job {
+	return &Job{store: store, cfg: cfg}
+}
+
+// RunOnce подбирает параметры модели и сохраняет их; возвращает число откалиброванных вопросов
+func (j *Job) RunOnce(ctx context.Context) (int, error) {
+	observations, err := j.store.ListFirstAttempts(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("load answers: %w", err)
+	}
+
+	now := time.Now().UTC()
+	estimates := Fit(observations)
+	calibrations := make([]models.QuestionCalibration, 0, len(estimates))
+	calibrated := 0
+	for questionID, e := range estimates {
+		c := models.QuestionCalibration{
+			QuestionID:   questionID,
+			Samples:      e.Samples,
+			CorrectRate:  e.CorrectRate,
+			CalibratedAt: now,
+		}
+		// Оценки по малой выборке не сохраняем, чтобы не менять баллы по шуму
+		if e.Samples >= j.cfg.MinSamples {
+			difficulty, discrimination := e.Difficulty, e.Discrimination
+			c.Difficulty = &difficulty
+			c.Discrimination = &discrimination
+			calibrated++
+		}
+		calibrations = append(calibrations, c)
+	}
+
+	if err := j.store.SaveCalibrations(ctx, calibrations); err != nil {
+		return 0, fmt.Errorf("save calibrations: %w", err)
+	}
+	return calibrated, nil
+}
+
+// Start запускает калибровку сразу и затем с заданным интервалом, пока не отменен ctx
+func (j *Job) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		calibrated, err := j.RunOnce(ctx)
+		if err != nil {
+			log.Printf("⚠️ Calibration job error: %v", err)
+		} else {
+			log.Printf("🎯 Calibration job: откалибровано вопросов: %d", calibrated)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
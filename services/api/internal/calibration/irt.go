@@ -0,0 +1,185 @@
+package calibration
+
+import (
+	"math"
+
+	"github.com/mindly/api/internal/models"
+)
+
+// Двухпараметрическая модель IRT (2PL):
+//
+//	P(правильно | θ) = 1 / (1 + exp(-a·(θ - b)))
+//
+// θ - способность пользователя, b - сложность вопроса, a - различающая способность.
+// Параметры вопросов подбираются маргинальным методом максимального правдоподобия
+// (EM по сетке значений θ ~ N(0, 1)): пользователь отвечает лишь на несколько вопросов,
+// и совместная оценка θ по тем же ответам сильно завышала бы a.
+// Нормальные априорные распределения не дают оценкам уйти в бесконечность
+// у вопросов, где все ответы правильные или все неправильные.
+const (
+	emIterations     = 50
+	mStepIterations  = 5
+	quadraturePoints = 31
+	quadratureRange  = 4.0
+
+	difficultyPriorSD     = 2.0
+	discriminationPriorSD = 1.0
+	discriminationPrior   = 1.0
+
+	maxAbs            = 4.0
+	minDiscrimination = -2.0
+	maxDiscrimination = 4.0
+	// Ограничение шага Ньютона в M-шаге
+	maxStep = 0.5
+)
+
+// ItemEstimate - оценка параметров одного вопроса
+type ItemEstimate struct {
+	Difficulty     float64
+	Discrimination float64
+	Samples        int
+	CorrectRate    float64
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+func clamp(x, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, x))
+}
+
+// step - шаг Ньютона g/h, ограниченный по модулю maxStep
+func step(g, h float64) float64 {
+	return clamp(g/h, -maxStep, maxStep)
+}
+
+type response struct {
+	item int
+	y    float64
+}
+
+// Fit оценивает параметры вопросов по наблюдениям (первые попытки пользователей)
+func Fit(observations []models.AnswerObservation) map[string]ItemEstimate {
+	userIdx := map[string]int{}
+	items := map[string]int{}
+	var users [][]response
+	for _, o := range observations {
+		u, ok := userIdx[o.UserID]
+		if !ok {
+			u = len(users)
+			userIdx[o.UserID] = u
+			users = append(users, nil)
+		}
+		i, ok := items[o.QuestionID]
+		if !ok {
+			i = len(items)
+			items[o.QuestionID] = i
+		}
+		y := 0.0
+		if o.Correct {
+			y = 1
+		}
+		users[u] = append(users[u], response{i, y})
+	}
+
+	// Сетка значений θ и логарифмы весов стандартного нормального распределения
+	nodes := make([]float64, quadraturePoints)
+	logPrior := make([]float64, quadraturePoints)
+	for k := range nodes {
+		nodes[k] = -quadratureRange + 2*quadratureRange*float64(k)/float64(quadraturePoints-1)
+		logPrior[k] = -nodes[k] * nodes[k] / 2
+	}
+
+	a := make([]float64, len(items))
+	b := make([]float64, len(items))
+	samples := make([]int, len(items))
+	correct := make([]float64, len(items))
+	for _, rs := range users {
+		for _, r := range rs {
+			samples[r.item]++
+			correct[r.item] += r.y
+		}
+	}
+	for i := range a {
+		a[i] = discriminationPrior
+		// Начальная сложность - логит доли неправильных ответов (со сглаживанием)
+		p := (correct[i] + 0.5) / (float64(samples[i]) + 1)
+		b[i] = clamp(-math.Log(p/(1-p)), -maxAbs, maxAbs)
+	}
+
+	posterior := make([]float64, quadraturePoints)
+	for iter := 0; iter < emIterations; iter++ {
+		// E-шаг: ожидаемое число ответов (n) и правильных ответов (r) в каждой точке сетки
+		n := make([][]float64, len(items))
+		r := make([][]float64, len(items))
+		for i := range n {
+			n[i] = make([]float64, quadraturePoints)
+			r[i] = make([]float64, quadraturePoints)
+		}
+
+		for _, rs := range users {
+			maxLog := math.Inf(-1)
+			for k, theta := range nodes {
+				l := logPrior[k]
+				for _, resp := range rs {
+					p := sigmoid(a[resp.item] * (theta - b[resp.item]))
+					if resp.y > 0 {
+						l += math.Log(p + 1e-12)
+					} else {
+						l += math.Log(1 - p + 1e-12)
+					}
+				}
+				posterior[k] = l
+				maxLog = math.Max(maxLog, l)
+			}
+			var total float64
+			for k := range posterior {
+				posterior[k] = math.Exp(posterior[k] - maxLog)
+				total += posterior[k]
+			}
+			for k := range posterior {
+				w := posterior[k] / total
+				for _, resp := range rs {
+					n[resp.item][k] += w
+					r[resp.item][k] += w * resp.y
+				}
+			}
+		}
+
+		// M-шаг: несколько шагов Ньютона по (a, b) каждого вопроса
+		for i := range a {
+			for s := 0; s < mStepIterations; s++ {
+				var ga, ha, gb, hb float64
+				for k, theta := range nodes {
+					x := theta - b[i]
+					p := sigmoid(a[i] * x)
+					e := r[i][k] - n[i][k]*p
+					w := n[i][k] * p * (1 - p)
+					ga += e * x
+					ha -= w * x * x
+					gb -= a[i] * e
+					hb -= a[i] * a[i] * w
+				}
+				ga -= (a[i] - discriminationPrior) / (discriminationPriorSD * discriminationPriorSD)
+				ha -= 1 / (discriminationPriorSD * discriminationPriorSD)
+				gb -= b[i] / (difficultyPriorSD * difficultyPriorSD)
+				hb -= 1 / (difficultyPriorSD * difficultyPriorSD)
+
+				a[i] = clamp(a[i]-step(ga, ha), minDiscrimination, maxDiscrimination)
+				b[i] = clamp(b[i]-step(gb, hb), -maxAbs, maxAbs)
+			}
+		}
+	}
+
+	result := make(map[string]ItemEstimate, len(items))
+	for id, i := range items {
+		result[id] = ItemEstimate{
+			Difficulty:     math.Round(b[i]*1000) / 1000,
+			Discrimination: math.Round(a[i]*1000) / 1000,
+			Samples:        samples[i],
+			CorrectRate:    correct[i] / float64(samples[i]),
+		}
+	}
+	return result
+}
@@ -0,0 +1,571 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+)
+
+// ErrExportTooFrequent - предыдущая выгрузка запрошена недавно
+var ErrExportTooFrequent = errors.New("data export requested too recently")
+
+// AccountRepository - выгрузка персональных данных и удаление учетной записи
+type AccountRepository struct {
+	db *sql.DB
+}
+
+func NewAccountRepository(db *sql.DB) *AccountRepository {
+	return &AccountRepository{db: db}
+}
+
+const exportColumns = `id::text, user_id::text, status, COALESCE(file_key, ''), size_bytes, created_at, completed_at, expires_at`
+
+// RequestExport ставит выгрузку в очередь. Если выгрузка уже собирается, возвращается она
+// (created=false). Новая выгрузка - не чаще раза в interval, иначе ErrExportTooFrequent
+// вместе с предыдущей выгрузкой
+func (r *AccountRepository) RequestExport(ctx context.Context, userID string, interval time.Duration, at time.Time) (e models.DataExport, created bool, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return e, false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Блокировка пользователя: два одновременных запроса не создадут две выгрузки
+	err = tx.QueryRowContext(ctx,
+		`SELECT id::text FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, userID,
+	).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return e, false, ErrNotFound
+	}
+	if err != nil {
+		return e, false, fmt.Errorf("lock user: %w", err)
+	}
+
+	// Последняя выгрузка, кроме неудачных: неудачную можно сразу запросить снова
+	last, err := scanExport(tx.QueryRowContext(ctx, `
+		SELECT `+exportColumns+` FROM data_exports
+		WHERE user_id = $1 AND status <> 'failed'
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, userID))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return e, false, err
+	}
+	if err == nil {
+		if last.Status == models.ExportPending || last.Status == models.ExportProcessing {
+			return last, false, nil
+		}
+		if at.Before(last.CreatedAt.Add(interval)) {
+			return last, false, ErrExportTooFrequent
+		}
+	}
+
+	e, err = scanExport(tx.QueryRowContext(ctx, `
+		INSERT INTO data_exports (user_id, created_at) VALUES ($1, $2)
+		RETURNING `+exportColumns,
+		userID, at))
+	if err != nil {
+		return e, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return e, false, fmt.Errorf("commit: %w", err)
+	}
+	return e, true, nil
+}
+
+// ListExports возвращает последние выгрузки пользователя
+func (r *AccountRepository) ListExports(ctx context.Context, userID string, limit int) ([]models.DataExport, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+exportColumns+` FROM data_exports
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	exports := []models.DataExport{}
+	for rows.Next() {
+		e, err := scanExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return exports, nil
+}
+
+// GetExport возвращает выгрузку пользователя; чужая выгрузка - ErrNotFound
+func (r *AccountRepository) GetExport(ctx context.Context, userID, exportID string) (models.DataExport, error) {
+	return scanExport(r.db.QueryRowContext(ctx, `
+		SELECT `+exportColumns+` FROM data_exports WHERE id = $1 AND user_id = $2
+	`, exportID, userID))
+}
+
+// ClaimExport берет в работу самую старую выгрузку из очереди
+func (r *AccountRepository) ClaimExport(ctx context.Context, staleBefore, at time.Time) (models.DataExport, bool, error) {
+	e, err := scanExport(r.db.QueryRowContext(ctx, `
+		UPDATE data_exports SET status = 'processing', started_at = $2
+		WHERE id = (
+			SELECT id FROM data_exports
+			WHERE status = 'pending' OR (status = 'processing' AND started_at < $1)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+exportColumns,
+		staleBefore, at))
+	if errors.Is(err, ErrNotFound) {
+		return e, false, nil
+	}
+	if err != nil {
+		return e, false, err
+	}
+	return e, true, nil
+}
+
+// CompleteExport отмечает архив готовым к скачиванию до expiresAt
+func (r *AccountRepository) CompleteExport(ctx context.Context, exportID, fileKey string, size int64, expiresAt, at time.Time) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE data_exports
+		SET status = 'ready', file_key = $2, size_bytes = $3, expires_at = $4, completed_at = $5, error = NULL
+		WHERE id = $1
+	`, exportID, fileKey, size, expiresAt, at)
+	if err != nil {
+		return fmt.Errorf("update export: %w", err)
+	}
+	return expectOneRow(res)
+}
+
+// FailExport сохраняет причину неудачной выгрузки (видна только в базе)
+func (r *AccountRepository) FailExport(ctx context.Context, exportID, reason string, at time.Time) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE data_exports SET status = 'failed', error = $2, completed_at = $3 WHERE id = $1
+	`, exportID, reason, at)
+	if err != nil {
+		return fmt.Errorf("update export: %w", err)
+	}
+	return expectOneRow(res)
+}
+
+// ExpireExports помечает просроченные архивы и возвращает ключи их файлов
+func (r *AccountRepository) ExpireExports(ctx context.Context, now time.Time) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		WITH expired AS (
+			SELECT id, file_key FROM data_exports
+			WHERE status = 'ready' AND expires_at <= $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE data_exports d SET status = 'expired', file_key = NULL
+		FROM expired e
+		WHERE d.id = e.id
+		RETURNING e.file_key
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return scanKeys(rows)
+}
+
+// AccountData собирает все данные пользователя для выгрузки
+func (r *AccountRepository) AccountData(ctx context.Context, userID string, at time.Time) (models.AccountData, error) {
+	data := models.AccountData{
+		GeneratedAt: at,
+		Progress:    []models.ExportProgress{},
+		QuizAnswers: []models.ExportQuizAnswer{},
+		Points:      []models.PointsEntry{},
+		Comments:    []models.ExportComment{},
+	}
+
+	var err error
+	data.Profile, err = scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, userID))
+	if err != nil {
+		return data, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT p.video_id::text, v.title, COALESCE(p.is_watched, FALSE), p.watched_at,
+			COALESCE(p.quiz_attempted, FALSE), COALESCE(p.quiz_correct, FALSE),
+			COALESCE(p.points_earned, 0), TO_CHAR(p.interaction_date, 'YYYY-MM-DD')
+		FROM user_video_progress p
+		JOIN videos v ON v.id = p.video_id
+		WHERE p.user_id = $1
+		ORDER BY p.interaction_date, p.video_id
+	`, userID)
+	if err != nil {
+		return data, fmt.Errorf("query progress: %w", err)
+	}
+	err = scanRows(rows, func(row rowScanner) error {
+		var p models.ExportProgress
+		var watchedAt sql.NullTime
+		err := row.Scan(&p.VideoID, &p.VideoTitle, &p.IsWatched, &watchedAt,
+			&p.QuizAttempted, &p.QuizCorrect, &p.PointsEarned, &p.InteractionDate)
+		if watchedAt.Valid {
+			p.WatchedAt = &watchedAt.Time
+		}
+		data.Progress = append(data.Progress, p)
+		return err
+	})
+	if err != nil {
+		return data, err
+	}
+
+	rows, err = r.db.QueryContext(ctx, `
+		SELECT a.id, a.question_id::text, q.video_id::text, a.answer, a.is_correct, a.points_earned, a.answered_at
+		FROM quiz_answers a
+		JOIN quiz_questions q ON q.id = a.question_id
+		WHERE a.user_id = $1
+		ORDER BY a.answered_at, a.id
+	`, userID)
+	if err != nil {
+		return data, fmt.Errorf("query quiz answers: %w", err)
+	}
+	err = scanRows(rows, func(row rowScanner) error {
+		var a models.ExportQuizAnswer
+		err := row.Scan(&a.ID, &a.QuestionID, &a.VideoID, &a.Answer, &a.IsCorrect, &a.PointsEarned, &a.AnsweredAt)
+		data.QuizAnswers = append(data.QuizAnswers, a)
+		return err
+	})
+	if err != nil {
+		return data, err
+	}
+
+	rows, err = r.db.QueryContext(ctx, `
+		SELECT id, user_id::text, amount, reason, COALESCE(reference, ''), COALESCE(video_id::text, ''),
+			COALESCE(note, ''), balance_after, created_at
+		FROM points_ledger
+		WHERE user_id = $1
+		ORDER BY id
+	`, userID)
+	if err != nil {
+		return data, fmt.Errorf("query points: %w", err)
+	}
+	err = scanRows(rows, func(row rowScanner) error {
+		var p models.PointsEntry
+		err := row.Scan(&p.ID, &p.UserID, &p.Amount, &p.Reason, &p.Reference, &p.VideoID,
+			&p.Note, &p.BalanceAfter, &p.CreatedAt)
+		data.Points = append(data.Points, p)
+		return err
+	})
+	if err != nil {
+		return data, err
+	}
+
+	rows, err = r.db.QueryContext(ctx, `
+		SELECT id::text, video_id::text, COALESCE(parent_id::text, ''), body, moderation_status,
+			edited_at, deleted_at, created_at
+		FROM comments
+		WHERE user_id = $1
+		ORDER BY created_at, id
+	`, userID)
+	if err != nil {
+		return data, fmt.Errorf("query comments: %w", err)
+	}
+	err = scanRows(rows, func(row rowScanner) error {
+		var c models.ExportComment
+		var editedAt, deletedAt sql.NullTime
+		err := row.Scan(&c.ID, &c.VideoID, &c.ParentID, &c.Body, &c.Status, &editedAt, &deletedAt, &c.CreatedAt)
+		if editedAt.Valid {
+			c.EditedAt = &editedAt.Time
+		}
+		if deletedAt.Valid {
+			c.DeletedAt = &deletedAt.Time
+		}
+		data.Comments = append(data.Comments, c)
+		return err
+	})
+	if err != nil {
+		return data, err
+	}
+
+	return data, nil
+}
+
+// ScheduleDeletion запрашивает удаление учетной записи в момент scheduledAt
+// (повторный запрос срок не переносит) и закрывает все сессии, кроме keepSessionID
+func (r *AccountRepository) ScheduleDeletion(ctx context.Context, userID, keepSessionID string, scheduledAt, at time.Time) (models.AccountDeletion, error) {
+	var d models.AccountDeletion
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return d, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE users
+		SET deletion_scheduled_at = COALESCE(deletion_scheduled_at, $2), updated_at = $3
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING deletion_scheduled_at
+	`, userID, scheduledAt, at).Scan(&d.ScheduledAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return d, ErrNotFound
+	}
+	if err != nil {
+		return d, fmt.Errorf("schedule deletion: %w", err)
+	}
+
+	d.RevokedSessions, err = revokeSessions(ctx, tx, userID, keepSessionID, at)
+	if err != nil {
+		return d, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return d, fmt.Errorf("commit: %w", err)
+	}
+	return d, nil
+}
+
+// CancelDeletion отменяет запрошенное удаление; false - удаление не было запрошено
+func (r *AccountRepository) CancelDeletion(ctx context.Context, userID string, at time.Time) (bool, error) {
+	var scheduled bool
+	err := r.db.QueryRowContext(ctx, `
+		WITH cancelled AS (
+			UPDATE users SET deletion_scheduled_at = NULL, updated_at = $2
+			WHERE id = $1 AND deleted_at IS NULL AND deletion_scheduled_at IS NOT NULL
+			RETURNING id
+		)
+		SELECT EXISTS (SELECT 1 FROM cancelled)
+		FROM users WHERE id = $1 AND deleted_at IS NULL
+	`, userID, at).Scan(&scheduled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, ErrNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("cancel deletion: %w", err)
+	}
+	return scheduled, nil
+}
+
+// DueDeletions возвращает учетные записи, срок отмены удаления которых истек
+func (r *AccountRepository) DueDeletions(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id::text FROM users
+		WHERE deletion_scheduled_at <= $1 AND deleted_at IS NULL
+		ORDER BY deletion_scheduled_at
+		LIMIT $2
+	`, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return scanKeys(rows)
+}
+
+// DeleteAccount удаляет учетную запись, если удаление не отменили (ok=false).
+// Строка users удаляется вместе с данными по ON DELETE CASCADE, если на нее не
+// опирается общая статистика. Иначе она обезличивается: ответы, прогресс и баллы
+// остаются для калибровки вопросов и уровней доверия авторов, а все личное
+// (контакты, входы, друзья, подписки, тексты комментариев) удаляется.
+// lockedHash - значение password_hash, по которому нельзя войти
+func (r *AccountRepository) DeleteAccount(ctx context.Context, userID, lockedHash string, at time.Time) (models.DeletedAccount, bool, error) {
+	d := models.DeletedAccount{FileKeys: []string{}}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return d, false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var avatarKey sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT avatar_key FROM users
+		WHERE id = $1 AND deleted_at IS NULL AND deletion_scheduled_at <= $2
+		FOR UPDATE
+	`, userID, at).Scan(&avatarKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return d, false, nil
+	}
+	if err != nil {
+		return d, false, fmt.Errorf("lock user: %w", err)
+	}
+	if avatarKey.Valid {
+		d.FileKeys = append(d.FileKeys, avatarKey.String)
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT file_key FROM data_exports WHERE user_id = $1 AND file_key IS NOT NULL`, userID)
+	if err != nil {
+		return d, false, fmt.Errorf("query exports: %w", err)
+	}
+	keys, err := scanKeys(rows)
+	if err != nil {
+		return d, false, err
+	}
+	d.FileKeys = append(d.FileKeys, keys...)
+
+	// Счетчики лайков и подписчиков кэшированы, каскад их не уменьшит.
+	// Профиль автора с видео остается, но отвязывается от учетной записи
+	byUser := []any{userID}
+	steps := []execStep{
+		{"update likes count", `
+			UPDATE videos v SET likes_count = GREATEST(v.likes_count - 1, 0)
+			FROM video_likes l WHERE l.video_id = v.id AND l.user_id = $1`, byUser},
+		{"delete likes", `DELETE FROM video_likes WHERE user_id = $1`, byUser},
+		{"update followers count", `
+			UPDATE authors a SET followers_count = GREATEST(a.followers_count - 1, 0)
+			FROM author_follows f WHERE f.author_id = a.id AND f.user_id = $1`, byUser},
+		{"delete follows", `DELETE FROM author_follows WHERE user_id = $1`, byUser},
+		{"unlink author", `UPDATE authors SET user_id = NULL WHERE user_id = $1`, byUser},
+	}
+	if err := execSteps(ctx, tx, steps); err != nil {
+		return d, false, err
+	}
+
+	// Ответы других пользователей на комментарии удаляемого пропали бы по каскаду parent_id
+	var keep bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM quiz_answers WHERE user_id = $1)
+			OR EXISTS (SELECT 1 FROM user_video_progress WHERE user_id = $1)
+			OR EXISTS (SELECT 1 FROM points_ledger WHERE user_id = $1)
+			OR EXISTS (
+				SELECT 1 FROM comments c
+				JOIN comments reply ON reply.parent_id = c.id
+				WHERE c.user_id = $1 AND reply.user_id <> $1
+			)
+	`, userID).Scan(&keep)
+	if err != nil {
+		return d, false, fmt.Errorf("query error: %w", err)
+	}
+
+	if !keep {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, userID); err != nil {
+			return d, false, fmt.Errorf("delete user: %w", err)
+		}
+		d.Mode = models.DeletionHard
+	} else {
+		byUserAt := []any{userID, at}
+		steps = []execStep{
+			{"delete comments", `
+				DELETE FROM comments c
+				WHERE c.user_id = $1 AND NOT EXISTS (
+					SELECT 1 FROM comments reply WHERE reply.parent_id = c.id AND reply.user_id <> $1
+				)`, byUser},
+			{"erase comments", `
+				UPDATE comments
+				SET body = '', flagged_keywords = '{}', is_pinned = FALSE, deleted_at = COALESCE(deleted_at, $2)
+				WHERE user_id = $1`, byUserAt},
+			{"delete sessions", `DELETE FROM sessions WHERE user_id = $1`, byUser},
+			{"delete login challenges", `DELETE FROM login_challenges WHERE user_id = $1`, byUser},
+			{"delete reset tokens", `DELETE FROM password_reset_tokens WHERE user_id = $1`, byUser},
+			{"delete verification tokens", `DELETE FROM email_verification_tokens WHERE user_id = $1`, byUser},
+			{"delete totp", `DELETE FROM user_totp WHERE user_id = $1`, byUser},
+			{"delete recovery codes", `DELETE FROM totp_recovery_codes WHERE user_id = $1`, byUser},
+			{"delete identities", `DELETE FROM user_identities WHERE user_id = $1`, byUser},
+			{"delete reservations", `DELETE FROM username_reservations WHERE user_id = $1`, byUser},
+			{"delete friends", `DELETE FROM user_friends WHERE user_id = $1 OR friend_id = $1`, byUser},
+			{"delete notifications", `DELETE FROM notifications WHERE user_id = $1`, byUser},
+			{"unlink notifications", `UPDATE notifications SET actor_id = NULL WHERE actor_id = $1`, byUser},
+			{"delete bookmarks", `DELETE FROM video_bookmarks WHERE user_id = $1`, byUser},
+			{"delete review schedule", `DELETE FROM review_schedule WHERE user_id = $1`, byUser},
+			{"delete exports", `DELETE FROM data_exports WHERE user_id = $1`, byUser},
+			{"reset streak", `
+				UPDATE user_stats
+				SET current_streak_days = 0, streak_freezes = 0, broken_streak = 0, streak_broken_at = NULL
+				WHERE user_id = $1`, byUser},
+			{"anonymize user", `
+				UPDATE users SET
+					email = 'deleted-' || id::text || '@deleted.invalid',
+					username = 'deleted_' || REPLACE(id::text, '-', ''), username_skeleton = NULL,
+					password_hash = $3, full_name = NULL, avatar_url = NULL, avatar_key = NULL,
+					email_verified_at = NULL, role = 'user', current_streak = 0, username_changed_at = NULL,
+					deletion_scheduled_at = NULL, deleted_at = $2, updated_at = $2
+				WHERE id = $1`, []any{userID, at, lockedHash}},
+		}
+		if err := execSteps(ctx, tx, steps); err != nil {
+			return d, false, err
+		}
+		d.Mode = models.DeletionAnonymized
+	}
+
+	if err := tx.Commit(); err != nil {
+		return d, false, fmt.Errorf("commit: %w", err)
+	}
+	return d, true, nil
+}
+
+// execStep - один запрос из последовательности execSteps
+type execStep struct {
+	name  string
+	query string
+	args  []any
+}
+
+// execSteps выполняет запросы по порядку в транзакции
+func execSteps(ctx context.Context, tx *sql.Tx, steps []execStep) error {
+	for _, step := range steps {
+		if _, err := tx.ExecContext(ctx, step.query, step.args...); err != nil {
+			return fmt.Errorf("%s: %w", step.name, err)
+		}
+	}
+	return nil
+}
+
+func scanExport(row rowScanner) (models.DataExport, error) {
+	var e models.DataExport
+	var size sql.NullInt64
+	var completedAt, expiresAt sql.NullTime
+	err := row.Scan(&e.ID, &e.UserID, &e.Status, &e.FileKey, &size, &e.CreatedAt, &completedAt, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return e, ErrNotFound
+	}
+	if err != nil {
+		return e, fmt.Errorf("scan error: %w", err)
+	}
+	if size.Valid {
+		e.SizeBytes = &size.Int64
+	}
+	if completedAt.Valid {
+		e.CompletedAt = &completedAt.Time
+	}
+	if expiresAt.Valid {
+		e.ExpiresAt = &expiresAt.Time
+	}
+	return e, nil
+}
+
+// scanKeys читает строки из одного текстового столбца и закрывает rows
+func scanKeys(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return keys, nil
+}
+
+// scanRows вызывает scan для каждой строки и закрывает rows
+func scanRows(rows *sql.Rows, scan func(row rowScanner) error) error {
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return fmt.Errorf("scan error: %w", err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+	return nil
+}
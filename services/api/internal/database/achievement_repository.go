@@ -0,0 +1,192 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mindly/api/internal/achievements"
+	"github.com/mindly/api/internal/models"
+)
+
+type AchievementRepository struct {
+	db *sql.DB
+}
+
+func NewAchievementRepository(db *sql.DB) *AchievementRepository {
+	return &AchievementRepository{db: db}
+}
+
+// ActiveAchievements возвращает все включенные достижения с правилами
+func (r *AchievementRepository) ActiveAchievements(ctx context.Context) ([]models.Achievement, error) {
+	return r.list(ctx, `
+		SELECT code, title, COALESCE(description, ''), COALESCE(icon, ''), rule, points, NULL::timestamp
+		FROM achievements
+		WHERE is_active
+		ORDER BY sort_order, code
+	`)
+}
+
+// ListForUser возвращает все включенные достижения с отметкой о получении пользователем
+func (r *AchievementRepository) ListForUser(ctx context.Context, userID string) ([]models.Achievement, error) {
+	return r.list(ctx, `
+		SELECT a.code, a.title, COALESCE(a.description, ''), COALESCE(a.icon, ''), a.rule, a.points, ua.unlocked_at
+		FROM achievements a
+		LEFT JOIN user_achievements ua ON ua.achievement_code = a.code AND ua.user_id = $1
+		WHERE a.is_active OR ua.unlocked_at IS NOT NULL
+		ORDER BY ua.unlocked_at DESC NULLS LAST, a.sort_order, a.code
+	`, userID)
+}
+
+func (r *AchievementRepository) list(ctx context.Context, query string, args ...any) ([]models.Achievement, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	list := []models.Achievement{}
+	for rows.Next() {
+		var a models.Achievement
+		var rule []byte
+		if err := rows.Scan(&a.Code, &a.Title, &a.Description, &a.Icon, &rule, &a.Points, &a.UnlockedAt); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		a.Rule = rule
+		list = append(list, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return list, nil
+}
+
+// UnlockedCodes возвращает коды уже полученных пользователем достижений
+func (r *AchievementRepository) UnlockedCodes(ctx context.Context, userID string) (map[string]bool, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT achievement_code FROM user_achievements WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	codes := map[string]bool{}
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		codes[code] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return codes, nil
+}
+
+// Unlock выдает достижение и начисляет за него баллы; повторная выдача
+// игнорируется благодаря первичному ключу
+func (r *AchievementRepository) Unlock(ctx context.Context, userID string, a models.Achievement, at time.Time) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO user_achievements (user_id, achievement_code, unlocked_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, achievement_code) DO NOTHING
+	`, userID, a.Code, at)
+	if err != nil {
+		return false, fmt.Errorf("insert error: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return false, nil
+	}
+
+	if a.Points > 0 {
+		entry := models.PointsEntry{
+			UserID:    userID,
+			Amount:    a.Points,
+			Reason:    models.PointsAchievement,
+			Reference: a.Code,
+		}
+		if _, err := appendPoints(ctx, tx, &entry); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit: %w", err)
+	}
+	return true, nil
+}
+
+// Metric вычисляет метрику для правил достижений.
+// Новая метрика добавляется сюда; сами правила меняются только в БД.
+func (r *AchievementRepository) Metric(ctx context.Context, userID, name string, params map[string]string) (float64, error) {
+	var query string
+	args := []any{userID}
+
+	switch name {
+	case "total_points":
+		// Заработанные баллы: траты на заморозки и восстановление серии не отнимают достижение
+		query = `
+			SELECT COALESCE(SUM(amount), 0) FROM points_ledger
+			WHERE user_id = $1 AND reason NOT IN ('freeze_purchase', 'streak_repair')`
+	case "streak_days":
+		query = `SELECT current_streak FROM users WHERE id = $1`
+	case "best_streak":
+		query = `SELECT best_streak FROM users WHERE id = $1`
+	case "videos_watched":
+		query = `SELECT COUNT(*) FROM user_video_progress WHERE user_id = $1 AND (is_watched OR quiz_attempted)`
+	case "quizzes_completed":
+		query = `SELECT COUNT(*) FROM user_video_progress WHERE user_id = $1 AND quiz_correct`
+	case "distinct_authors_watched":
+		query = `
+			SELECT COUNT(DISTINCT v.author_id)
+			FROM user_video_progress p
+			JOIN videos v ON v.id = p.video_id
+			WHERE p.user_id = $1 AND (p.is_watched OR p.quiz_attempted)`
+	case "correct_answers":
+		// Вопросы, на которые есть правильный ответ; можно ограничить областью или тегом
+		query = `
+			SELECT COUNT(DISTINCT qa.question_id)
+			FROM quiz_answers qa
+			JOIN quiz_questions q ON q.id = qa.question_id
+			JOIN videos v ON v.id = q.video_id
+			JOIN authors a ON a.id = v.author_id
+			WHERE qa.user_id = $1 AND qa.is_correct`
+		for key, value := range params {
+			args = append(args, value)
+			switch key {
+			case "expertise_area":
+				query += fmt.Sprintf(" AND a.expertise_area = $%d", len(args))
+			case "tag":
+				query += fmt.Sprintf(" AND $%d = ANY(v.tags)", len(args))
+			default:
+				return 0, fmt.Errorf("%w: correct_answers does not support param %q", achievements.ErrUnknownMetric, key)
+			}
+		}
+	default:
+		return 0, fmt.Errorf("%w: %s", achievements.ErrUnknownMetric, name)
+	}
+
+	var value sql.NullFloat64
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&value)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("metric %s: %w", name, err)
+	}
+	return value.Float64, nil
+}
@@ -0,0 +1,203 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/mindly/api/internal/models"
+)
+
+type AuthorRepository struct {
+	db *sql.DB
+}
+
+func NewAuthorRepository(db *sql.DB) *AuthorRepository {
+	return &AuthorRepository{db: db}
+}
+
+// GetUserID возвращает учетную запись, к которой привязан автор (пустая строка, если не привязан)
+func (r *AuthorRepository) GetUserID(ctx context.Context, authorID string) (string, error) {
+	var userID sql.NullString
+	err := r.db.QueryRowContext(ctx, `SELECT user_id::text FROM authors WHERE id = $1`, authorID).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("query error: %w", err)
+	}
+	return userID.String, nil
+}
+
+// ErrAlreadyAuthor - у пользователя уже есть профиль автора
+var ErrAlreadyAuthor = errors.New("user is already an author")
+
+// CreateForUser создает профиль автора для учетной записи пользователя
+func (r *AuthorRepository) CreateForUser(ctx context.Context, userID, fullName, expertiseArea string) (models.Author, error) {
+	author := models.Author{FullName: fullName, ExpertiseArea: expertiseArea}
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO authors (user_id, full_name, expertise_area)
+		SELECT id, $2, $3 FROM users WHERE id = $1
+		ON CONFLICT (user_id) DO NOTHING
+		RETURNING id, trust_tier, is_verified
+	`, userID, fullName, expertiseArea).Scan(&author.ID, &author.TrustTier, &author.IsVerified)
+	if errors.Is(err, sql.ErrNoRows) {
+		var exists bool
+		err = r.db.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM authors WHERE user_id = $1)`, userID,
+		).Scan(&exists)
+		if err != nil {
+			return author, fmt.Errorf("query error: %w", err)
+		}
+		if exists {
+			return author, ErrAlreadyAuthor
+		}
+		return author, ErrNotFound
+	}
+	if err != nil {
+		return author, fmt.Errorf("insert author: %w", err)
+	}
+	return author, nil
+}
+
+// ListTrustInputs собирает сигналы для пересчета уровня доверия по всем авторам
+func (r *AuthorRepository) ListTrustInputs(ctx context.Context) ([]models.AuthorTrustInputs, error) {
+	var platformCorrectRate float64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(AVG(CASE WHEN quiz_correct THEN 1.0 ELSE 0.0 END), 0)
+		FROM user_video_progress
+		WHERE quiz_attempted
+	`).Scan(&platformCorrectRate)
+	if err != nil {
+		return nil, fmt.Errorf("platform correct rate: %w", err)
+	}
+
+	// Жалобы на видео автора: открытые и подтвержденные модератором, отклоненные не считаются
+	query := `
+		WITH video_stats AS (
+			SELECT author_id,
+				COUNT(*) AS total,
+				COUNT(*) FILTER (WHERE moderation_status = 'rejected') AS rejected
+			FROM videos
+			GROUP BY author_id
+		),
+		report_stats AS (
+			SELECT v.author_id, COUNT(*) AS reports
+			FROM reports rp
+			JOIN videos v ON rp.target_type = 'video' AND v.id = rp.target_id
+			WHERE rp.status <> 'dismissed'
+			GROUP BY v.author_id
+		),
+		progress_stats AS (
+			SELECT v.author_id,
+				COUNT(DISTINCT p.user_id) AS viewers,
+				COUNT(*) AS views,
+				COUNT(*) FILTER (WHERE p.quiz_attempted) AS attempts,
+				COUNT(*) FILTER (WHERE p.quiz_correct) AS correct,
+				COUNT(*) FILTER (WHERE p.is_watched) AS watched
+			FROM user_video_progress p
+			JOIN videos v ON v.id = p.video_id
+			GROUP BY v.author_id
+		)
+		SELECT
+			a.id, COALESCE(a.trust_tier, 'silver'), a.trust_tier_manual, COALESCE(a.is_verified, FALSE),
+			COALESCE(vs.total, 0), COALESCE(vs.rejected, 0),
+			COALESCE(ps.viewers, 0), COALESCE(ps.views, 0), COALESCE(rs.reports, 0),
+			COALESCE(ps.attempts, 0), COALESCE(ps.correct, 0), COALESCE(ps.watched, 0)
+		FROM authors a
+		LEFT JOIN video_stats vs ON vs.author_id = a.id
+		LEFT JOIN progress_stats ps ON ps.author_id = a.id
+		LEFT JOIN report_stats rs ON rs.author_id = a.id
+		ORDER BY a.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var inputs []models.AuthorTrustInputs
+	for rows.Next() {
+		in := models.AuthorTrustInputs{PlatformCorrectRate: platformCorrectRate}
+		err := rows.Scan(
+			&in.AuthorID, &in.CurrentTier, &in.ManualTier, &in.IsVerified,
+			&in.VideosTotal, &in.VideosRejected,
+			&in.Viewers, &in.Views, &in.Reports,
+			&in.QuizAttempts, &in.QuizCorrect, &in.WatchedCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		inputs = append(inputs, in)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return inputs, nil
+}
+
+// SaveTrustEvaluation обновляет уровень и балл автора. Объяснение пересчета
+// (входные данные и причины) сохраняется, только если уровень или балл изменились;
+// иначе отмечается лишь время пересчета
+func (r *AuthorRepository) SaveTrustEvaluation(ctx context.Context, e models.TrustEvaluation) error {
+	inputsJSON, err := json.Marshal(e.Inputs)
+	if err != nil {
+		return fmt.Errorf("marshal inputs: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Балл хранится с одним знаком после запятой: сравниваем в том же виде
+	var changed bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT trust_tier IS DISTINCT FROM $2 OR trust_score IS DISTINCT FROM ROUND($3::numeric, 1)
+		FROM authors WHERE id = $1
+		FOR UPDATE
+	`, e.AuthorID, e.Tier, e.Score).Scan(&changed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("load author: %w", err)
+	}
+
+	if !changed {
+		_, err = tx.ExecContext(ctx,
+			`UPDATE authors SET trust_evaluated_at = $2 WHERE id = $1`, e.AuthorID, e.EvaluatedAt)
+		if err != nil {
+			return fmt.Errorf("update author: %w", err)
+		}
+		return tx.Commit()
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO author_trust_evaluations (
+			author_id, previous_tier, tier, score, inputs, reasons, evaluated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, e.AuthorID, e.PreviousTier, e.Tier, e.Score, inputsJSON, pq.Array(e.Reasons), e.EvaluatedAt)
+	if err != nil {
+		return fmt.Errorf("insert evaluation: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE authors
+		SET trust_tier = $2, trust_score = $3, trust_evaluated_at = $4
+		WHERE id = $1
+	`, e.AuthorID, e.Tier, e.Score, e.EvaluatedAt)
+	if err != nil {
+		return fmt.Errorf("update author: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,491 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/pagination"
+)
+
+var (
+	// ErrForbidden - действие доступно только владельцу (или автору видео)
+	ErrForbidden = errors.New("forbidden")
+	// ErrInvalidParent - ответ на комментарий к другому видео или на удаленный комментарий
+	ErrInvalidParent = errors.New("invalid parent comment")
+	// ErrPinLimit - у видео уже закреплено максимальное число комментариев
+	ErrPinLimit = errors.New("pinned comments limit reached")
+	// ErrPinReply - закрепить можно только комментарий верхнего уровня, не ответ
+	ErrPinReply = errors.New("replies cannot be pinned")
+)
+
+const (
+	// Сколько комментариев автор может закрепить у одного видео
+	maxPinnedComments = 3
+	// Сколько первых ответов приходит вместе с комментарием
+	replyPreviewSize = 3
+)
+
+// Колонки комментария в порядке scanComment. Видимость: одобренные всем,
+// свои на модерации - автору комментария ($2 - зритель, может быть NULL)
+const commentColumns = `
+	c.id, c.video_id, COALESCE(c.parent_id::text, ''), c.user_id, u.username,
+	c.body, c.moderation_status, c.is_pinned, c.deleted_at IS NOT NULL, c.edited_at, c.created_at,
+	(SELECT COUNT(*) FROM comments r
+	 WHERE r.parent_id = c.id AND r.deleted_at IS NULL AND r.moderation_status = 'approved')`
+
+const commentVisible = `(c.moderation_status = 'approved' OR c.user_id = $2::uuid)`
+
+type CommentRepository struct {
+	db *sql.DB
+}
+
+func NewCommentRepository(db *sql.DB) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+// List возвращает комментарии верхнего уровня к видео от новых к старым с первыми ответами.
+// Удаленный комментарий остается в списке, пока у него есть ответы
+func (r *CommentRepository) List(ctx context.Context, videoID, viewerID string, cursor *pagination.Cursor, limit int) (models.CommentPage, error) {
+	page := models.CommentPage{Comments: []models.Comment{}}
+
+	if err := ensureVideoApproved(ctx, r.db, videoID); err != nil {
+		return page, err
+	}
+
+	viewer := nullString(viewerID)
+	var cursorAt, cursorID sql.NullString
+	if cursor != nil {
+		cursorAt = sql.NullString{String: cursor.Timestamp(), Valid: true}
+		cursorID = sql.NullString{String: cursor.ID, Valid: true}
+	}
+
+	comments, err := r.query(ctx, `
+		SELECT `+commentColumns+`
+		FROM comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.video_id = $1
+		  AND c.parent_id IS NULL
+		  AND `+commentVisible+`
+		  AND (c.deleted_at IS NULL OR EXISTS (
+			SELECT 1 FROM comments r
+			WHERE r.parent_id = c.id AND r.deleted_at IS NULL AND r.moderation_status = 'approved'
+		  ))
+		  AND ($3::timestamp IS NULL OR (c.created_at, c.id) < ($3::timestamp, $4::uuid))
+		ORDER BY c.created_at DESC, c.id DESC
+		LIMIT $5
+	`, videoID, viewer, cursorAt, cursorID, limit+1)
+	if err != nil {
+		return page, err
+	}
+
+	if len(comments) > limit {
+		comments = comments[:limit]
+		last := comments[len(comments)-1]
+		page.NextCursor = pagination.Cursor{At: last.CreatedAt, ID: last.ID}.Encode()
+	}
+
+	if err := r.attachReplies(ctx, comments, viewer); err != nil {
+		return page, err
+	}
+	page.Comments = comments
+
+	if cursor == nil {
+		page.Pinned, err = r.query(ctx, `
+			SELECT `+commentColumns+`
+			FROM comments c
+			JOIN users u ON u.id = c.user_id
+			WHERE c.video_id = $1
+			  AND c.parent_id IS NULL
+			  AND c.is_pinned
+			  AND c.deleted_at IS NULL
+			  AND `+commentVisible+`
+			ORDER BY c.created_at
+		`, videoID, viewer)
+		if err != nil {
+			return page, err
+		}
+	}
+
+	return page, nil
+}
+
+// Replies возвращает ответы в ветке от старых к новым
+func (r *CommentRepository) Replies(ctx context.Context, parentID, viewerID string, cursor *pagination.Cursor, limit int) (models.CommentPage, error) {
+	page := models.CommentPage{Comments: []models.Comment{}}
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM comments c
+			JOIN videos v ON v.id = c.video_id AND v.moderation_status = 'approved'
+			WHERE c.id = $1 AND c.parent_id IS NULL AND `+commentVisible+`
+		)
+	`, parentID, nullString(viewerID)).Scan(&exists)
+	if err != nil {
+		return page, fmt.Errorf("query error: %w", err)
+	}
+	if !exists {
+		return page, ErrNotFound
+	}
+
+	var cursorAt, cursorID sql.NullString
+	if cursor != nil {
+		cursorAt = sql.NullString{String: cursor.Timestamp(), Valid: true}
+		cursorID = sql.NullString{String: cursor.ID, Valid: true}
+	}
+
+	replies, err := r.query(ctx, `
+		SELECT `+commentColumns+`
+		FROM comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.parent_id = $1
+		  AND c.deleted_at IS NULL
+		  AND `+commentVisible+`
+		  AND ($3::timestamp IS NULL OR (c.created_at, c.id) > ($3::timestamp, $4::uuid))
+		ORDER BY c.created_at, c.id
+		LIMIT $5
+	`, parentID, nullString(viewerID), cursorAt, cursorID, limit+1)
+	if err != nil {
+		return page, err
+	}
+
+	if len(replies) > limit {
+		replies = replies[:limit]
+		last := replies[len(replies)-1]
+		page.NextCursor = pagination.Cursor{At: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	page.Comments = replies
+
+	return page, nil
+}
+
+// Create добавляет комментарий. Ответ на ответ привязывается к комментарию верхнего уровня,
+// чтобы ветка оставалась одноуровневой. flagged - сработавшие слова фильтра: такой комментарий ждет модератора
+func (r *CommentRepository) Create(ctx context.Context, userID, videoID string, in models.CommentInput, flagged []string, at time.Time) (models.Comment, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Comment{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := ensureVideoApproved(ctx, tx, videoID); err != nil {
+		return models.Comment{}, err
+	}
+
+	var parentID sql.NullString
+	if in.ParentID != "" {
+		var parentVideoID string
+		var deleted bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT COALESCE(parent_id, id)::text, video_id::text, deleted_at IS NOT NULL
+			FROM comments WHERE id = $1
+		`, in.ParentID).Scan(&parentID.String, &parentVideoID, &deleted)
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Comment{}, ErrInvalidParent
+		}
+		if err != nil {
+			return models.Comment{}, fmt.Errorf("load parent: %w", err)
+		}
+		if parentVideoID != videoID || deleted {
+			return models.Comment{}, ErrInvalidParent
+		}
+		parentID.Valid = true
+	}
+
+	status := models.ModerationApproved
+	if len(flagged) > 0 {
+		status = models.ModerationPending
+	}
+
+	var id string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO comments (video_id, parent_id, user_id, body, moderation_status, flagged_keywords, created_at)
+		SELECT $1, $2, id, $4, $5, $6, $7 FROM users WHERE id = $3
+		RETURNING id
+	`, videoID, parentID, userID, in.Body, status, pq.Array(nonNil(flagged)), at).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Comment{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Comment{}, fmt.Errorf("insert comment: %w", err)
+	}
+
+	comment, err := getComment(ctx, tx, id)
+	if err != nil {
+		return comment, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return comment, fmt.Errorf("commit: %w", err)
+	}
+	return comment, nil
+}
+
+// Edit меняет текст своего комментария. Если в новом тексте сработал фильтр,
+// комментарий снова уходит на модерацию
+func (r *CommentRepository) Edit(ctx context.Context, commentID, userID, body string, flagged []string, at time.Time) (models.Comment, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Comment{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockOwnComment(ctx, tx, commentID, userID); err != nil {
+		return models.Comment{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE comments
+		SET body = $2,
+			edited_at = $3,
+			flagged_keywords = $4,
+			moderation_status = CASE WHEN $5 THEN 'pending' ELSE moderation_status END
+		WHERE id = $1
+	`, commentID, body, at, pq.Array(nonNil(flagged)), len(flagged) > 0)
+	if err != nil {
+		return models.Comment{}, fmt.Errorf("update comment: %w", err)
+	}
+
+	comment, err := getComment(ctx, tx, commentID)
+	if err != nil {
+		return comment, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return comment, fmt.Errorf("commit: %w", err)
+	}
+	return comment, nil
+}
+
+// Delete удаляет свой комментарий: текст стирается, ответы остаются в ветке
+func (r *CommentRepository) Delete(ctx context.Context, commentID, userID string, at time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockOwnComment(ctx, tx, commentID, userID); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE comments SET body = '', is_pinned = FALSE, deleted_at = $2 WHERE id = $1
+	`, commentID, at)
+	if err != nil {
+		return fmt.Errorf("delete comment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// SetPinned закрепляет или открепляет одобренный комментарий верхнего уровня.
+// Доступно только автору видео
+func (r *CommentRepository) SetPinned(ctx context.Context, commentID, userID string, pinned bool) (models.Comment, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Comment{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Строка видео блокируется, чтобы параллельные закрепления не превысили лимит
+	var videoID string
+	var authorUserID sql.NullString
+	var status models.ModerationStatus
+	var deleted, isReply bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT v.id::text, a.user_id::text, c.moderation_status, c.deleted_at IS NOT NULL, c.parent_id IS NOT NULL
+		FROM comments c
+		JOIN videos v ON v.id = c.video_id
+		JOIN authors a ON a.id = v.author_id
+		WHERE c.id = $1
+		FOR UPDATE OF v, c
+	`, commentID).Scan(&videoID, &authorUserID, &status, &deleted, &isReply)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Comment{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Comment{}, fmt.Errorf("load comment: %w", err)
+	}
+	if !authorUserID.Valid || authorUserID.String != userID {
+		return models.Comment{}, ErrForbidden
+	}
+	if deleted || status != models.ModerationApproved {
+		return models.Comment{}, ErrNotFound
+	}
+
+	if pinned && isReply {
+		return models.Comment{}, ErrPinReply
+	}
+
+	if pinned {
+		// Скрытые модерацией и удаленные закрепленные комментарии не показываются
+		// и место в лимите не занимают
+		var count int
+		err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM comments
+			WHERE video_id = $1 AND is_pinned AND id <> $2
+			  AND parent_id IS NULL AND moderation_status = 'approved' AND deleted_at IS NULL
+		`, videoID, commentID).Scan(&count)
+		if err != nil {
+			return models.Comment{}, fmt.Errorf("count pinned: %w", err)
+		}
+		if count >= maxPinnedComments {
+			return models.Comment{}, ErrPinLimit
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE comments SET is_pinned = $2 WHERE id = $1`, commentID, pinned); err != nil {
+		return models.Comment{}, fmt.Errorf("update pin: %w", err)
+	}
+
+	comment, err := getComment(ctx, tx, commentID)
+	if err != nil {
+		return comment, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return comment, fmt.Errorf("commit: %w", err)
+	}
+	return comment, nil
+}
+
+// attachReplies добавляет к комментариям первые ответы веток
+func (r *CommentRepository) attachReplies(ctx context.Context, comments []models.Comment, viewer sql.NullString) error {
+	if len(comments) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(comments))
+	for i, c := range comments {
+		ids[i] = c.ID
+	}
+
+	replies, err := r.query(ctx, `
+		SELECT `+commentColumns+`
+		FROM comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.id IN (
+			SELECT id FROM (
+				SELECT c.id, row_number() OVER (PARTITION BY c.parent_id ORDER BY c.created_at, c.id) AS rn
+				FROM comments c
+				WHERE c.parent_id = ANY($1::uuid[])
+				  AND c.deleted_at IS NULL
+				  AND `+commentVisible+`
+			) preview
+			WHERE rn <= $3
+		)
+		ORDER BY c.created_at, c.id
+	`, pq.Array(ids), viewer, replyPreviewSize)
+	if err != nil {
+		return err
+	}
+
+	byParent := map[string][]models.Comment{}
+	for _, reply := range replies {
+		byParent[reply.ParentID] = append(byParent[reply.ParentID], reply)
+	}
+	for i := range comments {
+		comments[i].Replies = byParent[comments[i].ID]
+	}
+	return nil
+}
+
+func (r *CommentRepository) query(ctx context.Context, query string, args ...any) ([]models.Comment, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	comments := []models.Comment{}
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return comments, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanComment(row rowScanner) (models.Comment, error) {
+	var c models.Comment
+	var editedAt sql.NullTime
+	err := row.Scan(
+		&c.ID, &c.VideoID, &c.ParentID, &c.UserID, &c.Username,
+		&c.Body, &c.Status, &c.Pinned, &c.Deleted, &editedAt, &c.CreatedAt,
+		&c.ReplyCount,
+	)
+	if err != nil {
+		return c, fmt.Errorf("scan error: %w", err)
+	}
+	if editedAt.Valid {
+		c.EditedAt = &editedAt.Time
+	}
+	return c, nil
+}
+
+// getComment читает комментарий в транзакции изменения
+func getComment(ctx context.Context, tx *sql.Tx, commentID string) (models.Comment, error) {
+	row := tx.QueryRowContext(ctx, `
+		SELECT `+commentColumns+`
+		FROM comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.id = $1
+	`, commentID)
+	c, err := scanComment(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, ErrNotFound
+	}
+	return c, err
+}
+
+// lockOwnComment блокирует неудаленный комментарий и проверяет, что он принадлежит пользователю
+func lockOwnComment(ctx context.Context, tx *sql.Tx, commentID, userID string) error {
+	var ownerID string
+	var deleted bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT user_id::text, deleted_at IS NOT NULL FROM comments WHERE id = $1 FOR UPDATE
+	`, commentID).Scan(&ownerID, &deleted)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && deleted) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("load comment: %w", err)
+	}
+	if ownerID != userID {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,217 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/pagination"
+)
+
+// EngagementRepository - лайки и закладки. Обе операции идемпотентны:
+// повторный лайк или снятие несуществующей закладки ничего не меняют.
+type EngagementRepository struct {
+	db *sql.DB
+}
+
+func NewEngagementRepository(db *sql.DB) *EngagementRepository {
+	return &EngagementRepository{db: db}
+}
+
+// SetLike ставит или снимает лайк и поддерживает счетчик videos.likes_count
+func (r *EngagementRepository) SetLike(ctx context.Context, userID, videoID string, liked bool, at time.Time) (models.Engagement, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Engagement{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := ensureVideoApproved(ctx, tx, videoID); err != nil {
+		return models.Engagement{}, err
+	}
+
+	var res sql.Result
+	delta := 1
+	if liked {
+		res, err = tx.ExecContext(ctx, `
+			INSERT INTO video_likes (user_id, video_id, created_at)
+			SELECT id, $2, $3 FROM users WHERE id = $1
+			ON CONFLICT DO NOTHING
+		`, userID, videoID, at)
+	} else {
+		delta = -1
+		res, err = tx.ExecContext(ctx,
+			`DELETE FROM video_likes WHERE user_id = $1 AND video_id = $2`, userID, videoID)
+	}
+	if err != nil {
+		return models.Engagement{}, fmt.Errorf("update like: %w", err)
+	}
+	changed, err := res.RowsAffected()
+	if err != nil {
+		return models.Engagement{}, fmt.Errorf("rows affected: %w", err)
+	}
+
+	if changed > 0 {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE videos SET likes_count = GREATEST(likes_count + $2, 0) WHERE id = $1
+		`, videoID, delta)
+		if err != nil {
+			return models.Engagement{}, fmt.Errorf("update likes count: %w", err)
+		}
+	}
+
+	state, err := engagementState(ctx, tx, userID, videoID)
+	if err != nil {
+		return state, err
+	}
+	if liked && !state.Liked {
+		// Лайк не вставился и его нет - значит, нет пользователя
+		return state, ErrNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return state, fmt.Errorf("commit: %w", err)
+	}
+	return state, nil
+}
+
+// SetBookmark сохраняет видео в библиотеку или убирает его оттуда
+func (r *EngagementRepository) SetBookmark(ctx context.Context, userID, videoID string, bookmarked bool, at time.Time) (models.Engagement, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Engagement{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := ensureVideoApproved(ctx, tx, videoID); err != nil {
+		return models.Engagement{}, err
+	}
+
+	if bookmarked {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO video_bookmarks (user_id, video_id, created_at)
+			SELECT id, $2, $3 FROM users WHERE id = $1
+			ON CONFLICT DO NOTHING
+		`, userID, videoID, at)
+	} else {
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM video_bookmarks WHERE user_id = $1 AND video_id = $2`, userID, videoID)
+	}
+	if err != nil {
+		return models.Engagement{}, fmt.Errorf("update bookmark: %w", err)
+	}
+
+	state, err := engagementState(ctx, tx, userID, videoID)
+	if err != nil {
+		return state, err
+	}
+	if bookmarked && !state.Bookmarked {
+		return state, ErrNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return state, fmt.Errorf("commit: %w", err)
+	}
+	return state, nil
+}
+
+// Saved возвращает сохраненные видео от недавно сохраненных к давним.
+// Страницы листаются курсором (время сохранения, ID видео)
+func (r *EngagementRepository) Saved(ctx context.Context, userID string, cursor *pagination.Cursor, limit int) (models.SavedPage, error) {
+	page := models.SavedPage{Videos: []models.SavedVideo{}}
+
+	var cursorAt, cursorID sql.NullString
+	if cursor != nil {
+		cursorAt = sql.NullString{String: cursor.Timestamp(), Valid: true}
+		cursorID = sql.NullString{String: cursor.ID, Valid: true}
+	}
+
+	// Одно лишнее видео показывает, есть ли следующая страница
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			v.id, v.title, v.description, v.video_url, v.thumbnail_url,
+			v.duration_sec, v.tags, v.likes_count, v.created_at,
+			a.id, a.full_name, a.expertise_area, a.trust_tier, a.is_verified, a.followers_count,
+			b.created_at
+		FROM video_bookmarks b
+		JOIN videos v ON v.id = b.video_id
+		JOIN authors a ON a.id = v.author_id
+		WHERE b.user_id = $1
+		  AND v.moderation_status = 'approved'
+		  AND ($2::timestamp IS NULL OR (b.created_at, b.video_id) < ($2::timestamp, $3::uuid))
+		ORDER BY b.created_at DESC, b.video_id DESC
+		LIMIT $4
+	`, userID, cursorAt, cursorID, limit+1)
+	if err != nil {
+		return page, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s models.SavedVideo
+		var description, thumbnailURL sql.NullString
+		var tagsRaw []byte
+
+		err := rows.Scan(
+			&s.ID, &s.Title, &description, &s.VideoURL, &thumbnailURL,
+			&s.DurationSec, &tagsRaw, &s.LikesCount, &s.CreatedAt,
+			&s.Author.ID, &s.Author.FullName, &s.Author.ExpertiseArea,
+			&s.Author.TrustTier, &s.Author.IsVerified, &s.Author.FollowersCount,
+			&s.SavedAt,
+		)
+		if err != nil {
+			return page, fmt.Errorf("scan error: %w", err)
+		}
+
+		s.Description = description.String
+		s.ThumbnailURL = thumbnailURL.String
+		s.Tags = parsePostgresArray(string(tagsRaw))
+		page.Videos = append(page.Videos, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return page, fmt.Errorf("rows error: %w", err)
+	}
+
+	if len(page.Videos) > limit {
+		page.Videos = page.Videos[:limit]
+		last := page.Videos[len(page.Videos)-1]
+		page.NextCursor = pagination.Cursor{At: last.SavedAt, ID: last.ID}.Encode()
+	}
+
+	return page, nil
+}
+
+// ensureVideoApproved проверяет, что видео существует и прошло модерацию
+func ensureVideoApproved(ctx context.Context, q rowQuerier, videoID string) error {
+	var approved bool
+	err := q.QueryRowContext(ctx,
+		`SELECT moderation_status = 'approved' FROM videos WHERE id = $1`, videoID,
+	).Scan(&approved)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !approved) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("load video: %w", err)
+	}
+	return nil
+}
+
+// engagementState читает лайк, закладку и счетчик лайков видео
+func engagementState(ctx context.Context, tx *sql.Tx, userID, videoID string) (models.Engagement, error) {
+	state := models.Engagement{VideoID: videoID}
+	err := tx.QueryRowContext(ctx, `
+		SELECT v.likes_count,
+			EXISTS (SELECT 1 FROM video_likes WHERE user_id = $1 AND video_id = v.id),
+			EXISTS (SELECT 1 FROM video_bookmarks WHERE user_id = $1 AND video_id = v.id)
+		FROM videos v
+		WHERE v.id = $2
+	`, userID, videoID).Scan(&state.LikesCount, &state.Liked, &state.Bookmarked)
+	if err != nil {
+		return state, fmt.Errorf("load engagement: %w", err)
+	}
+	return state, nil
+}
@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+)
+
+// ErrSelfFollow - автор пытается подписаться на самого себя
+var ErrSelfFollow = errors.New("cannot follow yourself")
+
+// Повторная подписка того же пользователя в течение этого срока (отписка и снова
+// подписка) не создает нового уведомления о подписчике
+const followNotificationWindow = 7 * 24 * time.Hour
+
+type FollowRepository struct {
+	db *sql.DB
+}
+
+func NewFollowRepository(db *sql.DB) *FollowRepository {
+	return &FollowRepository{db: db}
+}
+
+// Follow подписывает пользователя на автора. Повторная подписка ничего не меняет;
+// о новом подписчике автор узнает из уведомления, если автор привязан к учетной записи.
+// Уведомление от одного подписчика - не чаще раза в followNotificationWindow.
+func (r *FollowRepository) Follow(ctx context.Context, userID, authorID string, at time.Time) (models.FollowStatus, error) {
+	status := models.FollowStatus{AuthorID: authorID, Following: true}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return status, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Строка автора блокируется, чтобы счетчик подписчиков менялся последовательно
+	var authorUserID sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT user_id::text, followers_count FROM authors WHERE id = $1 FOR UPDATE
+	`, authorID).Scan(&authorUserID, &status.FollowersCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return status, ErrNotFound
+	}
+	if err != nil {
+		return status, fmt.Errorf("load author: %w", err)
+	}
+	if authorUserID.String == userID {
+		return status, ErrSelfFollow
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO author_follows (user_id, author_id, created_at)
+		SELECT id, $2, $3 FROM users WHERE id = $1
+		ON CONFLICT DO NOTHING
+	`, userID, authorID, at)
+	if err != nil {
+		return status, fmt.Errorf("insert follow: %w", err)
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return status, fmt.Errorf("rows affected: %w", err)
+	}
+	if inserted == 0 {
+		// Либо уже подписан, либо пользователя нет
+		var exists bool
+		err = tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM author_follows WHERE user_id = $1 AND author_id = $2)`,
+			userID, authorID,
+		).Scan(&exists)
+		if err != nil {
+			return status, fmt.Errorf("check follow: %w", err)
+		}
+		if !exists {
+			return status, ErrNotFound
+		}
+		return status, tx.Commit()
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE authors SET followers_count = followers_count + 1 WHERE id = $1
+		RETURNING followers_count
+	`, authorID).Scan(&status.FollowersCount)
+	if err != nil {
+		return status, fmt.Errorf("update followers count: %w", err)
+	}
+
+	if authorUserID.Valid {
+		var notified bool
+		err = tx.QueryRowContext(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM notifications
+				WHERE user_id = $1 AND notification_type = $2 AND actor_id = $3
+				  AND payload->>'author_id' = $4 AND created_at > $5
+			)
+		`, authorUserID.String, models.NotificationNewFollower, userID, authorID, at.Add(-followNotificationWindow)).Scan(&notified)
+		if err != nil {
+			return status, fmt.Errorf("check follow notification: %w", err)
+		}
+		if !notified {
+			payload := map[string]any{"author_id": authorID, "followers_count": status.FollowersCount}
+			if err := notify(ctx, tx, authorUserID.String, models.NotificationNewFollower, userID, payload, at); err != nil {
+				return status, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return status, fmt.Errorf("commit: %w", err)
+	}
+	return status, nil
+}
+
+// Unfollow отменяет подписку; отписка без подписки ничего не меняет
+func (r *FollowRepository) Unfollow(ctx context.Context, userID, authorID string) (models.FollowStatus, error) {
+	status := models.FollowStatus{AuthorID: authorID}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return status, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		`SELECT followers_count FROM authors WHERE id = $1 FOR UPDATE`, authorID,
+	).Scan(&status.FollowersCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return status, ErrNotFound
+	}
+	if err != nil {
+		return status, fmt.Errorf("load author: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`DELETE FROM author_follows WHERE user_id = $1 AND author_id = $2`, userID, authorID)
+	if err != nil {
+		return status, fmt.Errorf("delete follow: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return status, fmt.Errorf("rows affected: %w", err)
+	}
+
+	if deleted > 0 {
+		err = tx.QueryRowContext(ctx, `
+			UPDATE authors SET followers_count = GREATEST(followers_count - 1, 0) WHERE id = $1
+			RETURNING followers_count
+		`, authorID).Scan(&status.FollowersCount)
+		if err != nil {
+			return status, fmt.Errorf("update followers count: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return status, fmt.Errorf("commit: %w", err)
+	}
+	return status, nil
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type FriendRepository struct {
+	db *sql.DB
+}
+
+func NewFriendRepository(db *sql.DB) *FriendRepository {
+	return &FriendRepository{db: db}
+}
+
+// Add добавляет пользователя в список друзей; повторное добавление ничего не меняет
+func (r *FriendRepository) Add(ctx context.Context, userID, friendID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_friends (user_id, friend_id)
+		SELECT $1, id FROM users WHERE id = $2
+		ON CONFLICT DO NOTHING
+	`, userID, friendID)
+	if err != nil {
+		return fmt.Errorf("add friend: %w", err)
+	}
+	return nil
+}
+
+// Remove убирает пользователя из списка друзей
+func (r *FriendRepository) Remove(ctx context.Context, userID, friendID string) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM user_friends WHERE user_id = $1 AND friend_id = $2`, userID, friendID)
+	if err != nil {
+		return fmt.Errorf("remove friend: %w", err)
+	}
+	return nil
+}
+
+// IDs возвращает ID друзей пользователя
+func (r *FriendRepository) IDs(ctx context.Context, userID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT friend_id::text FROM user_friends WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return ids, nil
+}
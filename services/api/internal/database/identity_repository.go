@@ -0,0 +1,201 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/profile"
+)
+
+var (
+	// ErrIdentityEmailUnverified - провайдер не подтвердил email, по нему нельзя ни привязать, ни создать аккаунт
+	ErrIdentityEmailUnverified = errors.New("identity email is not verified by provider")
+	// ErrAccountEmailUnverified - аккаунт с этим email есть, но его владелец email не подтвердил:
+	// привязка позволила бы захватить аккаунт, созданный на чужой адрес
+	ErrAccountEmailUnverified = errors.New("existing account email is not verified")
+)
+
+// Основа username короче 50 символов колонки, чтобы поместился суффикс "_1234";
+// сколько раз пробовать случайный суффикс
+const (
+	usernameBaseLength = 40
+	usernameAttempts   = 10
+)
+
+// IdentityRepository - вход через внешних провайдеров OIDC
+type IdentityRepository struct {
+	db *sql.DB
+}
+
+func NewIdentityRepository(db *sql.DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+// CreateState запоминает начатый вход до возврата пользователя от провайдера
+func (r *IdentityRepository) CreateState(ctx context.Context, stateHash, provider, verifier, nonce string, expiresAt, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO oidc_login_states (state_hash, provider, code_verifier, nonce, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, stateHash, provider, verifier, nonce, expiresAt, at)
+	if err != nil {
+		return fmt.Errorf("insert state: %w", err)
+	}
+	return nil
+}
+
+// ConsumeState гасит state и возвращает PKCE code_verifier и nonce этого входа
+func (r *IdentityRepository) ConsumeState(ctx context.Context, stateHash, provider string, now time.Time) (verifier, nonce string, err error) {
+	var expiresAt time.Time
+	err = r.db.QueryRowContext(ctx, `
+		UPDATE oidc_login_states SET used_at = $3
+		WHERE state_hash = $1 AND provider = $2 AND used_at IS NULL
+		RETURNING code_verifier, nonce, expires_at
+	`, stateHash, provider, now).Scan(&verifier, &nonce, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", ErrTokenInvalid
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("query error: %w", err)
+	}
+	if !now.Before(expiresAt) {
+		return "", "", ErrTokenExpired
+	}
+	return verifier, nonce, nil
+}
+
+// SignIn находит пользователя внешней учетной записи. Новая учетная запись привязывается
+// к пользователю с тем же email (оба email должны быть подтверждены), иначе создается
+// пользователь без пароля. passwordHash - значение password_hash для нового пользователя
+func (r *IdentityRepository) SignIn(ctx context.Context, id models.ExternalIdentity, passwordHash string, at time.Time) (string, models.IdentityOutcome, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE user_identities SET last_login_at = $3, email = NULLIF($4, '')
+		WHERE provider = $1 AND subject = $2
+		RETURNING user_id::text
+	`, id.Provider, id.Subject, at, id.Email).Scan(&userID)
+	if err == nil {
+		if err := tx.Commit(); err != nil {
+			return "", "", fmt.Errorf("commit: %w", err)
+		}
+		return userID, models.IdentityExisting, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", "", fmt.Errorf("query error: %w", err)
+	}
+
+	if !id.EmailVerified || id.Email == "" {
+		return "", "", ErrIdentityEmailUnverified
+	}
+
+	outcome := models.IdentityLinked
+	var verified bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT id::text, email_verified_at IS NOT NULL FROM users WHERE LOWER(email) = LOWER($1) FOR UPDATE
+	`, id.Email).Scan(&userID, &verified)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		outcome = models.IdentityCreated
+		userID, err = createIdentityUser(ctx, tx, id, passwordHash, at)
+		if err != nil {
+			return "", "", err
+		}
+	case err != nil:
+		return "", "", fmt.Errorf("query error: %w", err)
+	case !verified:
+		return "", "", ErrAccountEmailUnverified
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_identities (user_id, provider, subject, email, last_login_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+	`, userID, id.Provider, id.Subject, id.Email, at)
+	if err != nil {
+		// UNIQUE (user_id, provider): к аккаунту уже привязана другая учетная запись провайдера
+		return "", "", fmt.Errorf("insert identity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", "", fmt.Errorf("commit: %w", err)
+	}
+	return userID, outcome, nil
+}
+
+// createIdentityUser создает пользователя с подтвержденным провайдером email.
+// Username строится из email; при занятости добавляется случайный суффикс
+func createIdentityUser(ctx context.Context, tx *sql.Tx, id models.ExternalIdentity, passwordHash string, at time.Time) (string, error) {
+	base := usernameBase(id.Email)
+
+	var fullName *string
+	if name := strings.TrimSpace(id.Name); name != "" {
+		fullName = &name
+	}
+
+	for attempt := 0; attempt < usernameAttempts; attempt++ {
+		username := base
+		if attempt > 0 {
+			n, err := rand.Int(rand.Reader, big.NewInt(10000))
+			if err != nil {
+				return "", fmt.Errorf("username suffix: %w", err)
+			}
+			username = fmt.Sprintf("%s_%04d", base, n.Int64())
+		}
+
+		// Имя не должно быть занято или зарезервировано за прежним владельцем (как в
+		// UserRepository.Taken). ON CONFLICT: имя могли занять одновременно с проверкой,
+		// тогда пробуем следующее
+		var userID string
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO users (email, username, username_skeleton, password_hash, full_name, email_verified_at, created_at, updated_at)
+			SELECT $1, $2, $6, $3, $4, $5, $5, $5
+			WHERE NOT EXISTS (SELECT 1 FROM users WHERE LOWER(username) = LOWER($2) OR username_skeleton = $6)
+			  AND NOT EXISTS (SELECT 1 FROM username_reservations
+			                  WHERE LOWER(username) = LOWER($2) AND reserved_until > $5)
+			ON CONFLICT DO NOTHING
+			RETURNING id::text
+		`, id.Email, username, passwordHash, fullName, at, profile.UsernameSkeleton(username)).Scan(&userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("insert user: %w", err)
+		}
+		return userID, nil
+	}
+	return "", fmt.Errorf("no free username for %q", base)
+}
+
+// usernameBase - часть email до @ из латиницы, цифр и _, от 3 до usernameBaseLength
+// символов: вместе с суффиксом имя помещается в users.username
+func usernameBase(email string) string {
+	local, _, _ := strings.Cut(email, "@")
+	var b strings.Builder
+	for _, c := range strings.ToLower(local) {
+		if b.Len() >= usernameBaseLength {
+			break
+		}
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '_':
+			b.WriteByte(byte(c))
+		case c == '.' || c == '-' || c == '+':
+			b.WriteByte('_')
+		}
+	}
+	base := strings.Trim(b.String(), "_")
+	if len(base) < 3 {
+		base = "user" + base
+	}
+	return base
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// LeaderboardRepository - данные из Postgres для рейтингов и их восстановления
+type LeaderboardRepository struct {
+	db *sql.DB
+}
+
+func NewLeaderboardRepository(db *sql.DB) *LeaderboardRepository {
+	return &LeaderboardRepository{db: db}
+}
+
+// TotalPoints возвращает баланс пользователей с ненулевыми баллами по журналу баллов.
+// Обезличенные удаленные учетные записи в рейтинги не попадают
+func (r *LeaderboardRepository) TotalPoints(ctx context.Context) (map[string]float64, error) {
+	return r.scores(ctx, `
+		SELECT l.user_id::text, SUM(l.amount)
+		FROM points_ledger l
+		JOIN users u ON u.id = l.user_id AND u.deleted_at IS NULL
+		GROUP BY l.user_id
+		HAVING SUM(l.amount) > 0
+	`)
+}
+
+// PointsSince возвращает изменение баланса с момента since: все записи журнала
+// учитываются со знаком, так же как их применяет к рейтингам leaderboard.Service.AddPoints
+func (r *LeaderboardRepository) PointsSince(ctx context.Context, since time.Time) (map[string]float64, error) {
+	return r.scores(ctx, `
+		SELECT l.user_id::text, SUM(l.amount)
+		FROM points_ledger l
+		JOIN users u ON u.id = l.user_id AND u.deleted_at IS NULL
+		WHERE l.created_at >= $1
+		GROUP BY l.user_id
+		HAVING SUM(l.amount) > 0
+	`, since)
+}
+
+// Streaks возвращает текущие серии пользователей
+func (r *LeaderboardRepository) Streaks(ctx context.Context) (map[string]float64, error) {
+	return r.scores(ctx, `SELECT id::text, current_streak FROM users WHERE current_streak > 0 AND deleted_at IS NULL`)
+}
+
+func (r *LeaderboardRepository) scores(ctx context.Context, query string, args ...any) (map[string]float64, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	scores := map[string]float64{}
+	for rows.Next() {
+		var userID string
+		var score float64
+		if err := rows.Scan(&userID, &score); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		scores[userID] = score
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return scores, nil
+}
+
+// Usernames возвращает имена пользователей по их ID
+func (r *LeaderboardRepository) Usernames(ctx context.Context, userIDs []string) (map[string]string, error) {
+	names := make(map[string]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return names, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id::text, username FROM users WHERE id = ANY($1::uuid[])`, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, username string
+		if err := rows.Scan(&id, &username); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		names[id] = username
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return names, nil
+}
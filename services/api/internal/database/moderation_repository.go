@@ -0,0 +1,189 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+)
+
+// ModerationRepository - очередь и решения модераторов по видео и комментариям
+type ModerationRepository struct {
+	db *sql.DB
+}
+
+func NewModerationRepository(db *sql.DB) *ModerationRepository {
+	return &ModerationRepository{db: db}
+}
+
+// PendingComments возвращает комментарии, ожидающие модератора, от давних к новым
+func (r *ModerationRepository) PendingComments(ctx context.Context, limit int) ([]models.FlaggedComment, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+commentColumns+`, v.title, c.flagged_keywords
+		FROM comments c
+		JOIN users u ON u.id = c.user_id
+		JOIN videos v ON v.id = c.video_id
+		WHERE c.moderation_status = 'pending' AND c.deleted_at IS NULL
+		ORDER BY c.created_at
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	queue := []models.FlaggedComment{}
+	for rows.Next() {
+		var f models.FlaggedComment
+		var editedAt sql.NullTime
+		var keywordsRaw []byte
+		err := rows.Scan(
+			&f.ID, &f.VideoID, &f.ParentID, &f.UserID, &f.Username,
+			&f.Body, &f.Status, &f.Pinned, &f.Deleted, &editedAt, &f.CreatedAt,
+			&f.ReplyCount, &f.VideoTitle, &keywordsRaw,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		if editedAt.Valid {
+			f.EditedAt = &editedAt.Time
+		}
+		f.FlaggedKeywords = parsePostgresArray(string(keywordsRaw))
+		queue = append(queue, f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return queue, nil
+}
+
+// ReportedTargets возвращает объекты с открытыми жалобами: сначала с наибольшим числом жалоб
+func (r *ModerationRepository) ReportedTargets(ctx context.Context, limit int) ([]models.ReportedTarget, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT rp.target_type, rp.target_id::text,
+			COALESCE(v.moderation_status, c.moderation_status, ''),
+			COUNT(*), MIN(rp.created_at),
+			COUNT(*) FILTER (WHERE rp.reason = 'misinformation'),
+			COUNT(*) FILTER (WHERE rp.reason = 'spam'),
+			COUNT(*) FILTER (WHERE rp.reason = 'offensive'),
+			COUNT(*) FILTER (WHERE rp.reason = 'copyright')
+		FROM reports rp
+		LEFT JOIN videos v ON rp.target_type = 'video' AND v.id = rp.target_id
+		LEFT JOIN comments c ON rp.target_type = 'comment' AND c.id = rp.target_id
+		WHERE rp.status = 'open'
+		GROUP BY rp.target_type, rp.target_id, v.moderation_status, c.moderation_status
+		ORDER BY COUNT(*) DESC, MIN(rp.created_at)
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	targets := []models.ReportedTarget{}
+	for rows.Next() {
+		var t models.ReportedTarget
+		var misinformation, spam, offensive, copyright int
+		err := rows.Scan(
+			&t.TargetType, &t.TargetID, &t.Status, &t.Reports, &t.FirstReport,
+			&misinformation, &spam, &offensive, &copyright,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		t.Reasons = map[models.ReportReason]int{}
+		for reason, n := range map[models.ReportReason]int{
+			models.ReportMisinformation: misinformation,
+			models.ReportSpam:           spam,
+			models.ReportOffensive:      offensive,
+			models.ReportCopyright:      copyright,
+		} {
+			if n > 0 {
+				t.Reasons[reason] = n
+			}
+		}
+		targets = append(targets, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return targets, nil
+}
+
+// DecideComment сохраняет решение модератора и закрывает жалобы на комментарий;
+// отклоненный комментарий открепляется
+func (r *ModerationRepository) DecideComment(ctx context.Context, commentID, moderatorID string, status models.ModerationStatus, at time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE comments
+		SET moderation_status = $2,
+			moderated_by = $3,
+			moderated_at = $4,
+			is_pinned = is_pinned AND $2 = 'approved'
+		WHERE id = $1 AND deleted_at IS NULL
+	`, commentID, status, moderatorID, at)
+	if err != nil {
+		return fmt.Errorf("update comment: %w", err)
+	}
+	if err := expectOneRow(res); err != nil {
+		return err
+	}
+
+	if err := resolveReports(ctx, tx, models.ReportTargetComment, commentID, moderatorID, status, at); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// DecideVideo меняет статус модерации видео и закрывает жалобы на него
+func (r *ModerationRepository) DecideVideo(ctx context.Context, videoID, moderatorID string, status models.ModerationStatus, at time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE videos SET moderation_status = $2 WHERE id = $1`, videoID, status)
+	if err != nil {
+		return fmt.Errorf("update video: %w", err)
+	}
+	if err := expectOneRow(res); err != nil {
+		return err
+	}
+
+	if err := resolveReports(ctx, tx, models.ReportTargetVideo, videoID, moderatorID, status, at); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+func expectOneRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
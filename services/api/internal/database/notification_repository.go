@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+)
+
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// notify создает уведомление в транзакции события, которое его вызвало
+func notify(ctx context.Context, tx *sql.Tx, userID string, t models.NotificationType, actorID string, payload map[string]any, at time.Time) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO notifications (user_id, notification_type, actor_id, payload, created_at)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5)
+	`, userID, t, actorID, data, at)
+	if err != nil {
+		return fmt.Errorf("insert notification: %w", err)
+	}
+	return nil
+}
+
+// List возвращает последние уведомления пользователя и число непрочитанных
+func (r *NotificationRepository) List(ctx context.Context, userID string, limit int) (models.NotificationList, error) {
+	list := models.NotificationList{Notifications: []models.Notification{}}
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID,
+	).Scan(&list.Unread)
+	if err != nil {
+		return list, fmt.Errorf("count unread: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, notification_type, COALESCE(actor_id::text, ''), payload, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return list, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var n models.Notification
+		var payload []byte
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.Type, &n.ActorID, &payload, &readAt, &n.CreatedAt); err != nil {
+			return list, fmt.Errorf("scan error: %w", err)
+		}
+		if err := json.Unmarshal(payload, &n.Payload); err != nil {
+			return list, fmt.Errorf("decode payload: %w", err)
+		}
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+		}
+		list.Notifications = append(list.Notifications, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return list, fmt.Errorf("rows error: %w", err)
+	}
+
+	return list, nil
+}
+
+// MarkAllRead отмечает прочитанными все уведомления пользователя и возвращает их число
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID string, at time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE notifications SET read_at = $2 WHERE user_id = $1 AND read_at IS NULL`, userID, at)
+	if err != nil {
+		return 0, fmt.Errorf("mark read: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PasswordRepository - смена и сброс пароля
+type PasswordRepository struct {
+	db *sql.DB
+}
+
+func NewPasswordRepository(db *sql.DB) *PasswordRepository {
+	return &PasswordRepository{db: db}
+}
+
+// ResetRecipient находит пользователя по email и историю писем сброса за сутки
+func (r *PasswordRepository) ResetRecipient(ctx context.Context, email string, now time.Time) (userID, username string, lastSentAt *time.Time, sentToday int, err error) {
+	var lastSent sql.NullTime
+	err = r.db.QueryRowContext(ctx, `
+		SELECT u.id::text, u.username,
+			(SELECT MAX(created_at) FROM password_reset_tokens WHERE user_id = u.id),
+			(SELECT COUNT(*) FROM password_reset_tokens
+			 WHERE user_id = u.id AND created_at > $2::timestamp - INTERVAL '24 hours')
+		FROM users u
+		WHERE LOWER(u.email) = LOWER($1)
+	`, email, now).Scan(&userID, &username, &lastSent, &sentToday)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil, 0, ErrNotFound
+	}
+	if err != nil {
+		return "", "", nil, 0, fmt.Errorf("query error: %w", err)
+	}
+	if lastSent.Valid {
+		lastSentAt = &lastSent.Time
+	}
+	return userID, username, lastSentAt, sentToday, nil
+}
+
+// CreateResetToken сохраняет хэш токена сброса
+func (r *PasswordRepository) CreateResetToken(ctx context.Context, userID, tokenHash string, expiresAt, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, userID, tokenHash, expiresAt, at)
+	if err != nil {
+		return fmt.Errorf("insert token: %w", err)
+	}
+	return nil
+}
+
+// ResetTarget возвращает логин и email владельца действующего токена сброса,
+// не гася токен: по ним новый пароль проверяется политикой до Reset
+func (r *PasswordRepository) ResetTarget(ctx context.Context, tokenHash string, now time.Time) (username, email string, err error) {
+	var expiresAt time.Time
+	var used bool
+	err = r.db.QueryRowContext(ctx, `
+		SELECT u.username, u.email, t.expires_at, t.used_at IS NOT NULL
+		FROM password_reset_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1
+	`, tokenHash).Scan(&username, &email, &expiresAt, &used)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && used) {
+		return "", "", ErrTokenInvalid
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("query error: %w", err)
+	}
+	if !now.Before(expiresAt) {
+		return "", "", ErrTokenExpired
+	}
+	return username, email, nil
+}
+
+// Reset задает новый пароль по токену: токен гасится вместе с остальными ссылками
+// пользователя, все сессии закрываются
+func (r *PasswordRepository) Reset(ctx context.Context, tokenHash, newHash string, at time.Time) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID string
+	var expiresAt time.Time
+	var used bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT user_id::text, expires_at, used_at IS NOT NULL
+		FROM password_reset_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`, tokenHash).Scan(&userID, &expiresAt, &used)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && used) {
+		return "", ErrTokenInvalid
+	}
+	if err != nil {
+		return "", fmt.Errorf("load token: %w", err)
+	}
+	if !at.Before(expiresAt) {
+		return "", ErrTokenExpired
+	}
+
+	if err := setPassword(ctx, tx, userID, newHash, at); err != nil {
+		return "", err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE password_reset_tokens SET used_at = $2 WHERE user_id = $1 AND used_at IS NULL
+	`, userID, at)
+	if err != nil {
+		return "", fmt.Errorf("use tokens: %w", err)
+	}
+
+	if _, err := revokeSessions(ctx, tx, userID, "", at); err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("commit: %w", err)
+	}
+	return userID, nil
+}
+
+// Change задает новый пароль и закрывает все сессии, кроме текущей (keepSessionID).
+// Текущий пароль проверяет вызывающий код
+func (r *PasswordRepository) Change(ctx context.Context, userID, newHash, keepSessionID string, at time.Time) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := setPassword(ctx, tx, userID, newHash, at); err != nil {
+		return 0, err
+	}
+
+	revoked, err := revokeSessions(ctx, tx, userID, keepSessionID, at)
+	if err != nil {
+		return 0, err
+	}
+
+	// Ссылки сброса, выданные до смены пароля, больше не нужны
+	_, err = tx.ExecContext(ctx, `
+		UPDATE password_reset_tokens SET used_at = $2 WHERE user_id = $1 AND used_at IS NULL
+	`, userID, at)
+	if err != nil {
+		return 0, fmt.Errorf("use tokens: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	return revoked, nil
+}
+
+func setPassword(ctx context.Context, tx *sql.Tx, userID, hash string, at time.Time) error {
+	res, err := tx.ExecContext(ctx,
+		`UPDATE users SET password_hash = $2, updated_at = $3 WHERE id = $1`, userID, hash, at)
+	if err != nil {
+		return fmt.Errorf("update password: %w", err)
+	}
+	return expectOneRow(res)
+}
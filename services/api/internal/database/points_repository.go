@@ -0,0 +1,254 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/mindly/api/internal/models"
+)
+
+// PointsRepository - журнал баллов points_ledger и сверка кэшированных счетчиков с ним
+type PointsRepository struct {
+	db *sql.DB
+}
+
+func NewPointsRepository(db *sql.DB) *PointsRepository {
+	return &PointsRepository{db: db}
+}
+
+// ledgerBalance блокирует строку пользователя и возвращает его баланс по журналу:
+// balance_after последней записи. Кэш users.score здесь не читается, чтобы его
+// расхождение с журналом не попадало в новые записи и находилось сверкой
+func ledgerBalance(ctx context.Context, tx *sql.Tx, userID string) (int, error) {
+	var balance int
+	err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE((
+			SELECT l.balance_after FROM points_ledger l
+			WHERE l.user_id = u.id
+			ORDER BY l.id DESC
+			LIMIT 1
+		), 0)
+		FROM users u
+		WHERE u.id = $1
+		FOR UPDATE OF u
+	`, userID).Scan(&balance)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("lock balance: %w", err)
+	}
+	return balance, nil
+}
+
+// appendPoints добавляет запись в журнал баллов и в той же транзакции обновляет
+// кэши баланса users.score и user_stats.total_points. Возвращает false, если начисление
+// с такими же reason и reference уже есть (тогда ничего не меняется).
+// Баллы за видео в user_video_progress.points_earned обновляет вызывающий код.
+func appendPoints(ctx context.Context, tx *sql.Tx, e *models.PointsEntry) (bool, error) {
+	balance, err := ledgerBalance(ctx, tx, e.UserID)
+	if err != nil {
+		return false, err
+	}
+	e.BalanceAfter = balance + e.Amount
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO points_ledger (user_id, amount, reason, reference, video_id, note, balance_after)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, '')::uuid, NULLIF($6, ''), $7)
+		ON CONFLICT (user_id, reason, reference) WHERE reference IS NOT NULL DO NOTHING
+		RETURNING id, created_at
+	`, e.UserID, e.Amount, e.Reason, e.Reference, e.VideoID, e.Note, e.BalanceAfter).Scan(&e.ID, &e.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("insert ledger entry: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users SET score = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1
+	`, e.UserID, e.BalanceAfter)
+	if err != nil {
+		return false, fmt.Errorf("update user score: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_stats (user_id, total_points, last_activity_date, updated_at)
+		VALUES ($1, $2, CURRENT_DATE, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id) DO UPDATE SET
+			total_points = EXCLUDED.total_points,
+			last_activity_date = CURRENT_DATE,
+			updated_at = CURRENT_TIMESTAMP
+	`, e.UserID, e.BalanceAfter)
+	if err != nil {
+		return false, fmt.Errorf("update user stats: %w", err)
+	}
+	return true, nil
+}
+
+// Adjust вручную начисляет или списывает баллы (admin_adjustment) с обязательным комментарием
+func (r *PointsRepository) Adjust(ctx context.Context, userID string, amount int, note string) (models.PointsEntry, error) {
+	entry := models.PointsEntry{
+		UserID: userID,
+		Amount: amount,
+		Reason: models.PointsAdminAdjustment,
+		Note:   note,
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entry, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := appendPoints(ctx, tx, &entry); err != nil {
+		return entry, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entry, fmt.Errorf("commit: %w", err)
+	}
+	return entry, nil
+}
+
+const userDriftQuery = `
+	WITH ledger AS (
+		SELECT user_id, SUM(amount)::int AS balance FROM points_ledger GROUP BY user_id
+	)
+	SELECT u.id::text, COALESCE(l.balance, 0), COALESCE(u.score, 0), COALESCE(s.total_points, 0)
+	FROM users u
+	LEFT JOIN ledger l ON l.user_id = u.id
+	LEFT JOIN user_stats s ON s.user_id = u.id
+	WHERE COALESCE(l.balance, 0) <> COALESCE(u.score, 0)
+	   OR (s.user_id IS NOT NULL AND COALESCE(l.balance, 0) <> COALESCE(s.total_points, 0))
+	ORDER BY u.id
+`
+
+const videoDriftQuery = `
+	WITH ledger AS (
+		SELECT user_id, video_id, SUM(amount)::int AS points
+		FROM points_ledger
+		WHERE reason = 'quiz_correct' AND video_id IS NOT NULL
+		GROUP BY user_id, video_id
+	)
+	SELECT p.user_id::text, p.video_id::text, COALESCE(l.points, 0), COALESCE(p.points_earned, 0)
+	FROM user_video_progress p
+	LEFT JOIN ledger l ON l.user_id = p.user_id AND l.video_id = p.video_id
+	WHERE COALESCE(l.points, 0) <> COALESCE(p.points_earned, 0)
+	ORDER BY p.user_id, p.video_id
+`
+
+// Drift находит пользователей, у которых кэшированный баланс расходится с журналом
+func (r *PointsRepository) Drift(ctx context.Context) ([]models.PointsDrift, error) {
+	rows, err := r.db.QueryContext(ctx, userDriftQuery)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var drift []models.PointsDrift
+	for rows.Next() {
+		var d models.PointsDrift
+		if err := rows.Scan(&d.UserID, &d.LedgerBalance, &d.Score, &d.StatsTotal); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		drift = append(drift, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return drift, nil
+}
+
+// VideoDrift находит расхождения баллов за видео с журналом
+func (r *PointsRepository) VideoDrift(ctx context.Context) ([]models.VideoPointsDrift, error) {
+	rows, err := r.db.QueryContext(ctx, videoDriftQuery)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var drift []models.VideoPointsDrift
+	for rows.Next() {
+		var d models.VideoPointsDrift
+		if err := rows.Scan(&d.UserID, &d.VideoID, &d.Ledger, &d.Cached); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		drift = append(drift, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return drift, nil
+}
+
+// Reconcile переписывает кэшированные счетчики значениями из журнала
+func (r *PointsRepository) Reconcile(ctx context.Context) (models.ReconcileResult, error) {
+	var result models.ReconcileResult
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Блокируем журнал от новых записей, чтобы сверка шла по согласованному снимку
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE points_ledger IN SHARE MODE`); err != nil {
+		return result, fmt.Errorf("lock ledger: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		WITH ledger AS (
+			SELECT u.id AS user_id, COALESCE(SUM(pl.amount), 0)::int AS balance
+			FROM users u
+			LEFT JOIN points_ledger pl ON pl.user_id = u.id
+			GROUP BY u.id
+		)
+		UPDATE users u SET score = l.balance, updated_at = CURRENT_TIMESTAMP
+		FROM ledger l
+		WHERE l.user_id = u.id AND COALESCE(u.score, 0) <> l.balance
+	`)
+	if err != nil {
+		return result, fmt.Errorf("fix users: %w", err)
+	}
+	result.Users, _ = res.RowsAffected()
+
+	res, err = tx.ExecContext(ctx, `
+		UPDATE user_stats s SET total_points = COALESCE(u.score, 0), updated_at = CURRENT_TIMESTAMP
+		FROM users u
+		WHERE u.id = s.user_id AND COALESCE(s.total_points, 0) <> COALESCE(u.score, 0)
+	`)
+	if err != nil {
+		return result, fmt.Errorf("fix stats: %w", err)
+	}
+	result.Stats, _ = res.RowsAffected()
+
+	res, err = tx.ExecContext(ctx, `
+		WITH ledger AS (
+			SELECT user_id, video_id, SUM(amount)::int AS points
+			FROM points_ledger
+			WHERE reason = 'quiz_correct' AND video_id IS NOT NULL
+			GROUP BY user_id, video_id
+		)
+		UPDATE user_video_progress p SET points_earned = COALESCE(l.points, 0)
+		FROM user_video_progress p2
+		LEFT JOIN ledger l ON l.user_id = p2.user_id AND l.video_id = p2.video_id
+		WHERE p2.user_id = p.user_id AND p2.video_id = p.video_id
+		  AND COALESCE(p.points_earned, 0) <> COALESCE(l.points, 0)
+	`)
+	if err != nil {
+		return result, fmt.Errorf("fix video progress: %w", err)
+	}
+	result.Videos, _ = res.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("commit: %w", err)
+	}
+	return result, nil
+}
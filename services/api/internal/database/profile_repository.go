@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/profile"
+)
+
+var (
+	// ErrUsernameTaken - имя занято другим пользователем или закреплено за прежним владельцем
+	ErrUsernameTaken = errors.New("username is taken")
+	// ErrUsernameCooldown - username менялся слишком недавно
+	ErrUsernameCooldown = errors.New("username was changed recently")
+)
+
+// ProfileRepository - редактирование профиля текущим пользователем
+type ProfileRepository struct {
+	db *sql.DB
+}
+
+func NewProfileRepository(db *sql.DB) *ProfileRepository {
+	return &ProfileRepository{db: db}
+}
+
+// Update меняет username и full_name. Старый username закрепляется за пользователем
+// на reservation, следующая смена username возможна не раньше чем через cooldown
+func (r *ProfileRepository) Update(ctx context.Context, userID string, upd models.ProfileUpdate, cooldown, reservation time.Duration, now time.Time) (models.User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.User{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	user, err := scanUser(tx.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1 FOR UPDATE`, userID))
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if upd.Username != nil && *upd.Username != user.Username {
+		if err := changeUsername(ctx, tx, user, *upd.Username, cooldown, reservation, now); err != nil {
+			return models.User{}, err
+		}
+	}
+
+	if upd.FullName.Set {
+		_, err := tx.ExecContext(ctx, `UPDATE users SET full_name = $2, updated_at = $3 WHERE id = $1`,
+			userID, upd.FullName.Value, now)
+		if err != nil {
+			return models.User{}, fmt.Errorf("update full name: %w", err)
+		}
+	}
+
+	user, err = scanUser(tx.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, userID))
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.User{}, fmt.Errorf("commit: %w", err)
+	}
+	return user, nil
+}
+
+func changeUsername(ctx context.Context, tx *sql.Tx, user models.User, username string, cooldown, reservation time.Duration, now time.Time) error {
+	// Смена только регистра букв своего же имени не занимает чужое и не требует резерва
+	sameName := strings.EqualFold(username, user.Username)
+
+	if !sameName && user.UsernameChangedAt != nil && now.Before(user.UsernameChangedAt.Add(cooldown)) {
+		return ErrUsernameCooldown
+	}
+
+	// Имя свободно, если его нет у других пользователей (в том числе в виде,
+	// неотличимом по скелету) и оно не закреплено за кем-то другим
+	skeleton := profile.UsernameSkeleton(username)
+	var taken bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM users
+		              WHERE (LOWER(username) = LOWER($1) OR username_skeleton = $4) AND id <> $2)
+			OR EXISTS(SELECT 1 FROM username_reservations
+			          WHERE LOWER(username) = LOWER($1) AND user_id <> $2 AND reserved_until > $3)
+	`, username, user.ID, now, skeleton).Scan(&taken)
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+	if taken {
+		return ErrUsernameTaken
+	}
+
+	if !sameName {
+		// Освобождаем просроченный резерв на новое имя и резерв самого пользователя на него
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM username_reservations
+			WHERE LOWER(username) = LOWER($1) AND (user_id = $2 OR reserved_until <= $3)
+		`, username, user.ID, now)
+		if err != nil {
+			return fmt.Errorf("release reservation: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO username_reservations (username, user_id, reserved_until, created_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (username) DO UPDATE
+			SET user_id = EXCLUDED.user_id, reserved_until = EXCLUDED.reserved_until, created_at = EXCLUDED.created_at
+		`, user.Username, user.ID, now.Add(reservation), now)
+		if err != nil {
+			return fmt.Errorf("reserve old username: %w", err)
+		}
+	}
+
+	// Исправление регистра не сдвигает паузу между сменами
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users
+		SET username = $2, username_skeleton = $5, updated_at = $3,
+			username_changed_at = CASE WHEN $4 THEN username_changed_at ELSE $3 END
+		WHERE id = $1
+	`, user.ID, username, now, sameName, skeleton)
+	if err != nil {
+		return UserConflict(fmt.Errorf("update username: %w", err))
+	}
+	return nil
+}
+
+// SetAvatar сохраняет аватар (nil - удалить) и возвращает ключ прежнего файла для удаления
+func (r *ProfileRepository) SetAvatar(ctx context.Context, userID string, url, key *string, at time.Time) (string, error) {
+	var oldKey sql.NullString
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE users u SET avatar_url = $2, avatar_key = $3, updated_at = $4
+		FROM (SELECT id, avatar_key FROM users WHERE id = $1 FOR UPDATE) old
+		WHERE u.id = old.id
+		RETURNING old.avatar_key
+	`, userID, url, key, at).Scan(&oldKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("update avatar: %w", err)
+	}
+	return oldKey.String, nil
+}
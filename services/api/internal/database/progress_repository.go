@@ -0,0 +1,207 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/progression"
+	"github.com/mindly/api/internal/streak"
+)
+
+type ProgressRepository struct {
+	db *sql.DB
+}
+
+func NewProgressRepository(db *sql.DB) *ProgressRepository {
+	return &ProgressRepository{db: db}
+}
+
+// Today возвращает прогресс дневной цели, серию и уровень пользователя на день at
+func (r *ProgressRepository) Today(ctx context.Context, userID string, at time.Time) (models.TodayProgress, error) {
+	day := streak.Day(at)
+	today := models.TodayProgress{Date: day.Format("2006-01-02")}
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT daily_goal_type, daily_goal_target FROM users WHERE id = $1
+	`, userID).Scan(&today.Goal.Type, &today.Goal.Target)
+	if errors.Is(err, sql.ErrNoRows) {
+		return today, ErrNotFound
+	}
+	if err != nil {
+		return today, fmt.Errorf("query error: %w", err)
+	}
+
+	row, err := loadStreak(ctx, r.db, userID, false)
+	if err != nil {
+		return today, err
+	}
+
+	// Если сегодня уже была активность, действует цель, зафиксированная на этот день
+	var goalMetAt sql.NullTime
+	err = r.db.QueryRowContext(ctx, `
+		SELECT videos_watched, correct_answers, goal_type, goal_target, goal_met_at
+		FROM user_daily_activity
+		WHERE user_id = $1 AND activity_date = $2
+	`, userID, day).Scan(&today.VideosWatched, &today.CorrectAnswers, &today.Goal.Type, &today.Goal.Target, &goalMetAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return today, fmt.Errorf("query activity: %w", err)
+	}
+
+	today.GoalProgress = progression.Progress(today.Goal, today.VideosWatched, today.CorrectAnswers)
+	if goalMetAt.Valid {
+		today.GoalMet = true
+		today.GoalMetAt = &goalMetAt.Time
+	}
+	today.CurrentStreak = streak.Effective(row.state, day)
+	today.BestStreak = row.state.Best
+
+	// Уровень считается по заработанным баллам, а не по балансу: траты на заморозки
+	// и восстановление серии уровень не понижают
+	var earned int
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(amount), 0) FROM points_ledger
+		WHERE user_id = $1 AND amount > 0 AND reason NOT IN ('freeze_purchase', 'streak_repair')
+	`, userID).Scan(&earned)
+	if err != nil {
+		return today, fmt.Errorf("query earned points: %w", err)
+	}
+	today.Level = progression.LevelFor(earned)
+	return today, nil
+}
+
+// SetGoal меняет дневную цель. Если сегодня цель еще не выполнена, новая цель действует уже сегодня
+// (и может сразу оказаться выполненной, если ее снизили).
+func (r *ProgressRepository) SetGoal(ctx context.Context, userID string, goal models.DailyGoal, at time.Time) (models.GoalUpdate, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.GoalUpdate{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE users SET daily_goal_type = $2, daily_goal_target = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, userID, goal.Type, goal.Target)
+	if err != nil {
+		return models.GoalUpdate{}, fmt.Errorf("update goal: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.GoalUpdate{}, ErrNotFound
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE user_daily_activity SET goal_type = $3, goal_target = $4
+		WHERE user_id = $1 AND activity_date = $2 AND goal_met_at IS NULL
+	`, userID, streak.Day(at), goal.Type, goal.Target)
+	if err != nil {
+		return models.GoalUpdate{}, fmt.Errorf("update today goal: %w", err)
+	}
+
+	update, err := recordActivity(ctx, tx, userID, 0, 0, at)
+	if err != nil {
+		return update, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return update, fmt.Errorf("commit: %w", err)
+	}
+	return update, nil
+}
+
+// MarkWatched отмечает видео досмотренным. В дневную цель засчитывается только первый просмотр.
+func (r *ProgressRepository) MarkWatched(ctx context.Context, userID, videoID string, at time.Time) (models.WatchResult, error) {
+	result := models.WatchResult{VideoID: videoID}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM videos WHERE id = $1 AND moderation_status = 'approved')
+	`, videoID).Scan(&exists)
+	if err != nil {
+		return result, fmt.Errorf("check video: %w", err)
+	}
+	if !exists {
+		return result, ErrNotFound
+	}
+
+	var watched bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(is_watched, FALSE) FROM user_video_progress
+		WHERE user_id = $1 AND video_id = $2
+		FOR UPDATE
+	`, userID, videoID).Scan(&watched)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return result, fmt.Errorf("query progress: %w", err)
+	}
+	result.FirstWatch = !watched
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_video_progress (user_id, video_id, is_watched, watched_at, interaction_date)
+		VALUES ($1, $2, TRUE, $3, CURRENT_DATE)
+		ON CONFLICT (user_id, video_id) DO UPDATE SET
+			is_watched = TRUE,
+			watched_at = COALESCE(user_video_progress.watched_at, EXCLUDED.watched_at),
+			interaction_date = CURRENT_DATE
+	`, userID, videoID, at)
+	if err != nil {
+		return result, fmt.Errorf("upsert progress: %w", err)
+	}
+
+	if result.FirstWatch {
+		result.GoalUpdate, err = recordActivity(ctx, tx, userID, 1, 0, at)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("commit: %w", err)
+	}
+	return result, nil
+}
+
+// recordActivity учитывает действие в дневной активности пользователя. Когда действие
+// впервые за день выполняет цель, продлевает серию.
+func recordActivity(ctx context.Context, tx *sql.Tx, userID string, videos, correctAnswers int, at time.Time) (models.GoalUpdate, error) {
+	var update models.GoalUpdate
+	day := streak.Day(at)
+
+	var goal models.DailyGoal
+	var watched, correct int
+	var alreadyMet bool
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO user_daily_activity (
+			user_id, activity_date, videos_watched, correct_answers, goal_type, goal_target
+		)
+		SELECT id, $2, $3, $4, daily_goal_type, daily_goal_target FROM users WHERE id = $1
+		ON CONFLICT (user_id, activity_date) DO UPDATE SET
+			videos_watched = user_daily_activity.videos_watched + EXCLUDED.videos_watched,
+			correct_answers = user_daily_activity.correct_answers + EXCLUDED.correct_answers
+		RETURNING videos_watched, correct_answers, goal_type, goal_target, goal_met_at IS NOT NULL
+	`, userID, day, videos, correctAnswers).Scan(&watched, &correct, &goal.Type, &goal.Target, &alreadyMet)
+	if err != nil {
+		return update, fmt.Errorf("record activity: %w", err)
+	}
+
+	if alreadyMet || !progression.Met(goal, watched, correct) {
+		return update, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE user_daily_activity SET goal_met_at = $3 WHERE user_id = $1 AND activity_date = $2
+	`, userID, day, at)
+	if err != nil {
+		return update, fmt.Errorf("mark goal met: %w", err)
+	}
+
+	return advanceStreak(ctx, tx, userID, day)
+}
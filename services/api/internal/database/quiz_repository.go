@@ -0,0 +1,414 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mindly/api/internal/calibration"
+	"github.com/mindly/api/internal/models"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type QuizRepository struct {
+	db *sql.DB
+}
+
+func NewQuizRepository(db *sql.DB) *QuizRepository {
+	return &QuizRepository{db: db}
+}
+
+const questionColumns = `
+	q.id, q.video_id, q.position, q.question_type, q.question,
+	q.options, q.answer, COALESCE(q.explanation, ''), q.points_awarded, q.difficulty
+`
+
+func scanQuestion(row interface{ Scan(...any) error }) (models.QuizQuestion, error) {
+	var q models.QuizQuestion
+	var optionsRaw, answerRaw []byte
+	err := row.Scan(
+		&q.ID, &q.VideoID, &q.Position, &q.Type, &q.Question,
+		&optionsRaw, &answerRaw, &q.Explanation, &q.BasePoints, &q.Difficulty,
+	)
+	if err != nil {
+		return q, err
+	}
+	q.PointsAwarded = calibration.Points(q.BasePoints, q.Difficulty)
+	if err := json.Unmarshal(optionsRaw, &q.Options); err != nil {
+		return q, fmt.Errorf("options of question %s: %w", q.ID, err)
+	}
+	q.Answer = json.RawMessage(answerRaw)
+	return q, nil
+}
+
+// GetQuestions возвращает вопросы к видео в порядке показа
+func (r *QuizRepository) GetQuestions(ctx context.Context, videoID string) ([]models.QuizQuestion, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+questionColumns+`
+		FROM quiz_questions q
+		WHERE q.video_id = $1
+		ORDER BY q.position
+	`, videoID)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	questions := []models.QuizQuestion{}
+	for rows.Next() {
+		q, err := scanQuestion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		questions = append(questions, q)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return questions, nil
+}
+
+// GetQuestion возвращает вопрос, если он относится к указанному видео
+func (r *QuizRepository) GetQuestion(ctx context.Context, videoID, questionID string) (models.QuizQuestion, error) {
+	q, err := scanQuestion(r.db.QueryRowContext(ctx, `
+		SELECT `+questionColumns+`
+		FROM quiz_questions q
+		WHERE q.id = $1 AND q.video_id = $2
+	`, questionID, videoID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return q, ErrNotFound
+	}
+	if err != nil {
+		return q, fmt.Errorf("query error: %w", err)
+	}
+	return q, nil
+}
+
+// VideoAuthorUserID возвращает учетную запись автора видео (пустая строка, если не привязана)
+func (r *QuizRepository) VideoAuthorUserID(ctx context.Context, videoID string) (string, error) {
+	var userID sql.NullString
+	err := r.db.QueryRowContext(ctx, `
+		SELECT a.user_id::text
+		FROM videos v
+		JOIN authors a ON a.id = v.author_id
+		WHERE v.id = $1
+	`, videoID).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("query error: %w", err)
+	}
+	return userID.String, nil
+}
+
+// ErrUnknownQuestion - в сохраняемом тесте указан вопрос, которого нет у видео
+var ErrUnknownQuestion = errors.New("question does not belong to the video")
+
+// ErrAnswerKeyChanged - у существующего вопроса сменили тип или правильный ответ.
+// Прежние ответы, баллы и калибровка относятся к старому ключу, поэтому такой вопрос
+// нужно сохранить как новый (без ID)
+var ErrAnswerKeyChanged = errors.New("question type or answer cannot be changed")
+
+// SaveQuestions сохраняет тест к видео целиком. Вопросы с ID обновляются на месте:
+// их ответы, калибровка и начисления в журнале баллов сохраняются, поэтому тип и
+// правильный ответ у них менять нельзя (ErrAnswerKeyChanged). Вопросы без ID
+// добавляются, а не указанные в запросе удаляются каскадно вместе с ответами.
+func (r *QuizRepository) SaveQuestions(ctx context.Context, videoID string, questions []models.QuizQuestion) ([]models.QuizQuestion, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id::text FROM quiz_questions WHERE video_id = $1 FOR UPDATE
+	`, videoID)
+	if err != nil {
+		return nil, fmt.Errorf("lock questions: %w", err)
+	}
+	existing, err := scanKeys(rows)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(existing))
+	for _, id := range existing {
+		known[id] = true
+	}
+
+	kept := []string{}
+	for _, q := range questions {
+		if q.ID == "" {
+			continue
+		}
+		if !known[q.ID] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownQuestion, q.ID)
+		}
+		// Один вопрос не может занять две позиции
+		known[q.ID] = false
+		kept = append(kept, q.ID)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM quiz_questions WHERE video_id = $1 AND NOT (id::text = ANY($2))
+	`, videoID, pq.Array(kept))
+	if err != nil {
+		return nil, fmt.Errorf("delete questions: %w", err)
+	}
+
+	// Позиции уникальны в пределах видео: сначала освобождаем их, затем расставляем заново
+	_, err = tx.ExecContext(ctx, `UPDATE quiz_questions SET position = -position WHERE video_id = $1`, videoID)
+	if err != nil {
+		return nil, fmt.Errorf("release positions: %w", err)
+	}
+
+	for i, q := range questions {
+		optionsJSON, err := json.Marshal(q.Options)
+		if err != nil {
+			return nil, fmt.Errorf("marshal options: %w", err)
+		}
+
+		position := i + 1
+		if q.ID != "" {
+			res, err := tx.ExecContext(ctx, `
+				UPDATE quiz_questions
+				SET position = $2, question = $4, options = $5,
+					explanation = NULLIF($7, ''), points_awarded = $8
+				WHERE id = $1 AND question_type = $3 AND answer = $6::jsonb
+			`, q.ID, position, q.Type, q.Question, optionsJSON, []byte(q.Answer), q.Explanation, q.BasePoints)
+			if err != nil {
+				return nil, fmt.Errorf("update question %d: %w", position, err)
+			}
+			// Строка заблокирована выше, поэтому не обновиться она могла только из-за другого ключа
+			if n, _ := res.RowsAffected(); n == 0 {
+				return nil, fmt.Errorf("%w: %s", ErrAnswerKeyChanged, q.ID)
+			}
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO quiz_questions (
+				video_id, position, question_type, question, options, answer, explanation, points_awarded
+			) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
+		`, videoID, position, q.Type, q.Question, optionsJSON, []byte(q.Answer), q.Explanation, q.BasePoints)
+		if err != nil {
+			return nil, fmt.Errorf("insert question %d: %w", position, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return r.GetQuestions(ctx, videoID)
+}
+
+// ListFirstAttempts возвращает первую попытку каждого пользователя по каждому вопросу:
+// повторные попытки после показа правильного ответа искажают оценку сложности
+func (r *QuizRepository) ListFirstAttempts(ctx context.Context) ([]models.AnswerObservation, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (user_id, question_id) user_id::text, question_id::text, is_correct
+		FROM quiz_answers
+		ORDER BY user_id, question_id, answered_at
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var observations []models.AnswerObservation
+	for rows.Next() {
+		var o models.AnswerObservation
+		if err := rows.Scan(&o.UserID, &o.QuestionID, &o.Correct); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		observations = append(observations, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return observations, nil
+}
+
+// SaveCalibrations сохраняет оценки сложности вопросов
+func (r *QuizRepository) SaveCalibrations(ctx context.Context, calibrations []models.QuestionCalibration) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, c := range calibrations {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE quiz_questions
+			SET difficulty = $2, discrimination = $3, calibration_samples = $4,
+				correct_rate = $5, calibrated_at = $6
+			WHERE id = $1
+		`, c.QuestionID, c.Difficulty, c.Discrimination, c.Samples, c.CorrectRate, c.CalibratedAt)
+		if err != nil {
+			return fmt.Errorf("update question %s: %w", c.QuestionID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// AuthorQuestionStats возвращает калибровку всех вопросов к видео автора
+func (r *QuizRepository) AuthorQuestionStats(ctx context.Context, authorID string) ([]models.QuestionStats, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT q.id, q.video_id, v.title, q.position, q.question_type, q.question,
+			q.points_awarded, q.difficulty, q.discrimination,
+			q.calibration_samples, COALESCE(q.correct_rate, 0), q.calibrated_at
+		FROM quiz_questions q
+		JOIN videos v ON v.id = q.video_id
+		WHERE v.author_id = $1
+		ORDER BY v.created_at DESC, q.position
+	`, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []models.QuestionStats{}
+	for rows.Next() {
+		var s models.QuestionStats
+		var calibratedAt sql.NullTime
+		err := rows.Scan(
+			&s.QuestionID, &s.VideoID, &s.VideoTitle, &s.Position, &s.Type, &s.Question,
+			&s.BasePoints, &s.Difficulty, &s.Discrimination,
+			&s.Samples, &s.CorrectRate, &calibratedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		s.CalibratedAt = calibratedAt.Time
+		s.PointsAwarded = calibration.Points(s.BasePoints, s.Difficulty)
+		stats = append(stats, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return stats, nil
+}
+
+// RecordAnswer сохраняет ответ, обновляет прогресс по видео и начисляет баллы.
+// Баллы за вопрос начисляются, только если правильной была первая попытка:
+// после ошибки правильный ответ показывается, и повторная попытка баллов не дает.
+func (r *QuizRepository) RecordAnswer(ctx context.Context, userID string, q models.QuizQuestion, answer json.RawMessage, correct bool) (models.QuizAnswerResult, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.QuizAnswerResult{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := recordAnswer(ctx, tx, userID, q, answer, correct)
+	if err != nil {
+		return result, err
+	}
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("commit: %w", err)
+	}
+	return result, nil
+}
+
+// recordAnswer записывает ответ в транзакции tx; см. RecordAnswer
+func recordAnswer(ctx context.Context, tx *sql.Tx, userID string, q models.QuizQuestion, answer json.RawMessage, correct bool) (models.QuizAnswerResult, error) {
+	result := models.QuizAnswerResult{
+		QuestionID:    q.ID,
+		Correct:       correct,
+		CorrectAnswer: q.Answer,
+		Explanation:   q.Explanation,
+	}
+
+	var attempted, alreadyCorrect bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM quiz_answers WHERE user_id = $1 AND question_id = $2),
+			EXISTS(SELECT 1 FROM quiz_answers WHERE user_id = $1 AND question_id = $2 AND is_correct)
+	`, userID, q.ID).Scan(&attempted, &alreadyCorrect)
+	if err != nil {
+		return result, fmt.Errorf("check previous answers: %w", err)
+	}
+
+	// Начисление за вопрос уникально в журнале, поэтому и при гонке попыток баллы придут один раз
+	if correct && !attempted && q.PointsAwarded > 0 {
+		entry := models.PointsEntry{
+			UserID:    userID,
+			Amount:    q.PointsAwarded,
+			Reason:    models.PointsQuizCorrect,
+			Reference: q.ID,
+			VideoID:   q.VideoID,
+		}
+		awarded, err := appendPoints(ctx, tx, &entry)
+		if err != nil {
+			return result, err
+		}
+		if awarded {
+			result.PointsEarned = q.PointsAwarded
+		}
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO quiz_answers (user_id, question_id, answer, is_correct, points_earned)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING answered_at
+	`, userID, q.ID, []byte(answer), correct, result.PointsEarned).Scan(&result.AnsweredAt)
+	if err != nil {
+		return result, fmt.Errorf("insert answer: %w", err)
+	}
+
+	// Тест пройден, когда на каждый вопрос к видео есть правильный ответ
+	err = tx.QueryRowContext(ctx, `
+		SELECT NOT EXISTS (
+			SELECT 1 FROM quiz_questions qq
+			WHERE qq.video_id = $2
+			  AND NOT EXISTS (
+				SELECT 1 FROM quiz_answers a
+				WHERE a.question_id = qq.id AND a.user_id = $1 AND a.is_correct
+			  )
+		)
+	`, userID, q.VideoID).Scan(&result.QuizCompleted)
+	if err != nil {
+		return result, fmt.Errorf("check quiz completion: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_video_progress (
+			user_id, video_id, quiz_attempted, quiz_correct, points_earned, interaction_date
+		) VALUES ($1, $2, TRUE, $3, $4, CURRENT_DATE)
+		ON CONFLICT (user_id, video_id) DO UPDATE SET
+			quiz_attempted = TRUE,
+			quiz_correct = EXCLUDED.quiz_correct,
+			points_earned = user_video_progress.points_earned + EXCLUDED.points_earned,
+			interaction_date = CURRENT_DATE
+	`, userID, q.VideoID, result.QuizCompleted, result.PointsEarned)
+	if err != nil {
+		return result, fmt.Errorf("upsert progress: %w", err)
+	}
+
+	// В дневную цель засчитывается первый правильный ответ на вопрос
+	if correct && !alreadyCorrect {
+		result.GoalUpdate, err = recordActivity(ctx, tx, userID, 0, 1, result.AnsweredAt)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	// Ошибка в тесте отправляет видео в расписание повторений
+	if !correct {
+		if err := scheduleReview(ctx, tx, userID, q.VideoID, time.Now()); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+func DefaultRedisConfig() RedisConfig {
+	return RedisConfig{
+		Addr:     "localhost:6379",
+		Password: "",
+		DB:       0,
+	}
+}
+
+// ConnectRedis создает клиент Redis и проверяет соединение.
+// Клиент возвращается и при ошибке ping: он сам переподключится, когда Redis станет доступен.
+func ConnectRedis(ctx context.Context, cfg RedisConfig) (*redis.Client, error) {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:         cfg.Addr,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		DialTimeout:  5 * time.Second,
+		ReadTimeout:  3 * time.Second,
+		WriteTimeout: 3 * time.Second,
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		return rdb, fmt.Errorf("failed to ping redis: %w", err)
+	}
+
+	log.Println("✅ Redis подключен")
+	return rdb, nil
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+)
+
+type ReportRepository struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// Таблица и условие существования объекта жалобы
+var reportTargets = map[models.ReportTargetType]string{
+	models.ReportTargetVideo:   `SELECT moderation_status FROM videos WHERE id = $1 FOR UPDATE`,
+	models.ReportTargetComment: `SELECT moderation_status FROM comments WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`,
+}
+
+var reportHide = map[models.ReportTargetType]string{
+	models.ReportTargetVideo:   `UPDATE videos SET moderation_status = 'pending' WHERE id = $1`,
+	models.ReportTargetComment: `UPDATE comments SET moderation_status = 'pending', is_pinned = FALSE WHERE id = $1`,
+}
+
+// Create принимает жалобу. Повторная жалоба того же пользователя не создает новую запись.
+// Когда открытых жалоб становится threshold, одобренный объект скрывается до решения модератора
+func (r *ReportRepository) Create(ctx context.Context, reporterID string, req models.ReportRequest, threshold int, at time.Time) (models.ReportResult, error) {
+	var result models.ReportResult
+
+	lookup, ok := reportTargets[req.TargetType]
+	if !ok {
+		return result, ErrNotFound
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Строка объекта блокируется, чтобы подсчет жалоб и скрытие шли последовательно
+	var status models.ModerationStatus
+	err = tx.QueryRowContext(ctx, lookup, req.TargetID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && status == models.ModerationRejected) {
+		return result, ErrNotFound
+	}
+	if err != nil {
+		return result, fmt.Errorf("load target: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO reports (reporter_id, target_type, target_id, reason, details, created_at)
+		SELECT id, $2, $3, $4, NULLIF($5, ''), $6 FROM users WHERE id = $1
+		ON CONFLICT (reporter_id, target_type, target_id) DO NOTHING
+		RETURNING id
+	`, reporterID, req.TargetType, req.TargetID, req.Reason, req.Details, at).Scan(&result.ReportID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = tx.QueryRowContext(ctx, `
+			SELECT id FROM reports WHERE reporter_id = $1 AND target_type = $2 AND target_id = $3
+		`, reporterID, req.TargetType, req.TargetID).Scan(&result.ReportID)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Жалобы нет и она не вставилась - нет пользователя
+			return result, ErrNotFound
+		}
+		if err != nil {
+			return result, fmt.Errorf("load report: %w", err)
+		}
+		result.Duplicate = true
+		result.Hidden = status == models.ModerationPending
+		return result, tx.Commit()
+	}
+	if err != nil {
+		return result, fmt.Errorf("insert report: %w", err)
+	}
+
+	var open int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM reports WHERE target_type = $1 AND target_id = $2 AND status = 'open'
+	`, req.TargetType, req.TargetID).Scan(&open)
+	if err != nil {
+		return result, fmt.Errorf("count reports: %w", err)
+	}
+
+	if status == models.ModerationApproved && open >= threshold {
+		if _, err := tx.ExecContext(ctx, reportHide[req.TargetType], req.TargetID); err != nil {
+			return result, fmt.Errorf("hide target: %w", err)
+		}
+		status = models.ModerationPending
+	}
+	result.Hidden = status == models.ModerationPending
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("commit: %w", err)
+	}
+	return result, nil
+}
+
+// resolveReports закрывает открытые жалобы на объект по решению модератора
+// и уведомляет каждого пожаловавшегося о результате
+func resolveReports(ctx context.Context, tx *sql.Tx, targetType models.ReportTargetType, targetID, moderatorID string, decision models.ModerationStatus, at time.Time) error {
+	outcome := models.ReportDismissed
+	if decision == models.ModerationRejected {
+		outcome = models.ReportUpheld
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE reports
+		SET status = $3, resolved_by = $4, resolved_at = $5
+		WHERE target_type = $1 AND target_id = $2 AND status = 'open'
+		RETURNING id, reporter_id::text
+	`, targetType, targetID, outcome, moderatorID, at)
+	if err != nil {
+		return fmt.Errorf("resolve reports: %w", err)
+	}
+
+	type resolved struct {
+		id         int64
+		reporterID string
+	}
+	var reports []resolved
+	for rows.Next() {
+		var rep resolved
+		if err := rows.Scan(&rep.id, &rep.reporterID); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan error: %w", err)
+		}
+		reports = append(reports, rep)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+
+	for _, rep := range reports {
+		payload := map[string]any{
+			"report_id":   rep.id,
+			"target_type": targetType,
+			"target_id":   targetID,
+			"outcome":     outcome,
+		}
+		if err := notify(ctx, tx, rep.reporterID, models.NotificationReportResolved, moderatorID, payload, at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
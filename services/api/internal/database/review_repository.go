@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/srs"
+)
+
+type ReviewRepository struct {
+	db *sql.DB
+}
+
+func NewReviewRepository(db *sql.DB) *ReviewRepository {
+	return &ReviewRepository{db: db}
+}
+
+// DueReviews возвращает видео, тесты к которым пора повторить, начиная с самых просроченных
+func (r *ReviewRepository) DueReviews(ctx context.Context, userID string, today time.Time, limit int) ([]models.ReviewItem, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			v.id, v.title, v.description, v.video_url, v.thumbnail_url,
+			v.duration_sec, v.tags, v.created_at,
+			a.id, a.full_name, a.expertise_area, a.trust_tier, a.is_verified,
+			rs.due_date, rs.repetitions, rs.interval_days
+		FROM review_schedule rs
+		JOIN videos v ON v.id = rs.video_id
+		JOIN authors a ON a.id = v.author_id
+		WHERE rs.user_id = $1
+		  AND rs.due_date <= $2
+		  AND v.moderation_status = 'approved'
+		ORDER BY rs.due_date, rs.ease_factor
+		LIMIT $3
+	`, userID, today, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.ReviewItem{}
+	for rows.Next() {
+		var item models.ReviewItem
+		var tagsRaw []byte
+		var thumbnailURL sql.NullString
+
+		err := rows.Scan(
+			&item.ID, &item.Title, &item.Description, &item.VideoURL, &thumbnailURL,
+			&item.DurationSec, &tagsRaw, &item.CreatedAt,
+			&item.Author.ID, &item.Author.FullName, &item.Author.ExpertiseArea,
+			&item.Author.TrustTier, &item.Author.IsVerified,
+			&item.DueDate, &item.Repetitions, &item.IntervalDays,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+
+		if thumbnailURL.Valid {
+			item.ThumbnailURL = thumbnailURL.String
+		}
+		item.Tags = parsePostgresArray(string(tagsRaw))
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return items, nil
+}
+
+// GetState возвращает состояние повторения теста к видео
+func (r *ReviewRepository) GetState(ctx context.Context, userID, videoID string) (srs.State, error) {
+	var s srs.State
+	err := r.db.QueryRowContext(ctx, `
+		SELECT repetitions, ease_factor, interval_days, due_date
+		FROM review_schedule
+		WHERE user_id = $1 AND video_id = $2
+	`, userID, videoID).Scan(&s.Repetitions, &s.EaseFactor, &s.IntervalDays, &s.DueDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, ErrNotFound
+	}
+	if err != nil {
+		return s, fmt.Errorf("query error: %w", err)
+	}
+	return s, nil
+}
+
+// ErrReviewNotDue - повторение еще не наступило или уже записано параллельным запросом
+var ErrReviewNotDue = errors.New("review is not due yet")
+
+// SaveReview в одной транзакции сохраняет следующее повторение и записывает ответы.
+// prev - состояние, по которому проверялись ответы: если его уже сменил другой
+// запрос, возвращается ErrReviewNotDue. При любой ошибке не сохраняется ничего,
+// и повторение можно отправить снова.
+func (r *ReviewRepository) SaveReview(ctx context.Context, userID, videoID string, prev, next srs.State, quality int, answers []models.GradedAnswer) ([]models.QuizAnswerResult, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := saveState(ctx, tx, userID, videoID, prev, next, quality); err != nil {
+		return nil, err
+	}
+
+	results := make([]models.QuizAnswerResult, 0, len(answers))
+	for _, a := range answers {
+		result, err := recordAnswer(ctx, tx, userID, a.Question, a.Answer, a.Correct)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return results, nil
+}
+
+// saveState сохраняет состояние после повторения, если оно все еще равно prev
+func saveState(ctx context.Context, tx *sql.Tx, userID, videoID string, prev, s srs.State, quality int) error {
+	res, err := tx.ExecContext(ctx, `
+		UPDATE review_schedule
+		SET repetitions = $3, ease_factor = $4, interval_days = $5, due_date = $6,
+			last_quality = $7, last_reviewed_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND video_id = $2 AND due_date = $8 AND repetitions = $9
+	`, userID, videoID, s.Repetitions, s.EaseFactor, s.IntervalDays, s.DueDate, quality, prev.DueDate, prev.Repetitions)
+	if err != nil {
+		return fmt.Errorf("update schedule: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return ErrReviewNotDue
+	}
+	return nil
+}
+
+// scheduleReview ставит тест к видео в расписание повторений, если его там еще нет
+func scheduleReview(ctx context.Context, tx *sql.Tx, userID, videoID string, today time.Time) error {
+	s := srs.New(today)
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO review_schedule (user_id, video_id, repetitions, ease_factor, interval_days, due_date)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, video_id) DO NOTHING
+	`, userID, videoID, s.Repetitions, s.EaseFactor, s.IntervalDays, s.DueDate)
+	if err != nil {
+		return fmt.Errorf("schedule review: %w", err)
+	}
+	return nil
+}
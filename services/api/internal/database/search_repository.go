@@ -0,0 +1,175 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/mindly/api/internal/models"
+)
+
+// Параметры ts_headline: совпадения оборачиваются в <mark>, из описания берутся до двух фрагментов
+const (
+	titleHeadlineOptions       = `StartSel=<mark>, StopSel=</mark>, HighlightAll=true`
+	descriptionHeadlineOptions = `StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=" … "`
+)
+
+type SearchRepository struct {
+	db *sql.DB
+}
+
+func NewSearchRepository(db *sql.DB) *SearchRepository {
+	return &SearchRepository{db: db}
+}
+
+// escapeHTML экранирует текст до подсветки: в ответе остаются только теги <mark>
+func escapeHTML(column string) string {
+	return fmt.Sprintf(`replace(replace(replace(%s, '&', '&amp;'), '<', '&lt;'), '>', '&gt;')`, column)
+}
+
+// headline подсвечивает совпадения в тексте той конфигурацией, запрос которой в нем нашелся:
+// русская морфология не подсвечивает слова, найденные английской, и наоборот
+func headline(text, options string) string {
+	return fmt.Sprintf(`CASE WHEN to_tsvector('russian', %[1]s) @@ sq.ru
+			THEN ts_headline('russian', %[1]s, sq.ru, '%[2]s')
+			ELSE ts_headline('english', %[1]s, sq.en, '%[2]s')
+		END`, text, options)
+}
+
+// Search ищет одобренные видео по тексту (русская и английская морфология) и тегам.
+// С текстом запроса результаты сортируются по релевантности, без него - от новых к старым.
+func (r *SearchRepository) Search(ctx context.Context, p models.SearchParams) (models.SearchPage, error) {
+	page := models.SearchPage{Query: p.Query, Tags: p.Tags, Results: []models.SearchResult{}}
+
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// Запрос разбирается обеими конфигурациями, чтобы находились словоформы на обоих языках
+	query := arg(p.Query)
+	conditions := []string{"v.moderation_status = 'approved'"}
+	orderBy := "v.created_at DESC"
+	rankExpr := "0::real"
+	titleExpr := escapeHTML("v.title")
+	descriptionExpr := escapeHTML("COALESCE(v.description, '')")
+	if p.Query != "" {
+		conditions = append(conditions, "v.search_vector @@ sq.q")
+		rankExpr = "ts_rank_cd(v.search_vector, sq.q, 32)"
+		orderBy = "rank DESC, v.created_at DESC"
+		titleExpr = headline(titleExpr, titleHeadlineOptions)
+		descriptionExpr = headline(descriptionExpr, descriptionHeadlineOptions)
+	}
+
+	for _, spellings := range p.TagSpellings {
+		conditions = append(conditions, anyTagCondition(arg(pq.Array(spellings))))
+	}
+	if p.AuthorID != "" {
+		conditions = append(conditions, "a.id = "+arg(p.AuthorID)+"::uuid")
+	}
+	if p.ExpertiseArea != "" {
+		conditions = append(conditions, "a.expertise_area = "+arg(p.ExpertiseArea))
+	}
+	if p.MinDuration > 0 {
+		conditions = append(conditions, "v.duration_sec >= "+arg(p.MinDuration))
+	}
+	if p.MaxDuration > 0 {
+		conditions = append(conditions, "v.duration_sec <= "+arg(p.MaxDuration))
+	}
+	if p.VerifiedOnly {
+		conditions = append(conditions, "a.is_verified")
+	}
+
+	sqlQuery := fmt.Sprintf(`
+		SELECT
+			v.id, v.title, v.description, v.video_url, v.thumbnail_url,
+			v.duration_sec, v.tags, v.likes_count, v.created_at,
+			a.id, a.full_name, a.expertise_area, a.trust_tier, a.is_verified, a.followers_count,
+			%s AS rank, %s, %s,
+			COUNT(*) OVER ()
+		FROM videos v
+		JOIN authors a ON a.id = v.author_id
+		CROSS JOIN (
+			SELECT ru, en, ru || en AS q
+			FROM (SELECT websearch_to_tsquery('russian', %s) AS ru, websearch_to_tsquery('english', %s) AS en) t
+		) sq
+		WHERE %s
+		ORDER BY %s
+		LIMIT %s OFFSET %s
+	`, rankExpr, titleExpr, descriptionExpr, query, query,
+		strings.Join(conditions, " AND "), orderBy, arg(p.Limit), arg(p.Offset))
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return page, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var res models.SearchResult
+		var description, thumbnailURL sql.NullString
+		var tagsRaw []byte
+
+		err := rows.Scan(
+			&res.ID, &res.Title, &description, &res.VideoURL, &thumbnailURL,
+			&res.DurationSec, &tagsRaw, &res.LikesCount, &res.CreatedAt,
+			&res.Author.ID, &res.Author.FullName, &res.Author.ExpertiseArea,
+			&res.Author.TrustTier, &res.Author.IsVerified, &res.Author.FollowersCount,
+			&res.Rank, &res.Highlight.Title, &res.Highlight.Description,
+			&page.Total,
+		)
+		if err != nil {
+			return page, fmt.Errorf("scan error: %w", err)
+		}
+
+		res.Description = description.String
+		res.ThumbnailURL = thumbnailURL.String
+		res.Tags = parsePostgresArray(string(tagsRaw))
+		page.Results = append(page.Results, res)
+	}
+
+	if err := rows.Err(); err != nil {
+		return page, fmt.Errorf("rows error: %w", err)
+	}
+
+	return page, nil
+}
+
+// SuggestTags подсказывает теги, начинающиеся с prefix, от самых популярных
+func (r *SearchRepository) SuggestTags(ctx context.Context, prefix string, limit int) ([]models.TagSuggestion, error) {
+	// Символы шаблона LIKE во введенном тексте ищутся буквально
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix)
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tag, COUNT(*)
+		FROM videos v, unnest(v.tags) AS tag
+		WHERE v.moderation_status = 'approved'
+		  AND lower(tag) LIKE lower($1) || '%'
+		GROUP BY tag
+		ORDER BY COUNT(*) DESC, tag
+		LIMIT $2
+	`, escaped, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := []models.TagSuggestion{}
+	for rows.Next() {
+		var s models.TagSuggestion
+		if err := rows.Scan(&s.Tag, &s.Videos); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		suggestions = append(suggestions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return suggestions, nil
+}
@@ -0,0 +1,253 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/session"
+)
+
+// ErrRefreshReused - предъявлен уже замененный refresh-токен; сессия отозвана
+var ErrRefreshReused = errors.New("refresh token reused")
+
+type SessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create открывает сессию с первым refresh-токеном семейства и возвращает ее ID
+func (r *SessionRepository) Create(ctx context.Context, s models.SessionInput, at time.Time) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO sessions (
+			user_id, access_token_hash, access_expires_at, device_name, platform,
+			user_agent, ip_address, expires_at, last_used_at, created_at, enrollment_only
+		) VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, $9, $10)
+		RETURNING id
+	`, s.UserID, s.AccessTokenHash, s.AccessExpiresAt, s.Device.DeviceName, s.Device.Platform,
+		s.UserAgent, s.IP, s.ExpiresAt, at, s.EnrollmentOnly).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("insert session: %w", err)
+	}
+
+	if err := insertRefreshToken(ctx, tx, id, s.RefreshTokenHash, at); err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("commit: %w", err)
+	}
+	return id, nil
+}
+
+// Authenticate находит действующую сессию по хэшу access-токена и отмечает время использования
+func (r *SessionRepository) Authenticate(ctx context.Context, tokenHash string, now time.Time) (session.Session, error) {
+	var s session.Session
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE sessions SET last_used_at = $2
+		WHERE access_token_hash = $1 AND revoked_at IS NULL
+			AND access_expires_at > $2 AND expires_at > $2
+		RETURNING id, user_id, enrollment_only
+	`, tokenHash, now).Scan(&s.ID, &s.UserID, &s.EnrollmentOnly)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, session.ErrInvalid
+	}
+	if err != nil {
+		return s, fmt.Errorf("query error: %w", err)
+	}
+	return s, nil
+}
+
+// RefreshResult - сессия, токены которой обновлены
+type RefreshResult struct {
+	SessionID string
+	UserID    string
+}
+
+// Refresh меняет refresh-токен на новую пару. Токен одноразовый: повторное предъявление
+// замененного токена значит, что его украли, и отзывает всю сессию (ErrRefreshReused)
+func (r *SessionRepository) Refresh(ctx context.Context, refreshHash, newAccessHash, newRefreshHash, ip string, accessExpiresAt, expiresAt, now time.Time) (RefreshResult, error) {
+	var res RefreshResult
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return res, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var rotated, revoked bool
+	var familyExpiresAt time.Time
+	err = tx.QueryRowContext(ctx, `
+		SELECT s.id, s.user_id::text, rt.rotated_at IS NOT NULL, s.revoked_at IS NOT NULL, s.expires_at
+		FROM refresh_tokens rt
+		JOIN sessions s ON s.id = rt.session_id
+		WHERE rt.token_hash = $1
+		FOR UPDATE OF rt, s
+	`, refreshHash).Scan(&res.SessionID, &res.UserID, &rotated, &revoked, &familyExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return res, ErrTokenInvalid
+	}
+	if err != nil {
+		return res, fmt.Errorf("load refresh token: %w", err)
+	}
+	if revoked {
+		return res, ErrTokenInvalid
+	}
+
+	if rotated {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE sessions SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`, res.SessionID, now)
+		if err != nil {
+			return res, fmt.Errorf("revoke session: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return res, fmt.Errorf("commit: %w", err)
+		}
+		return res, ErrRefreshReused
+	}
+	if !now.Before(familyExpiresAt) {
+		return res, ErrTokenExpired
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE refresh_tokens SET rotated_at = $2 WHERE token_hash = $1`, refreshHash, now)
+	if err != nil {
+		return res, fmt.Errorf("rotate refresh token: %w", err)
+	}
+	if err := insertRefreshToken(ctx, tx, res.SessionID, newRefreshHash, now); err != nil {
+		return res, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE sessions
+		SET access_token_hash = $2, access_expires_at = $3, expires_at = $4,
+			last_used_at = $5, ip_address = COALESCE(NULLIF($6, ''), ip_address)
+		WHERE id = $1
+	`, res.SessionID, newAccessHash, accessExpiresAt, expiresAt, now, ip)
+	if err != nil {
+		return res, fmt.Errorf("update session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return res, fmt.Errorf("commit: %w", err)
+	}
+	return res, nil
+}
+
+func insertRefreshToken(ctx context.Context, tx *sql.Tx, sessionID, tokenHash string, at time.Time) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (session_id, token_hash, created_at) VALUES ($1, $2, $3)
+	`, sessionID, tokenHash, at)
+	if err != nil {
+		return fmt.Errorf("insert refresh token: %w", err)
+	}
+	return nil
+}
+
+// List возвращает действующие сессии пользователя, последние использованные - первыми
+func (r *SessionRepository) List(ctx context.Context, userID, currentID string, now time.Time) ([]models.DeviceSession, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, device_name, platform, user_agent, ip_address, created_at, last_used_at, expires_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY COALESCE(last_used_at, created_at) DESC, id
+	`, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.DeviceSession{}
+	for rows.Next() {
+		var s models.DeviceSession
+		var deviceName, userAgent, ip sql.NullString
+		var lastSeen sql.NullTime
+		if err := rows.Scan(&s.ID, &deviceName, &s.Platform, &userAgent, &ip,
+			&s.CreatedAt, &lastSeen, &s.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		if deviceName.Valid {
+			s.DeviceName = &deviceName.String
+		}
+		if userAgent.Valid {
+			s.UserAgent = &userAgent.String
+		}
+		if ip.Valid {
+			s.IPAddress = &ip.String
+		}
+		if lastSeen.Valid {
+			s.LastSeenAt = &lastSeen.Time
+		}
+		s.Current = s.ID == currentID
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return sessions, nil
+}
+
+// Revoke закрывает сессию
+func (r *SessionRepository) Revoke(ctx context.Context, sessionID string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE sessions SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`, sessionID, at)
+	if err != nil {
+		return fmt.Errorf("revoke session: %w", err)
+	}
+	return nil
+}
+
+// RevokeOwn закрывает сессию пользователя на одном устройстве; ErrNotFound - чужая или уже закрыта
+func (r *SessionRepository) RevokeOwn(ctx context.Context, userID, sessionID string, at time.Time) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE sessions SET revoked_at = $3
+		WHERE id = $2 AND user_id = $1 AND revoked_at IS NULL
+	`, userID, sessionID, at)
+	if err != nil {
+		return fmt.Errorf("revoke session: %w", err)
+	}
+	return expectOneRow(res)
+}
+
+// RevokeOthers закрывает все сессии пользователя, кроме keepID
+func (r *SessionRepository) RevokeOthers(ctx context.Context, userID, keepID string, at time.Time) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	revoked, err := revokeSessions(ctx, tx, userID, keepID, at)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	return revoked, nil
+}
+
+// revokeSessions закрывает все сессии пользователя, кроме exceptID (пустая строка - все)
+func revokeSessions(ctx context.Context, tx *sql.Tx, userID, exceptID string, at time.Time) (int64, error) {
+	res, err := tx.ExecContext(ctx, `
+		UPDATE sessions SET revoked_at = $3
+		WHERE user_id = $1 AND revoked_at IS NULL AND ($2::uuid IS NULL OR id <> $2::uuid)
+	`, userID, nullString(exceptID), at)
+	if err != nil {
+		return 0, fmt.Errorf("revoke sessions: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,417 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/streak"
+)
+
+// StreakRepository - серии, заморозки и журнал серии streak_events
+type StreakRepository struct {
+	db  *sql.DB
+	cfg streak.Config
+}
+
+func NewStreakRepository(db *sql.DB) *StreakRepository {
+	return &StreakRepository{db: db, cfg: streak.DefaultConfig()}
+}
+
+// streakRow - состояние серии пользователя вместе с данными для восстановления
+type streakRow struct {
+	state        streak.State
+	score        int
+	brokenStreak int
+	brokenAt     sql.NullTime
+}
+
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// loadStreak читает серию пользователя; в транзакции строка пользователя блокируется
+func loadStreak(ctx context.Context, q rowQuerier, userID string, forUpdate bool) (streakRow, error) {
+	query := `
+		SELECT COALESCE(u.current_streak, 0), COALESCE(u.best_streak, 0), COALESCE(u.score, 0),
+			s.last_streak_date, COALESCE(s.streak_freezes, 0),
+			COALESCE(s.broken_streak, 0), s.streak_broken_at
+		FROM users u
+		LEFT JOIN user_stats s ON s.user_id = u.id
+		WHERE u.id = $1
+	`
+	if forUpdate {
+		query += ` FOR UPDATE OF u`
+	}
+
+	var row streakRow
+	var lastDay sql.NullTime
+	err := q.QueryRowContext(ctx, query, userID).Scan(
+		&row.state.Current, &row.state.Best, &row.score,
+		&lastDay, &row.state.Freezes,
+		&row.brokenStreak, &row.brokenAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return row, ErrNotFound
+	}
+	if err != nil {
+		return row, fmt.Errorf("query streak: %w", err)
+	}
+	row.state.LastDay = lastDay.Time
+	return row, nil
+}
+
+// saveStreak записывает серию в users и user_stats
+func saveStreak(ctx context.Context, tx *sql.Tx, userID string, row streakRow) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE users SET current_streak = $2, best_streak = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, userID, row.state.Current, row.state.Best)
+	if err != nil {
+		return fmt.Errorf("update user streak: %w", err)
+	}
+
+	var lastDay sql.NullTime
+	if !row.state.LastDay.IsZero() {
+		lastDay = sql.NullTime{Time: row.state.LastDay, Valid: true}
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_stats (
+			user_id, current_streak_days, max_streak_days, last_streak_date,
+			streak_freezes, broken_streak, streak_broken_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id) DO UPDATE SET
+			current_streak_days = EXCLUDED.current_streak_days,
+			max_streak_days = EXCLUDED.max_streak_days,
+			last_streak_date = EXCLUDED.last_streak_date,
+			streak_freezes = EXCLUDED.streak_freezes,
+			broken_streak = EXCLUDED.broken_streak,
+			streak_broken_at = EXCLUDED.streak_broken_at,
+			updated_at = CURRENT_TIMESTAMP
+	`, userID, row.state.Current, row.state.Best, lastDay,
+		row.state.Freezes, row.brokenStreak, row.brokenAt)
+	if err != nil {
+		return fmt.Errorf("update streak stats: %w", err)
+	}
+	return nil
+}
+
+// logStreakEvent добавляет запись в журнал серии
+func logStreakEvent(ctx context.Context, tx *sql.Tx, userID string, eventType models.StreakEventType, day time.Time, before, after, freezes, pointsSpent int) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO streak_events (
+			user_id, event_type, event_date, streak_before, streak_after, freezes_after, points_spent
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, userID, eventType, streak.Day(day), before, after, freezes, pointsSpent)
+	if err != nil {
+		return fmt.Errorf("insert streak event %s: %w", eventType, err)
+	}
+	return nil
+}
+
+// rollStreak закрывает пропущенные до now дни заморозками или прерывает серию.
+// Возвращает true, если состояние изменилось и его нужно сохранить.
+func rollStreak(ctx context.Context, tx *sql.Tx, userID string, row *streakRow, now time.Time) (bool, error) {
+	rolled, roll := streak.Roll(row.state, now)
+
+	freezes := row.state.Freezes
+	for _, day := range roll.Frozen {
+		freezes--
+		err := logStreakEvent(ctx, tx, userID, models.StreakFreezeUsed, day,
+			row.state.Current, row.state.Current, freezes, 0)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if roll.Broken {
+		err := logStreakEvent(ctx, tx, userID, models.StreakBroken, roll.BrokenOn,
+			roll.Lost, 0, rolled.Freezes, 0)
+		if err != nil {
+			return false, err
+		}
+		row.brokenStreak = roll.Lost
+		row.brokenAt = sql.NullTime{Time: now, Valid: true}
+	}
+
+	row.state = rolled
+	return roll.Broken || len(roll.Frozen) > 0, nil
+}
+
+// advanceStreak продлевает серию пользователя за день, в который выполнена цель,
+// и начисляет бонус за круглую дату серии
+func advanceStreak(ctx context.Context, tx *sql.Tx, userID string, day time.Time) (models.GoalUpdate, error) {
+	cfg := streak.DefaultConfig()
+	update := models.GoalUpdate{GoalCompleted: true}
+
+	row, err := loadStreak(ctx, tx, userID, true)
+	if err != nil {
+		return update, err
+	}
+	if _, err := rollStreak(ctx, tx, userID, &row, day); err != nil {
+		return update, err
+	}
+
+	before := row.state.Current
+	var earned bool
+	row.state, earned = streak.GoalMet(cfg, row.state, day)
+
+	update.CurrentStreak = row.state.Current
+
+	err = logStreakEvent(ctx, tx, userID, models.StreakGoalMet, day, before, row.state.Current, row.state.Freezes, 0)
+	if err != nil {
+		return update, err
+	}
+	if earned {
+		err = logStreakEvent(ctx, tx, userID, models.StreakFreezeEarned, day,
+			row.state.Current, row.state.Current, row.state.Freezes, 0)
+		if err != nil {
+			return update, err
+		}
+	}
+
+	if bonus := cfg.Bonus(row.state.Current); bonus > 0 {
+		entry := models.PointsEntry{
+			UserID:    userID,
+			Amount:    bonus,
+			Reason:    models.PointsStreakBonus,
+			Reference: streak.Day(day).Format("2006-01-02"),
+		}
+		awarded, err := appendPoints(ctx, tx, &entry)
+		if err != nil {
+			return update, err
+		}
+		if awarded {
+			update.BonusPoints = bonus
+		}
+	}
+
+	return update, saveStreak(ctx, tx, userID, row)
+}
+
+// spendPoints списывает баллы через журнал, если их хватает на балансе по журналу
+func spendPoints(ctx context.Context, tx *sql.Tx, userID string, points int, reason models.PointsReason) error {
+	balance, err := ledgerBalance(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+	if balance < points {
+		return streak.ErrNotEnoughPoints
+	}
+	_, err = appendPoints(ctx, tx, &models.PointsEntry{UserID: userID, Amount: -points, Reason: reason})
+	return err
+}
+
+// RollStreak закрывает пропущенные дни одного пользователя. Возвращает true, если серия прервалась.
+func (r *StreakRepository) RollStreak(ctx context.Context, userID string, now time.Time) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	row, err := loadStreak(ctx, tx, userID, true)
+	if err != nil {
+		return false, err
+	}
+	wasActive := row.state.Current > 0
+
+	changed, err := rollStreak(ctx, tx, userID, &row, now)
+	if err != nil || !changed {
+		return false, err
+	}
+	if err := saveStreak(ctx, tx, userID, row); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit: %w", err)
+	}
+	return wasActive && row.state.Current == 0, nil
+}
+
+// RollStreaks закрывает пропущенные дни у всех серий, не продленных вчера
+func (r *StreakRepository) RollStreaks(ctx context.Context, today time.Time) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT u.id::text
+		FROM users u
+		JOIN user_stats s ON s.user_id = u.id
+		WHERE u.current_streak > 0 AND s.last_streak_date < $1
+	`, streak.Day(today).AddDate(0, 0, -1))
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		candidates = append(candidates, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	var broken []string
+	for _, id := range candidates {
+		isBroken, err := r.RollStreak(ctx, id, today)
+		if err != nil {
+			return broken, fmt.Errorf("roll streak for user %s: %w", id, err)
+		}
+		if isBroken {
+			broken = append(broken, id)
+		}
+	}
+	return broken, nil
+}
+
+// Overview возвращает серию, заморозки, доступное восстановление и последние события
+func (r *StreakRepository) Overview(ctx context.Context, userID string, now time.Time) (models.StreakOverview, error) {
+	overview := models.StreakOverview{
+		MaxFreezes: r.cfg.MaxFreezes,
+		FreezeCost: r.cfg.FreezeCost,
+		History:    []models.StreakEvent{},
+	}
+
+	row, err := loadStreak(ctx, r.db, userID, false)
+	if err != nil {
+		return overview, err
+	}
+
+	overview.CurrentStreak = streak.Effective(row.state, now)
+	overview.BestStreak = row.state.Best
+	overview.Freezes = row.state.Freezes
+	overview.Points = row.score
+	if row.brokenStreak > 0 && row.brokenAt.Valid {
+		expiresAt := row.brokenAt.Time.Add(r.cfg.RepairWindow)
+		if now.Before(expiresAt) {
+			overview.Repair = &models.StreakRepair{
+				LostStreak: row.brokenStreak,
+				Cost:       r.cfg.RepairCost(row.brokenStreak),
+				ExpiresAt:  expiresAt,
+			}
+		}
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT event_type, event_date, streak_before, streak_after, freezes_after, points_spent, created_at
+		FROM streak_events
+		WHERE user_id = $1
+		ORDER BY id DESC
+		LIMIT 30
+	`, userID)
+	if err != nil {
+		return overview, fmt.Errorf("query events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.StreakEvent
+		var day time.Time
+		err := rows.Scan(&e.Type, &day, &e.StreakBefore, &e.StreakAfter, &e.FreezesAfter, &e.PointsSpent, &e.CreatedAt)
+		if err != nil {
+			return overview, fmt.Errorf("scan error: %w", err)
+		}
+		e.Date = day.Format("2006-01-02")
+		overview.History = append(overview.History, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return overview, fmt.Errorf("rows error: %w", err)
+	}
+
+	return overview, nil
+}
+
+// BuyFreeze покупает заморозку за баллы. Возвращает потраченные баллы и то,
+// прервалась ли серия при закрытии пропущенных дней перед покупкой.
+func (r *StreakRepository) BuyFreeze(ctx context.Context, userID string, now time.Time) (int, bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	row, err := loadStreak(ctx, tx, userID, true)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Купленная заморозка не закрывает уже пропущенные дни
+	wasActive := row.state.Current > 0
+	if _, err := rollStreak(ctx, tx, userID, &row, now); err != nil {
+		return 0, false, err
+	}
+	broken := wasActive && row.state.Current == 0
+
+	if row.state.Freezes >= r.cfg.MaxFreezes {
+		return 0, broken, streak.ErrFreezeLimit
+	}
+	if err := spendPoints(ctx, tx, userID, r.cfg.FreezeCost, models.PointsFreezePurchase); err != nil {
+		return 0, broken, err
+	}
+
+	row.state.Freezes++
+	err = logStreakEvent(ctx, tx, userID, models.StreakFreezePurchased, now,
+		row.state.Current, row.state.Current, row.state.Freezes, r.cfg.FreezeCost)
+	if err != nil {
+		return 0, broken, err
+	}
+	if err := saveStreak(ctx, tx, userID, row); err != nil {
+		return 0, broken, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, broken, fmt.Errorf("commit: %w", err)
+	}
+	return r.cfg.FreezeCost, broken, nil
+}
+
+// Repair восстанавливает прерванную серию за баллы. Возвращает новую серию и потраченные баллы.
+func (r *StreakRepository) Repair(ctx context.Context, userID string, now time.Time) (int, int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	row, err := loadStreak(ctx, tx, userID, true)
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := rollStreak(ctx, tx, userID, &row, now); err != nil {
+		return 0, 0, err
+	}
+
+	before := row.state.Current
+	repaired, err := streak.Repair(r.cfg, row.state, row.brokenStreak, row.brokenAt.Time, now)
+	if err != nil {
+		return 0, 0, err
+	}
+	cost := r.cfg.RepairCost(row.brokenStreak)
+	if err := spendPoints(ctx, tx, userID, cost, models.PointsStreakRepair); err != nil {
+		return 0, 0, err
+	}
+
+	row.state = repaired
+	row.brokenStreak = 0
+	row.brokenAt = sql.NullTime{}
+	err = logStreakEvent(ctx, tx, userID, models.StreakRepaired, now, before, row.state.Current, row.state.Freezes, cost)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := saveStreak(ctx, tx, userID, row); err != nil {
+		return 0, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("commit: %w", err)
+	}
+	return row.state.Current, cost, nil
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/taxonomy"
+)
+
+// TopicRepository - разделы навигации: области экспертизы и таксономия тегов
+type TopicRepository struct {
+	db *sql.DB
+}
+
+func NewTopicRepository(db *sql.DB) *TopicRepository {
+	return &TopicRepository{db: db}
+}
+
+// ExpertiseAreas возвращает области экспертизы с числом одобренных видео и авторов
+func (r *TopicRepository) ExpertiseAreas(ctx context.Context) ([]models.Topic, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT a.expertise_area, COUNT(v.id), COUNT(DISTINCT a.id)
+		FROM authors a
+		JOIN videos v ON v.author_id = a.id AND v.moderation_status = 'approved'
+		WHERE COALESCE(a.expertise_area, '') <> ''
+		GROUP BY a.expertise_area
+		ORDER BY COUNT(v.id) DESC, a.expertise_area
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	topics := []models.Topic{}
+	for rows.Next() {
+		var t models.Topic
+		if err := rows.Scan(&t.Name, &t.Videos, &t.Authors); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		topics = append(topics, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return topics, nil
+}
+
+// Taxonomy загружает канонические теги и синонимы
+func (r *TopicRepository) Taxonomy(ctx context.Context) (*taxonomy.Taxonomy, error) {
+	names, err := r.pairs(ctx, `SELECT slug, name FROM tags`)
+	if err != nil {
+		return nil, fmt.Errorf("load tags: %w", err)
+	}
+	aliases, err := r.pairs(ctx, `SELECT alias, tag_slug FROM tag_aliases`)
+	if err != nil {
+		return nil, fmt.Errorf("load tag aliases: %w", err)
+	}
+	return taxonomy.New(names, aliases), nil
+}
+
+// normalizedTagSQL - taxonomy.Normalize для элемента tag массива тегов видео
+const normalizedTagSQL = `regexp_replace(replace(lower(btrim(tag)), 'ё', 'е'), '\s+', ' ', 'g')`
+
+// anyTagCondition - условие "у видео v есть тег в одном из нормализованных написаний"
+// из параметра запроса param (text[])
+func anyTagCondition(param string) string {
+	return `EXISTS (SELECT 1 FROM unnest(v.tags) AS tag WHERE ` + normalizedTagSQL + ` = ANY(` + param + `::text[]))`
+}
+
+// TagUsage возвращает для каждого написания тега ID одобренных видео с ним
+func (r *TopicRepository) TagUsage(ctx context.Context) (map[string][]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tag, v.id::text
+		FROM videos v, unnest(v.tags) AS tag
+		WHERE v.moderation_status = 'approved'
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	usage := map[string][]string{}
+	for rows.Next() {
+		var tag, videoID string
+		if err := rows.Scan(&tag, &videoID); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		usage[tag] = append(usage[tag], videoID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return usage, nil
+}
+
+func (r *TopicRepository) pairs(ctx context.Context, query string) (map[string]string, error) {
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	result := map[string]string{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		result[key] = value
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,321 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/mindly/api/internal/models"
+)
+
+// ErrTwoFactorEnabled - 2FA уже включена, повторная выдача секрета запрещена
+var ErrTwoFactorEnabled = errors.New("two-factor authentication already enabled")
+
+// TwoFactorRepository - TOTP-секреты, резервные коды, второй шаг входа и требования по ролям
+type TwoFactorRepository struct {
+	db *sql.DB
+}
+
+func NewTwoFactorRepository(db *sql.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db}
+}
+
+// Enroll сохраняет новый секрет, пока 2FA не подтверждена; повторный вызов заменяет секрет
+func (r *TwoFactorRepository) Enroll(ctx context.Context, userID, secret string, at time.Time) error {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_totp (user_id, secret, created_at)
+		SELECT id, $2, $3 FROM users WHERE id = $1
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = 0, created_at = EXCLUDED.created_at
+		WHERE user_totp.enabled_at IS NULL
+	`, userID, secret, at)
+	if err != nil {
+		return fmt.Errorf("save secret: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		// Либо 2FA уже включена, либо пользователя нет
+		enabled, err := r.Enabled(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if enabled {
+			return ErrTwoFactorEnabled
+		}
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Secret возвращает секрет, последний принятый интервал и включена ли 2FA.
+// ErrNotFound - секрет не выдавался
+func (r *TwoFactorRepository) Secret(ctx context.Context, userID string) (secret string, lastStep int64, enabled bool, err error) {
+	err = r.db.QueryRowContext(ctx, `
+		SELECT secret, last_used_step, enabled_at IS NOT NULL FROM user_totp WHERE user_id = $1
+	`, userID).Scan(&secret, &lastStep, &enabled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", 0, false, ErrNotFound
+	}
+	if err != nil {
+		return "", 0, false, fmt.Errorf("query error: %w", err)
+	}
+	return secret, lastStep, enabled, nil
+}
+
+// Enabled - включена ли у пользователя 2FA
+func (r *TwoFactorRepository) Enabled(ctx context.Context, userID string) (bool, error) {
+	var enabled bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM user_totp WHERE user_id = $1 AND enabled_at IS NOT NULL)
+	`, userID).Scan(&enabled)
+	if err != nil {
+		return false, fmt.Errorf("query error: %w", err)
+	}
+	return enabled, nil
+}
+
+// Enable включает 2FA после первого верного кода, заменяет резервные коды
+// и закрывает остальные сессии: они были открыты без второго фактора.
+// Текущая сессия, открытая только для включения 2FA, становится полной
+func (r *TwoFactorRepository) Enable(ctx context.Context, userID string, step int64, codeHashes []string, keepSessionID string, at time.Time) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE user_totp SET enabled_at = $3, last_used_step = $2
+		WHERE user_id = $1 AND enabled_at IS NULL AND last_used_step < $2
+	`, userID, step, at)
+	if err != nil {
+		return 0, fmt.Errorf("enable: %w", err)
+	}
+	if err := expectOneRow(res); err != nil {
+		return 0, err
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes, at); err != nil {
+		return 0, err
+	}
+
+	revoked, err := revokeSessions(ctx, tx, userID, keepSessionID, at)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE sessions SET enrollment_only = FALSE WHERE id = $1::uuid AND user_id = $2 AND enrollment_only
+	`, nullString(keepSessionID), userID)
+	if err != nil {
+		return 0, fmt.Errorf("complete enrollment session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	return revoked, nil
+}
+
+// Disable выключает 2FA и удаляет резервные коды
+func (r *TwoFactorRepository) Disable(ctx context.Context, userID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM totp_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("delete recovery codes: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("delete secret: %w", err)
+	}
+	if err := expectOneRow(res); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// UseStep отмечает интервал принятого кода. false - код этого или более позднего
+// интервала уже использован (повтор)
+func (r *TwoFactorRepository) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE user_totp SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2
+	`, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("use step: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// UseRecoveryCode гасит резервный код. false - кода нет или он уже использован
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userID, codeHash string, at time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE totp_recovery_codes SET used_at = $3
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, codeHash, at)
+	if err != nil {
+		return false, fmt.Errorf("use recovery code: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// RegenerateRecoveryCodes заменяет резервные коды новыми, старые перестают действовать
+func (r *TwoFactorRepository) RegenerateRecoveryCodes(ctx context.Context, userID string, codeHashes []string, at time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes, at); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID string, codeHashes []string, at time.Time) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM totp_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("delete recovery codes: %w", err)
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO totp_recovery_codes (user_id, code_hash, created_at)
+		SELECT $1, h, $3 FROM unnest($2::text[]) AS h
+	`, userID, pq.Array(codeHashes), at)
+	if err != nil {
+		return fmt.Errorf("insert recovery codes: %w", err)
+	}
+	return nil
+}
+
+// CreateChallenge начинает вход, ожидающий второй фактор
+func (r *TwoFactorRepository) CreateChallenge(ctx context.Context, userID, tokenHash string, expiresAt, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO login_challenges (user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, userID, tokenHash, expiresAt, at)
+	if err != nil {
+		return fmt.Errorf("insert challenge: %w", err)
+	}
+	return nil
+}
+
+// ChallengeAttempt засчитывает попытку ввода кода и возвращает пользователя.
+// После maxAttempts попыток вход нужно начинать заново
+func (r *TwoFactorRepository) ChallengeAttempt(ctx context.Context, tokenHash string, maxAttempts int, now time.Time) (string, error) {
+	var userID string
+	var expiresAt time.Time
+	var attempts int
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE login_challenges SET attempts = attempts + 1
+		WHERE token_hash = $1 AND used_at IS NULL
+		RETURNING user_id::text, expires_at, attempts
+	`, tokenHash).Scan(&userID, &expiresAt, &attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrTokenInvalid
+	}
+	if err != nil {
+		return "", fmt.Errorf("query error: %w", err)
+	}
+	if !now.Before(expiresAt) {
+		return "", ErrTokenExpired
+	}
+	if attempts > maxAttempts {
+		return "", ErrTokenInvalid
+	}
+	return userID, nil
+}
+
+// CompleteChallenge гасит вход после верного кода; повторно им войти нельзя
+func (r *TwoFactorRepository) CompleteChallenge(ctx context.Context, tokenHash string, at time.Time) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE login_challenges SET used_at = $2 WHERE token_hash = $1 AND used_at IS NULL
+	`, tokenHash, at)
+	if err != nil {
+		return fmt.Errorf("complete challenge: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return ErrTokenInvalid
+	}
+	return nil
+}
+
+// Requirements - для каких ролей 2FA обязательна
+func (r *TwoFactorRepository) Requirements(ctx context.Context) ([]models.TwoFactorRequirement, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT role, required, updated_at FROM two_factor_requirements ORDER BY role
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	reqs := []models.TwoFactorRequirement{}
+	for rows.Next() {
+		var req models.TwoFactorRequirement
+		if err := rows.Scan(&req.Role, &req.Required, &req.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		reqs = append(reqs, req)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return reqs, nil
+}
+
+// SetRequired включает или снимает обязательную 2FA для роли
+func (r *TwoFactorRepository) SetRequired(ctx context.Context, role models.Role, required bool, updatedBy string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO two_factor_requirements (role, required, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (role) DO UPDATE
+		SET required = EXCLUDED.required, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at
+	`, role, required, updatedBy, at)
+	if err != nil {
+		return fmt.Errorf("save requirement: %w", err)
+	}
+	return nil
+}
+
+// Required - обязательна ли 2FA для роли
+func (r *TwoFactorRepository) Required(ctx context.Context, role models.Role) (bool, error) {
+	var required bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM two_factor_requirements WHERE role = $1 AND required)
+	`, role).Scan(&required)
+	if err != nil {
+		return false, fmt.Errorf("query error: %w", err)
+	}
+	return required, nil
+}
+
+// RequirementUnmet - роль пользователя требует 2FA, а он ее не включил
+func (r *TwoFactorRepository) RequirementUnmet(ctx context.Context, userID string, role models.Role) (bool, error) {
+	var unmet bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM two_factor_requirements WHERE role = $2 AND required)
+			AND NOT EXISTS(SELECT 1 FROM user_totp WHERE user_id = $1 AND enabled_at IS NOT NULL)
+	`, userID, role).Scan(&unmet)
+	if err != nil {
+		return false, fmt.Errorf("query error: %w", err)
+	}
+	return unmet, nil
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/mindly/api/internal/models"
+)
+
+// ErrEmailTaken - email уже занят другой учетной записью (без учета регистра)
+var ErrEmailTaken = errors.New("email is taken")
+
+// Уникальные индексы users (init.sql) и поле, которое они защищают
+var userUniqueIndexes = map[string]error{
+	"idx_users_email_lower":       ErrEmailTaken,
+	"idx_users_username_lower":    ErrUsernameTaken,
+	"idx_users_username_skeleton": ErrUsernameTaken,
+}
+
+// UserConflict переводит нарушение уникальности email или username (23505) в
+// ErrEmailTaken или ErrUsernameTaken; остальные ошибки возвращаются как есть.
+// Проверка заранее не защищает от гонки двух запросов, поэтому ошибку вставки
+// или обновления users нужно пропускать через UserConflict
+func UserConflict(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+		return err
+	}
+	if taken, ok := userUniqueIndexes[pqErr.Constraint]; ok {
+		return taken
+	}
+	return err
+}
+
+type UserRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// Role возвращает роль пользователя
+func (r *UserRepository) Role(ctx context.Context, userID string) (models.Role, error) {
+	var role models.Role
+	err := r.db.QueryRowContext(ctx, `SELECT role FROM users WHERE id = $1`, userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("query error: %w", err)
+	}
+	return role, nil
+}
+
+// EmailVerified - подтвердил ли пользователь email
+func (r *UserRepository) EmailVerified(ctx context.Context, userID string) (bool, error) {
+	var verified bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT email_verified_at IS NOT NULL FROM users WHERE id = $1`, userID,
+	).Scan(&verified)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, ErrNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("query error: %w", err)
+	}
+	return verified, nil
+}
+
+// Колонки пользователя в порядке scanUser
+const userColumns = `
+	id, email, username, password_hash, full_name,
+	COALESCE(score, 0), COALESCE(current_streak, 0), COALESCE(best_streak, 0),
+	role, email_verified_at, avatar_url, username_changed_at, deletion_scheduled_at, created_at, updated_at`
+
+// GetByID возвращает пользователя
+func (r *UserRepository) GetByID(ctx context.Context, userID string) (models.User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, userID))
+}
+
+// FindByLogin ищет пользователя по email или имени пользователя без учета регистра.
+// login должен быть приведен к NFKC (profile.NormalizeUsername)
+func (r *UserRepository) FindByLogin(ctx context.Context, login string) (models.User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, `
+		SELECT `+userColumns+` FROM users
+		WHERE LOWER(email) = LOWER($1) OR LOWER(username) = LOWER($1)
+		ORDER BY LOWER(email) = LOWER($1) DESC
+		LIMIT 1
+	`, login))
+}
+
+// Taken проверяет, свободны ли email и username для новой учетной записи: username
+// не должен совпадать с чужим без учета регистра, по скелету или с закрепленным
+// за прежним владельцем. Возвращает ErrEmailTaken, ErrUsernameTaken или nil
+func (r *UserRepository) Taken(ctx context.Context, email, username, skeleton string, now time.Time) error {
+	var emailTaken, usernameTaken bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			EXISTS(SELECT 1 FROM users WHERE LOWER(email) = LOWER($1)),
+			EXISTS(SELECT 1 FROM users WHERE LOWER(username) = LOWER($2) OR username_skeleton = $3)
+				OR EXISTS(SELECT 1 FROM username_reservations
+				          WHERE LOWER(username) = LOWER($2) AND reserved_until > $4)
+	`, email, username, skeleton, now).Scan(&emailTaken, &usernameTaken)
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+	switch {
+	case emailTaken:
+		return ErrEmailTaken
+	case usernameTaken:
+		return ErrUsernameTaken
+	}
+	return nil
+}
+
+// UpgradePasswordHash заменяет хэш пароля пересчитанным после входа. Хэш меняется,
+// только если его не успели сменить с момента проверки (смена или сброс пароля)
+func (r *UserRepository) UpgradePasswordHash(ctx context.Context, userID, oldHash, newHash string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE users SET password_hash = $3 WHERE id = $1 AND password_hash = $2`,
+		userID, oldHash, newHash)
+	if err != nil {
+		return fmt.Errorf("update password hash: %w", err)
+	}
+	return nil
+}
+
+func scanUser(row rowScanner) (models.User, error) {
+	var u models.User
+	var fullName sql.NullString
+	var verifiedAt, usernameChangedAt, deletionScheduledAt sql.NullTime
+	var avatarURL sql.NullString
+	err := row.Scan(
+		&u.ID, &u.Email, &u.Username, &u.PasswordHash, &fullName,
+		&u.Score, &u.CurrentStreak, &u.BestStreak,
+		&u.Role, &verifiedAt, &avatarURL, &usernameChangedAt, &deletionScheduledAt, &u.CreatedAt, &u.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return u, ErrNotFound
+	}
+	if err != nil {
+		return u, fmt.Errorf("query error: %w", err)
+	}
+	if fullName.Valid {
+		u.FullName = &fullName.String
+	}
+	if verifiedAt.Valid {
+		u.EmailVerifiedAt = &verifiedAt.Time
+	}
+	if avatarURL.Valid {
+		u.AvatarURL = &avatarURL.String
+	}
+	if usernameChangedAt.Valid {
+		u.UsernameChangedAt = &usernameChangedAt.Time
+	}
+	if deletionScheduledAt.Valid {
+		u.DeletionScheduledAt = &deletionScheduledAt.Time
+	}
+	return u, nil
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mindly/api/internal/verification"
+)
+
+var (
+	// ErrTokenInvalid - токена нет, он уже использован или выдан для другого адреса
+	ErrTokenInvalid = errors.New("invalid token")
+	// ErrTokenExpired - срок действия токена истек
+	ErrTokenExpired = errors.New("token expired")
+)
+
+type VerificationRepository struct {
+	db *sql.DB
+}
+
+func NewVerificationRepository(db *sql.DB) *VerificationRepository {
+	return &VerificationRepository{db: db}
+}
+
+// Recipient возвращает адрес и имя пользователя и историю отправок за сутки
+func (r *VerificationRepository) Recipient(ctx context.Context, userID string, now time.Time) (email, username string, state verification.SendState, err error) {
+	var verifiedAt, lastSent sql.NullTime
+	err = r.db.QueryRowContext(ctx, `
+		SELECT u.email, u.username, u.email_verified_at,
+			(SELECT MAX(created_at) FROM email_verification_tokens WHERE user_id = u.id),
+			(SELECT COUNT(*) FROM email_verification_tokens
+			 WHERE user_id = u.id AND created_at > $2::timestamp - INTERVAL '24 hours')
+		FROM users u
+		WHERE u.id = $1
+	`, userID, now).Scan(&email, &username, &verifiedAt, &lastSent, &state.SentToday)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", state, ErrNotFound
+	}
+	if err != nil {
+		return "", "", state, fmt.Errorf("query error: %w", err)
+	}
+
+	state.Verified = verifiedAt.Valid
+	if lastSent.Valid {
+		state.LastSentAt = &lastSent.Time
+	}
+	return email, username, state, nil
+}
+
+// CreateToken сохраняет хэш нового токена для адреса пользователя
+func (r *VerificationRepository) CreateToken(ctx context.Context, userID, email, tokenHash string, expiresAt, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO email_verification_tokens (user_id, token_hash, email, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, userID, tokenHash, email, expiresAt, at)
+	if err != nil {
+		return fmt.Errorf("insert token: %w", err)
+	}
+	return nil
+}
+
+// Verify подтверждает email по токену и гасит остальные ссылки пользователя
+func (r *VerificationRepository) Verify(ctx context.Context, tokenHash string, at time.Time) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID, tokenEmail, userEmail string
+	var expiresAt time.Time
+	var used bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT t.user_id::text, t.email, u.email, t.expires_at, t.used_at IS NOT NULL
+		FROM email_verification_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1
+		FOR UPDATE OF t, u
+	`, tokenHash).Scan(&userID, &tokenEmail, &userEmail, &expiresAt, &used)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrTokenInvalid
+	}
+	if err != nil {
+		return "", fmt.Errorf("load token: %w", err)
+	}
+	if used || tokenEmail != userEmail {
+		return "", ErrTokenInvalid
+	}
+	if !at.Before(expiresAt) {
+		return "", ErrTokenExpired
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, $2), updated_at = $2 WHERE id = $1
+	`, userID, at)
+	if err != nil {
+		return "", fmt.Errorf("verify email: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE email_verification_tokens SET used_at = $2 WHERE user_id = $1 AND used_at IS NULL
+	`, userID, at)
+	if err != nil {
+		return "", fmt.Errorf("use tokens: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("commit: %w", err)
+	}
+	return userID, nil
+}
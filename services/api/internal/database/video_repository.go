@@ -5,10 +5,18 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/lib/pq"
 
 	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/pagination"
+	"github.com/mindly/api/internal/ranking"
 )
 
+// Во сколько раз больше видео выбирается из БД для переранжирования ленты
+const feedCandidateFactor = 3
+
 type VideoRepository struct {
 	db *sql.DB
 }
@@ -18,25 +26,150 @@ func NewVideoRepository(db *sql.DB) *VideoRepository {
 }
 
 func (r *VideoRepository) GetFeed(ctx context.Context, userID string, limit int) ([]models.VideoWithAuthor, error) {
+    return r.GetFilteredFeed(ctx, models.FeedFilter{ViewerID: userID}, limit)
+}
+
+// GetFilteredFeed - лента раздела (область экспертизы или тег) с тем же отбором и ранжированием, что и основная
+func (r *VideoRepository) GetFilteredFeed(ctx context.Context, filter models.FeedFilter, limit int) ([]models.VideoWithAuthor, error) {
     query := `
         SELECT 
             v.id, v.title, v.description, v.video_url, v.thumbnail_url,
-            v.duration_sec, v.tags, v.created_at,
-            a.id, a.full_name, a.expertise_area, a.trust_tier, a.is_verified
+            v.duration_sec, v.tags, v.likes_count, v.created_at,
+            a.id, a.full_name, a.expertise_area, a.trust_tier, a.is_verified, a.followers_count
         FROM videos v
         JOIN authors a ON v.author_id = a.id
         WHERE v.moderation_status = 'approved'
+          AND ($2 = '' OR a.expertise_area = $2)
+          AND ($3::text[] IS NULL OR ` + anyTagCondition("$3") + `)
         ORDER BY v.created_at DESC
         LIMIT $1
     `
     
-    rows, err := r.db.QueryContext(ctx, query, limit)
+    // Берем свежих кандидатов с запасом и переранжируем их с учетом признаков
+    rows, err := r.db.QueryContext(ctx, query, limit*feedCandidateFactor, filter.ExpertiseArea, pq.Array(filter.AnyTags))
     if err != nil {
         return nil, fmt.Errorf("query error: %w", err)
     }
     defer rows.Close()
     
-    var videos []models.VideoWithAuthor
+    videos, err := scanFeedVideos(rows)
+    if err != nil {
+        return nil, err
+    }
+    
+    // Лайки зрителя поднимают видео авторов, которые ему нравятся
+    affinity, err := r.authorAffinity(ctx, filter.ViewerID)
+    if err != nil {
+        return nil, err
+    }
+    
+    ranking.Sort(ranking.DefaultWeights(), videos, func(v models.VideoWithAuthor) ranking.Features {
+        return ranking.Features{
+            CreatedAt:      v.CreatedAt,
+            TrustTier:      v.Author.TrustTier,
+            Likes:          v.LikesCount,
+            AuthorAffinity: affinity[v.Author.ID],
+        }
+    }, time.Now())
+    
+    if len(videos) > limit {
+        videos = videos[:limit]
+    }
+    
+    return videos, nil
+}
+
+// authorAffinity возвращает для каждого автора долю лайков зрителя, отданных его видео
+func (r *VideoRepository) authorAffinity(ctx context.Context, viewerID string) (map[string]float64, error) {
+    affinity := map[string]float64{}
+    if viewerID == "" {
+        return affinity, nil
+    }
+    
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT v.author_id::text, COUNT(*)
+        FROM video_likes l
+        JOIN videos v ON v.id = l.video_id
+        WHERE l.user_id = $1
+        GROUP BY v.author_id
+    `, viewerID)
+    if err != nil {
+        return nil, fmt.Errorf("query error: %w", err)
+    }
+    defer rows.Close()
+    
+    total := 0
+    counts := map[string]int{}
+    for rows.Next() {
+        var authorID string
+        var likes int
+        if err := rows.Scan(&authorID, &likes); err != nil {
+            return nil, fmt.Errorf("scan error: %w", err)
+        }
+        counts[authorID] = likes
+        total += likes
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("rows error: %w", err)
+    }
+    
+    for authorID, likes := range counts {
+        affinity[authorID] = float64(likes) / float64(total)
+    }
+    return affinity, nil
+}
+
+// GetFollowingFeed - одобренные видео авторов, на которых подписан пользователь, от новых к старым.
+// Страницы листаются курсором (created_at, id) последнего видео, без переранжирования
+func (r *VideoRepository) GetFollowingFeed(ctx context.Context, userID string, cursor *pagination.Cursor, limit int) (models.FollowingFeedPage, error) {
+    page := models.FollowingFeedPage{Videos: []models.VideoWithAuthor{}}
+    
+    var cursorAt, cursorID sql.NullString
+    if cursor != nil {
+        cursorAt = sql.NullString{String: cursor.Timestamp(), Valid: true}
+        cursorID = sql.NullString{String: cursor.ID, Valid: true}
+    }
+    
+    query := `
+        SELECT 
+            v.id, v.title, v.description, v.video_url, v.thumbnail_url,
+            v.duration_sec, v.tags, v.likes_count, v.created_at,
+            a.id, a.full_name, a.expertise_area, a.trust_tier, a.is_verified, a.followers_count
+        FROM author_follows f
+        JOIN videos v ON v.author_id = f.author_id
+        JOIN authors a ON a.id = f.author_id
+        WHERE f.user_id = $1
+          AND v.moderation_status = 'approved'
+          AND ($2::timestamp IS NULL OR (v.created_at, v.id) < ($2::timestamp, $3::uuid))
+        ORDER BY v.created_at DESC, v.id DESC
+        LIMIT $4
+    `
+    
+    // Одно лишнее видео показывает, есть ли следующая страница
+    rows, err := r.db.QueryContext(ctx, query, userID, cursorAt, cursorID, limit+1)
+    if err != nil {
+        return page, fmt.Errorf("query error: %w", err)
+    }
+    defer rows.Close()
+    
+    videos, err := scanFeedVideos(rows)
+    if err != nil {
+        return page, err
+    }
+    
+    if len(videos) > limit {
+        videos = videos[:limit]
+        last := videos[len(videos)-1]
+        page.NextCursor = pagination.Cursor{At: last.CreatedAt, ID: last.ID}.Encode()
+    }
+    page.Videos = videos
+    
+    return page, nil
+}
+
+// scanFeedVideos читает строки ленты: поля видео и автора в порядке запросов ленты
+func scanFeedVideos(rows *sql.Rows) ([]models.VideoWithAuthor, error) {
+    videos := []models.VideoWithAuthor{}
     
     for rows.Next() {
         var v models.VideoWithAuthor
@@ -46,9 +179,9 @@ func (r *VideoRepository) GetFeed(ctx context.Context, userID string, limit int)
         
         err := rows.Scan(
             &v.ID, &v.Title, &v.Description, &v.VideoURL, &thumbnailURL,
-            &v.DurationSec, &tagsRaw, &v.CreatedAt,
+            &v.DurationSec, &tagsRaw, &v.LikesCount, &v.CreatedAt,
             &v.Author.ID, &v.Author.FullName, &v.Author.ExpertiseArea,
-            &v.Author.TrustTier, &v.Author.IsVerified,
+            &v.Author.TrustTier, &v.Author.IsVerified, &v.Author.FollowersCount,
         )
         if err != nil {
             return nil, fmt.Errorf("scan error: %w", err)
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mindly/api/internal/account"
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/password"
+	"github.com/mindly/api/internal/session"
+	"github.com/mindly/api/internal/storage"
+)
+
+// maxExportsListed - сколько последних выгрузок показывать
+const maxExportsListed = 20
+
+// AccountHandler - выгрузка персональных данных и удаление учетной записи.
+// Все действия только с сессией
+type AccountHandler struct {
+	accountRepo *database.AccountRepository
+	userRepo    *database.UserRepository
+	hasher      *password.Hasher
+	files       storage.Storage
+	job         *account.Job
+	cfg         account.Config
+}
+
+func NewAccountHandler(db *sql.DB, files storage.Storage, hasher *password.Hasher, job *account.Job, cfg account.Config) *AccountHandler {
+	return &AccountHandler{
+		accountRepo: database.NewAccountRepository(db),
+		userRepo:    database.NewUserRepository(db),
+		hasher:      hasher,
+		files:       files,
+		job:         job,
+		cfg:         cfg,
+	}
+}
+
+// RequestExport ставит в очередь выгрузку данных; архив собирается в фоне,
+// его состояние - в GET /api/me/exports
+func (h *AccountHandler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	s, ok := session.FromContext(r.Context())
+	if !ok {
+		sendJSONError(w, "Not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	e, created, err := h.accountRepo.RequestExport(r.Context(), s.UserID, h.cfg.ExportInterval, time.Now())
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, database.ErrExportTooFrequent) {
+		next := e.CreatedAt.Add(h.cfg.ExportInterval)
+		sendJSONErrorCode(w, "A new data export can be requested after "+next.UTC().Format(time.RFC3339),
+			"export_too_frequent", http.StatusTooManyRequests)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to request export for user %s: %v", s.UserID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if !created {
+		sendJSONSuccess(w, "Data export is already in progress", e, http.StatusAccepted)
+		return
+	}
+	h.job.Trigger()
+
+	log.Printf("📦 Запрошена выгрузка данных: user=%s, export=%s", s.UserID, e.ID)
+	sendJSONSuccess(w, "Data export requested", e, http.StatusAccepted)
+}
+
+// ListExports возвращает последние выгрузки; у готовых есть download_url
+func (h *AccountHandler) ListExports(w http.ResponseWriter, r *http.Request) {
+	s, ok := session.FromContext(r.Context())
+	if !ok {
+		sendJSONError(w, "Not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	exports, err := h.accountRepo.ListExports(r.Context(), s.UserID, maxExportsListed)
+	if err != nil {
+		log.Printf("❌ Failed to list exports for user %s: %v", s.UserID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	for i := range exports {
+		if exports[i].Status == models.ExportReady {
+			exports[i].DownloadURL = exportDownloadURL(exports[i].ID)
+		}
+	}
+
+	sendJSONSuccess(w, "Data exports", exports, http.StatusOK)
+}
+
+// DownloadExport отдает готовый архив выгрузки
+func (h *AccountHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	s, ok := session.FromContext(ctx)
+	if !ok {
+		sendJSONError(w, "Not logged in", http.StatusUnauthorized)
+		return
+	}
+	exportID := r.PathValue("id")
+	if !isUUID(exportID) {
+		sendJSONError(w, "Invalid export id", http.StatusBadRequest)
+		return
+	}
+
+	e, err := h.accountRepo.GetExport(ctx, s.UserID, exportID)
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "Export not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load export %s: %v", exportID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	switch e.Status {
+	case models.ExportReady:
+	case models.ExportExpired:
+		sendJSONError(w, "Export has expired, request a new one", http.StatusGone)
+		return
+	case models.ExportFailed:
+		sendJSONError(w, "Export failed, request a new one", http.StatusConflict)
+		return
+	default:
+		sendJSONError(w, "Export is not ready yet", http.StatusConflict)
+		return
+	}
+
+	f, err := h.files.Open(ctx, e.FileKey)
+	if errors.Is(err, storage.ErrNotExist) {
+		sendJSONError(w, "Export has expired, request a new one", http.StatusGone)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to open export %s: %v", exportID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="mindly-export-%s.zip"`, e.CreatedAt.Format("2006-01-02")))
+	w.Header().Set("Cache-Control", "no-store")
+	if e.SizeBytes != nil {
+		w.Header().Set("Content-Length", fmt.Sprint(*e.SizeBytes))
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		log.Printf("⚠️ Failed to send export %s: %v", exportID, err)
+	}
+}
+
+// Delete запрашивает удаление учетной записи: {"password": "..."} (без пароля -
+// только у учетных записей, созданных через внешний вход). До конца срока на отмену
+// можно войти и отменить удаление, остальные сессии закрываются сразу
+func (h *AccountHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	s, ok := session.FromContext(ctx)
+	if !ok {
+		sendJSONError(w, "Not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userRepo.GetByID(ctx, s.UserID)
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load user %s: %v", s.UserID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user.PasswordHash != password.NoPassword {
+		ok, _, err := h.hasher.Verify(user.PasswordHash, req.Password)
+		if err != nil {
+			log.Printf("❌ Failed to verify password of user %s: %v", s.UserID, err)
+		}
+		if !ok {
+			sendJSONError(w, "Password is incorrect", http.StatusForbidden)
+			return
+		}
+	}
+
+	now := time.Now()
+	deletion, err := h.accountRepo.ScheduleDeletion(ctx, s.UserID, s.ID, now.Add(h.cfg.DeletionGracePeriod), now)
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to schedule deletion of user %s: %v", s.UserID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("🗑 Запрошено удаление учетной записи: user=%s, срок %s", s.UserID, deletion.ScheduledAt.Format(time.RFC3339))
+	sendJSONSuccess(w, "Account deletion scheduled", deletion, http.StatusAccepted)
+}
+
+// CancelDeletion отменяет запрошенное удаление учетной записи
+func (h *AccountHandler) CancelDeletion(w http.ResponseWriter, r *http.Request) {
+	s, ok := session.FromContext(r.Context())
+	if !ok {
+		sendJSONError(w, "Not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	cancelled, err := h.accountRepo.CancelDeletion(r.Context(), s.UserID, time.Now())
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to cancel deletion of user %s: %v", s.UserID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !cancelled {
+		sendJSONError(w, "Account deletion is not scheduled", http.StatusConflict)
+		return
+	}
+
+	log.Printf("↩️ Удаление учетной записи отменено: user=%s", s.UserID)
+	sendJSONSuccess(w, "Account deletion cancelled", nil, http.StatusOK)
+}
+
+func exportDownloadURL(exportID string) string {
+	return "/api/me/exports/" + exportID + "/download"
+}
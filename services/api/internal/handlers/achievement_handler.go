@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mindly/api/internal/achievements"
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/leaderboard"
+	"github.com/mindly/api/internal/models"
+)
+
+type AchievementHandler struct {
+	achievementRepo *database.AchievementRepository
+}
+
+func NewAchievementHandler(db *sql.DB) *AchievementHandler {
+	return &AchievementHandler{achievementRepo: database.NewAchievementRepository(db)}
+}
+
+// GetMine возвращает все достижения с отметкой, какие уже получены
+func (h *AchievementHandler) GetMine(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	list, err := h.achievementRepo.ListForUser(r.Context(), userID)
+	if err != nil {
+		log.Printf("❌ Failed to load achievements for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Achievements loaded", list, http.StatusOK)
+}
+
+// evaluateAchievements проверяет достижения после события прогресса и отправляет
+// баллы за новые достижения в рейтинги.
+// Ошибка проверки не отменяет само событие: достижение выдастся при следующем.
+func evaluateAchievements(ctx context.Context, engine *achievements.Engine, lb *leaderboard.Service, userID string) []models.Achievement {
+	awarded, err := engine.Evaluate(ctx, userID)
+	if err != nil {
+		log.Printf("⚠️ Failed to evaluate achievements for user %s: %v", userID, err)
+	}
+
+	points := 0
+	for _, a := range awarded {
+		points += a.Points
+	}
+	publishPoints(ctx, lb, userID, points, time.Now())
+	return awarded
+}
@@ -4,21 +4,42 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/mail"
 	"strings"
 	"time"
 
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/mailer"
 	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/password"
+	"github.com/mindly/api/internal/profile"
+	"github.com/mindly/api/internal/verification"
 )
 
 type AuthHandler struct {
-	DB *sql.DB
+	DB         *sql.DB
+	userRepo   *database.UserRepository
+	verifyRepo *database.VerificationRepository
+	mailer     mailer.Mailer
+	verifyCfg  verification.Config
+	policy     password.Policy
+	hasher     *password.Hasher
 }
 
-func NewAuthHandler(db *sql.DB) *AuthHandler {
-	return &AuthHandler{DB: db}
+func NewAuthHandler(db *sql.DB, m mailer.Mailer, verifyCfg verification.Config, policy password.Policy, hasher *password.Hasher) *AuthHandler {
+	return &AuthHandler{
+		DB:         db,
+		userRepo:   database.NewUserRepository(db),
+		verifyRepo: database.NewVerificationRepository(db),
+		mailer:     m,
+		verifyCfg:  verifyCfg,
+		policy:     policy,
+		hasher:     hasher,
+	}
 }
 
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
@@ -36,9 +57,17 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Email хранится в нижнем регистре, username - в NFKC
+	req.Email = profile.NormalizeEmail(req.Email)
+	req.Username = profile.NormalizeUsername(req.Username)
+
 	// Логируем данные
+	var fullNameLog string
+	if req.FullName != nil {
+		fullNameLog = *req.FullName
+	}
 	log.Printf("📝 Регистрация: email=%s, username=%s, full_name='%s'",
-		req.Email, req.Username, req.FullName)
+		req.Email, req.Username, fullNameLog)
 
 	// Валидируем данные
 	if err := validateRegisterRequest(req); err != nil {
@@ -46,43 +75,33 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		sendJSONError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	identity := password.Identity{Username: req.Username, Email: req.Email}
+	if err := h.policy.Check(ctx, req.Password, identity); err != nil {
+		sendPasswordPolicyError(w, err)
+		return
+	}
 
-	// Проверяем, существует ли пользователь
-	var exists bool
-	query := "SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 OR username = $2)"
+	// Проверяем, свободны ли email и username (без учета регистра и похожих символов).
+	// Одновременную регистрацию ловят уникальные индексы при INSERT ниже
+	skeleton := profile.UsernameSkeleton(req.Username)
 
-	log.Printf("🔍 Проверка существования: email=%s, username=%s",
-		strings.ToLower(req.Email), req.Username)
+	log.Printf("🔍 Проверка существования: email=%s, username=%s", req.Email, req.Username)
 
-	err := h.DB.QueryRowContext(ctx, query, strings.ToLower(req.Email), req.Username).Scan(&exists)
+	err := h.userRepo.Taken(ctx, req.Email, req.Username, skeleton, time.Now())
+	if sendUserConflict(w, err) {
+		log.Printf("⚠️ Пользователь уже существует: %v", err)
+		return
+	}
 	if err != nil {
 		log.Printf("❌ Database error checking user existence: %v", err)
 		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("🔍 Результат проверки: exists=%v", exists)
-
-	if exists {
-		log.Printf("⚠️ Пользователь уже существует! Возвращаем 409")
-
-		// Найдем конфликтующего пользователя для отладки
-		var conflictEmail, conflictUsername string
-		h.DB.QueryRowContext(ctx,
-			"SELECT email, username FROM users WHERE email = $1 OR username = $2 LIMIT 1",
-			strings.ToLower(req.Email), req.Username,
-		).Scan(&conflictEmail, &conflictUsername)
-
-		log.Printf("⚠️ Конфликт с: email='%s', username='%s'", conflictEmail, conflictUsername)
-
-		sendJSONError(w, "User with this email or username already exists", http.StatusConflict)
-		return
-	}
-
 	log.Printf("✅ Пользователь не существует, создаем...")
 
 	// Хешируем пароль
-	passwordHash, err := models.HashPassword(req.Password)
+	passwordHash, err := h.hasher.Hash(req.Password)
 	if err != nil {
 		log.Printf("❌ Password hashing error: %v", err)
 		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
@@ -99,12 +118,13 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 			id,
 			email, 
 			username, 
+			username_skeleton,
 			password_hash, 
 			full_name, 
 			score, 
 			current_streak, 
 			best_streak
-		) VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7)
+		) VALUES (gen_random_uuid(), $1, $2, $8, $3, $4, $5, $6, $7)
 		RETURNING id::text, created_at, updated_at
 	`
 
@@ -118,18 +138,24 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Printf("📤 Выполняем INSERT с параметрами: email=%s, username=%s, full_name=%v",
-		strings.ToLower(req.Email), req.Username, fullNameParam)
+		req.Email, req.Username, fullNameParam)
 
 	err = h.DB.QueryRowContext(ctx, insertQuery,
-		strings.ToLower(req.Email),
+		req.Email,
 		req.Username,
 		passwordHash,
 		fullNameParam,
 		0,
 		0,
 		0,
+		skeleton,
 	).Scan(&id, &createdAt, &updatedAt)
 
+	// Email или username успели занять между проверкой и вставкой
+	if sendUserConflict(w, database.UserConflict(err)) {
+		log.Printf("⚠️ Пользователь уже существует (гонка регистраций): %v", err)
+		return
+	}
 	if err != nil {
 		log.Printf("❌ Database insert error: %v", err)
 		log.Printf("❌ Детали ошибки: email=%s, username=%s", req.Email, req.Username)
@@ -144,6 +170,11 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("✅ Пользователь создан! ID: %s, email: %s", id, req.Email)
 
+	// Письмо с подтверждением: если отправка не удалась, пользователь запросит его повторно
+	if err := sendVerificationEmail(ctx, h.verifyRepo, h.mailer, h.verifyCfg, id, time.Now()); err != nil {
+		log.Printf("⚠️ Не удалось отправить письмо с подтверждением для %s: %v", id, err)
+	}
+
 	// Создаем объект пользователя для ответа
 	user := models.User{
 		ID:            id,
@@ -161,7 +192,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	// Отправляем успешный ответ
 	response := models.APIResponse{
 		Status:  "success",
-		Message: "User registered successfully",
+		Message: "User registered successfully. Check your email to verify the address",
 		Data:    user,
 	}
 
@@ -213,30 +244,50 @@ func validateRegisterRequest(req models.RegisterRequest) error {
 	if strings.TrimSpace(req.Email) == "" {
 		return fmt.Errorf("email is required")
 	}
-	if !strings.Contains(req.Email, "@") || !strings.Contains(req.Email, ".") {
+	if !validEmail(req.Email) {
 		return fmt.Errorf("invalid email format")
 	}
 
-	// Проверяем username
+	// Проверяем username: те же правила, что и при смене в профиле
 	if strings.TrimSpace(req.Username) == "" {
 		return fmt.Errorf("username is required")
 	}
-	if len(req.Username) < 3 {
-		return fmt.Errorf("username must be at least 3 characters")
-	}
-	if len(req.Username) > 50 {
-		return fmt.Errorf("username must be less than 50 characters")
+	if err := profile.ValidateUsername(req.Username); err != nil {
+		return err
 	}
 
-	// Проверяем пароль
-	if strings.TrimSpace(req.Password) == "" {
-		return fmt.Errorf("password is required")
+	// Пароль проверяется отдельно по password.Policy
+
+	return nil
+}
+
+// validEmail принимает только голый адрес вида local@domain.tld без имени и угловых скобок
+func validEmail(email string) bool {
+	if len(email) > 254 {
+		return false
 	}
-	if len(req.Password) < 6 {
-		return fmt.Errorf("password must be at least 6 characters")
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return false
 	}
+	at := strings.LastIndex(email, "@")
+	domain := email[at+1:]
+	return at > 0 && strings.Contains(domain, ".") &&
+		!strings.HasPrefix(domain, ".") && !strings.HasSuffix(domain, ".")
+}
 
-	return nil
+// sendUserConflict отвечает 409 с названием занятого поля, если err - ErrEmailTaken
+// или ErrUsernameTaken; false - ошибка другая и ответ не отправлен
+func sendUserConflict(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, database.ErrEmailTaken):
+		sendJSONErrorCode(w, "User with this email already exists", "email_taken", http.StatusConflict)
+	case errors.Is(err, database.ErrUsernameTaken):
+		sendJSONErrorCode(w, "Username is already taken", "username_taken", http.StatusConflict)
+	default:
+		return false
+	}
+	return true
 }
 
 func sendJSONError(w http.ResponseWriter, message string, statusCode int) {
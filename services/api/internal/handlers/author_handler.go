@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mindly/api/internal/database"
+)
+
+type AuthorHandler struct {
+	authorRepo *database.AuthorRepository
+	userRepo   *database.UserRepository
+}
+
+func NewAuthorHandler(db *sql.DB) *AuthorHandler {
+	return &AuthorHandler{
+		authorRepo: database.NewAuthorRepository(db),
+		userRepo:   database.NewUserRepository(db),
+	}
+}
+
+// BecomeAuthor создает профиль автора для текущего пользователя:
+// {"full_name": "...", "expertise_area": "IT"}. Нужен подтвержденный email
+func (h *AuthorHandler) BecomeAuthor(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if !requireVerifiedEmail(w, r, h.userRepo, userID) {
+		return
+	}
+
+	var req struct {
+		FullName      string `json:"full_name"`
+		ExpertiseArea string `json:"expertise_area"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	req.FullName = strings.TrimSpace(req.FullName)
+	req.ExpertiseArea = strings.TrimSpace(req.ExpertiseArea)
+	if req.FullName == "" || utf8.RuneCountInString(req.FullName) > 255 {
+		sendJSONError(w, "full_name is required and must be at most 255 characters", http.StatusBadRequest)
+		return
+	}
+	if req.ExpertiseArea == "" || utf8.RuneCountInString(req.ExpertiseArea) > 100 {
+		sendJSONError(w, "expertise_area is required and must be at most 100 characters", http.StatusBadRequest)
+		return
+	}
+
+	author, err := h.authorRepo.CreateForUser(r.Context(), userID, req.FullName, req.ExpertiseArea)
+	if errors.Is(err, database.ErrAlreadyAuthor) {
+		sendJSONError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to create author profile for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("🎓 Новый автор: user=%s author=%s (%s)", userID, author.ID, author.ExpertiseArea)
+	sendJSONSuccess(w, "Author profile created", author, http.StatusCreated)
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/moderation"
+	"github.com/mindly/api/internal/pagination"
+)
+
+const maxCommentLength = 2000
+
+type CommentHandler struct {
+	commentRepo *database.CommentRepository
+	userRepo    *database.UserRepository
+	filter      *moderation.Filter
+}
+
+func NewCommentHandler(db *sql.DB, filter *moderation.Filter) *CommentHandler {
+	return &CommentHandler{
+		commentRepo: database.NewCommentRepository(db),
+		userRepo:    database.NewUserRepository(db),
+		filter:      filter,
+	}
+}
+
+// List возвращает комментарии к видео: ?cursor=&limit=. Закрепленные - на первой странице
+func (h *CommentHandler) List(w http.ResponseWriter, r *http.Request) {
+	videoID := r.PathValue("id")
+	if !isUUID(videoID) {
+		sendJSONError(w, "Invalid video id", http.StatusBadRequest)
+		return
+	}
+
+	cursor, ok := commentCursor(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.commentRepo.List(r.Context(), videoID, viewerID(r), cursor, feedLimit(r))
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "Video not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load comments for video %s: %v", videoID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Comments loaded", page, http.StatusOK)
+}
+
+// Replies возвращает ответы в ветке: ?cursor=&limit=
+func (h *CommentHandler) Replies(w http.ResponseWriter, r *http.Request) {
+	commentID := r.PathValue("id")
+	if !isUUID(commentID) {
+		sendJSONError(w, "Invalid comment id", http.StatusBadRequest)
+		return
+	}
+
+	cursor, ok := commentCursor(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.commentRepo.Replies(r.Context(), commentID, viewerID(r), cursor, feedLimit(r))
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load replies for comment %s: %v", commentID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Replies loaded", page, http.StatusOK)
+}
+
+// Create добавляет комментарий или ответ: {"body": "...", "parent_id": "..."}.
+// Комментировать можно только с подтвержденным email
+func (h *CommentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, videoID, ok := videoActionParams(w, r)
+	if !ok {
+		return
+	}
+	if !requireVerifiedEmail(w, r, h.userRepo, userID) {
+		return
+	}
+
+	var in models.CommentInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	in.Body = strings.TrimSpace(in.Body)
+	if err := validateCommentBody(in.Body); err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if in.ParentID != "" && !isUUID(in.ParentID) {
+		sendJSONError(w, "Invalid parent comment id", http.StatusBadRequest)
+		return
+	}
+
+	flagged := h.filter.Check(in.Body)
+	comment, err := h.commentRepo.Create(r.Context(), userID, videoID, in, flagged, time.Now())
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "Video not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, database.ErrInvalidParent) {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to create comment on video %s: %v", videoID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if len(flagged) > 0 {
+		log.Printf("🚩 Комментарий %s отправлен на модерацию: %v", comment.ID, flagged)
+		sendJSONSuccess(w, "Comment is awaiting moderation", comment, http.StatusCreated)
+		return
+	}
+	sendJSONSuccess(w, "Comment created", comment, http.StatusCreated)
+}
+
+// Edit меняет текст своего комментария: {"body": "..."}
+func (h *CommentHandler) Edit(w http.ResponseWriter, r *http.Request) {
+	userID, commentID, ok := commentParams(w, r)
+	if !ok {
+		return
+	}
+	if !requireVerifiedEmail(w, r, h.userRepo, userID) {
+		return
+	}
+
+	var in models.CommentInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	in.Body = strings.TrimSpace(in.Body)
+	if err := validateCommentBody(in.Body); err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flagged := h.filter.Check(in.Body)
+	comment, err := h.commentRepo.Edit(r.Context(), commentID, userID, in.Body, flagged, time.Now())
+	if !h.commentError(w, err, commentID) {
+		return
+	}
+
+	if len(flagged) > 0 {
+		log.Printf("🚩 Комментарий %s отправлен на модерацию после правки: %v", commentID, flagged)
+		sendJSONSuccess(w, "Comment is awaiting moderation", comment, http.StatusOK)
+		return
+	}
+	sendJSONSuccess(w, "Comment updated", comment, http.StatusOK)
+}
+
+// Delete удаляет свой комментарий
+func (h *CommentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, commentID, ok := commentParams(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.commentRepo.Delete(r.Context(), commentID, userID, time.Now())
+	if !h.commentError(w, err, commentID) {
+		return
+	}
+
+	sendJSONSuccess(w, "Comment deleted", nil, http.StatusOK)
+}
+
+// Pin закрепляет или открепляет комментарий под своим видео: {"pinned": true}
+func (h *CommentHandler) Pin(w http.ResponseWriter, r *http.Request) {
+	userID, commentID, ok := commentParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.PinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	comment, err := h.commentRepo.SetPinned(r.Context(), commentID, userID, req.Pinned)
+	if errors.Is(err, database.ErrPinLimit) {
+		sendJSONError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, database.ErrPinReply) {
+		sendJSONError(w, "Only top-level comments can be pinned", http.StatusBadRequest)
+		return
+	}
+	if !h.commentError(w, err, commentID) {
+		return
+	}
+
+	sendJSONSuccess(w, "Comment pin updated", comment, http.StatusOK)
+}
+
+// commentError отвечает на ошибку изменения комментария; true - ошибки не было
+func (h *CommentHandler) commentError(w http.ResponseWriter, err error, commentID string) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, database.ErrNotFound):
+		sendJSONError(w, "Comment not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrForbidden):
+		sendJSONError(w, "Not allowed to change this comment", http.StatusForbidden)
+	default:
+		log.Printf("❌ Failed to update comment %s: %v", commentID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+	}
+	return false
+}
+
+func commentParams(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return "", "", false
+	}
+
+	commentID := r.PathValue("id")
+	if !isUUID(commentID) {
+		sendJSONError(w, "Invalid comment id", http.StatusBadRequest)
+		return "", "", false
+	}
+
+	return userID, commentID, true
+}
+
+func commentCursor(w http.ResponseWriter, r *http.Request) (*pagination.Cursor, bool) {
+	cursor, err := pagination.Decode(r.URL.Query().Get("cursor"))
+	if err != nil || (cursor != nil && !isUUID(cursor.ID)) {
+		sendJSONError(w, "Invalid cursor", http.StatusBadRequest)
+		return nil, false
+	}
+	return cursor, true
+}
+
+func validateCommentBody(body string) error {
+	if body == "" {
+		return errors.New("comment body is required")
+	}
+	if utf8.RuneCountInString(body) > maxCommentLength {
+		return errors.New("comment is too long")
+	}
+	return nil
+}
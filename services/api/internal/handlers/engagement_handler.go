@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/pagination"
+)
+
+type EngagementHandler struct {
+	engagementRepo *database.EngagementRepository
+}
+
+func NewEngagementHandler(db *sql.DB) *EngagementHandler {
+	return &EngagementHandler{engagementRepo: database.NewEngagementRepository(db)}
+}
+
+// Like ставит лайк (PUT) или снимает его (DELETE); повтор запроса ничего не меняет
+func (h *EngagementHandler) Like(w http.ResponseWriter, r *http.Request) {
+	userID, videoID, ok := videoActionParams(w, r)
+	if !ok {
+		return
+	}
+
+	liked := r.Method != http.MethodDelete
+	state, err := h.engagementRepo.SetLike(r.Context(), userID, videoID, liked, time.Now())
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "Video not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to update like on video %s: %v", videoID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Like updated", state, http.StatusOK)
+}
+
+// Bookmark сохраняет видео в библиотеку (PUT) или убирает его оттуда (DELETE)
+func (h *EngagementHandler) Bookmark(w http.ResponseWriter, r *http.Request) {
+	userID, videoID, ok := videoActionParams(w, r)
+	if !ok {
+		return
+	}
+
+	bookmarked := r.Method != http.MethodDelete
+	state, err := h.engagementRepo.SetBookmark(r.Context(), userID, videoID, bookmarked, time.Now())
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "Video not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to update bookmark on video %s: %v", videoID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Bookmark updated", state, http.StatusOK)
+}
+
+// GetSaved возвращает библиотеку сохраненных видео: ?cursor=&limit=
+func (h *EngagementHandler) GetSaved(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	cursor, err := pagination.Decode(r.URL.Query().Get("cursor"))
+	if err != nil || (cursor != nil && !isUUID(cursor.ID)) {
+		sendJSONError(w, "Invalid cursor", http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.engagementRepo.Saved(r.Context(), userID, cursor, feedLimit(r))
+	if err != nil {
+		log.Printf("❌ Failed to load saved videos for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Saved videos loaded", page, http.StatusOK)
+}
+
+func videoActionParams(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return "", "", false
+	}
+
+	videoID := r.PathValue("id")
+	if !isUUID(videoID) {
+		sendJSONError(w, "Invalid video id", http.StatusBadRequest)
+		return "", "", false
+	}
+
+	return userID, videoID, true
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mindly/api/internal/database"
+)
+
+type FollowHandler struct {
+	followRepo *database.FollowRepository
+}
+
+func NewFollowHandler(db *sql.DB) *FollowHandler {
+	return &FollowHandler{followRepo: database.NewFollowRepository(db)}
+}
+
+// Follow подписывает текущего пользователя на автора
+func (h *FollowHandler) Follow(w http.ResponseWriter, r *http.Request) {
+	userID, authorID, ok := followParams(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := h.followRepo.Follow(r.Context(), userID, authorID, time.Now())
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "Author not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, database.ErrSelfFollow) {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to follow author %s: %v", authorID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Author followed", status, http.StatusOK)
+}
+
+// Unfollow отменяет подписку на автора
+func (h *FollowHandler) Unfollow(w http.ResponseWriter, r *http.Request) {
+	userID, authorID, ok := followParams(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := h.followRepo.Unfollow(r.Context(), userID, authorID)
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "Author not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to unfollow author %s: %v", authorID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Author unfollowed", status, http.StatusOK)
+}
+
+func followParams(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return "", "", false
+	}
+
+	authorID := r.PathValue("id")
+	if !isUUID(authorID) {
+		sendJSONError(w, "Invalid author id", http.StatusBadRequest)
+		return "", "", false
+	}
+
+	return userID, authorID, true
+}
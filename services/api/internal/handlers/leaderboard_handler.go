@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/leaderboard"
+	"github.com/mindly/api/internal/models"
+)
+
+type LeaderboardHandler struct {
+	lb         *leaderboard.Service
+	lbRepo     *database.LeaderboardRepository
+	friendRepo *database.FriendRepository
+}
+
+func NewLeaderboardHandler(db *sql.DB, lb *leaderboard.Service) *LeaderboardHandler {
+	return &LeaderboardHandler{
+		lb:         lb,
+		lbRepo:     database.NewLeaderboardRepository(db),
+		friendRepo: database.NewFriendRepository(db),
+	}
+}
+
+// publishPoints отправляет в рейтинги изменение баланса со знаком: начисления, траты и корректировки.
+// Ошибка Redis не отменяет начисление: рейтинги восстанавливаются командой leaderboard-rebuild.
+func publishPoints(ctx context.Context, lb *leaderboard.Service, userID string, points int, at time.Time) {
+	if points == 0 {
+		return
+	}
+	if err := lb.AddPoints(ctx, userID, points, at); err != nil {
+		log.Printf("⚠️ Failed to update leaderboards for user %s: %v", userID, err)
+	}
+}
+
+// publishStreak обновляет рейтинг серий, когда действие выполнило дневную цель,
+// и отправляет в рейтинги бонус за круглую дату серии
+func publishStreak(ctx context.Context, lb *leaderboard.Service, userID string, update models.GoalUpdate) {
+	if !update.GoalCompleted {
+		return
+	}
+	if err := lb.SetStreak(ctx, userID, update.CurrentStreak); err != nil {
+		log.Printf("⚠️ Failed to update streak leaderboard for user %s: %v", userID, err)
+	}
+	publishPoints(ctx, lb, userID, update.BonusPoints, time.Now())
+}
+
+// parseBoard читает рейтинг из пути и период из query (?window=all|week|month)
+func parseBoard(r *http.Request) (leaderboard.Board, leaderboard.Window, error) {
+	board := leaderboard.Board(r.PathValue("board"))
+	window := leaderboard.Window(r.URL.Query().Get("window"))
+	if window == "" {
+		window = leaderboard.WindowAll
+	}
+	return board, window, leaderboard.Validate(board, window)
+}
+
+// GetLeaderboard возвращает страницу рейтинга: общего (?scope=global) или среди друзей (?scope=friends)
+func (h *LeaderboardHandler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	board, window, err := parseBoard(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	scope := query.Get("scope")
+	if scope == "" {
+		scope = "global"
+	}
+
+	limit, err := strconv.ParseInt(query.Get("limit"), 10, 64)
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	offset, err := strconv.ParseInt(query.Get("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	// Текущий пользователь нужен для рейтинга друзей и строки "мое место"
+	userID, userErr := requestUserID(r)
+
+	page := models.LeaderboardPage{
+		Board:  string(board),
+		Window: string(window),
+		Scope:  scope,
+	}
+
+	var entries []leaderboard.Entry
+	var me *leaderboard.Entry
+	switch scope {
+	case "global":
+		entries, page.Total, err = h.lb.Top(ctx, board, window, offset, limit)
+		if err == nil && userErr == nil {
+			entry, rankErr := h.lb.Rank(ctx, board, window, userID)
+			if rankErr != nil {
+				log.Printf("⚠️ Failed to load rank for user %s: %v", userID, rankErr)
+			} else {
+				me = &entry
+			}
+		}
+	case "friends":
+		if userErr != nil {
+			sendJSONError(w, userErr.Error(), http.StatusUnauthorized)
+			return
+		}
+		var ids []string
+		ids, err = h.friendRepo.IDs(ctx, userID)
+		if err == nil {
+			// Рейтинг друзей небольшой: ранжируем целиком и отдаем страницу
+			entries, err = h.lb.Among(ctx, board, window, append(ids, userID))
+			page.Total = int64(len(entries))
+			me = findEntry(entries, userID)
+			entries = pageOf(entries, offset, limit)
+		}
+	default:
+		sendJSONError(w, "scope must be global or friends", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load leaderboard %s/%s: %v", board, window, err)
+		sendJSONError(w, "Leaderboard is temporarily unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	page.Entries, page.Me, err = h.withUsernames(r, entries, me)
+	if err != nil {
+		log.Printf("❌ Failed to load usernames: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Leaderboard loaded", page, http.StatusOK)
+}
+
+// GetMyRank возвращает место текущего пользователя в общем рейтинге
+func (h *LeaderboardHandler) GetMyRank(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	board, window, err := parseBoard(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.lb.Rank(r.Context(), board, window, userID)
+	if err != nil {
+		log.Printf("❌ Failed to load rank for user %s: %v", userID, err)
+		sendJSONError(w, "Leaderboard is temporarily unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	_, me, err := h.withUsernames(r, nil, &entry)
+	if err != nil {
+		log.Printf("❌ Failed to load usernames: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Rank loaded", me, http.StatusOK)
+}
+
+// AddFriend добавляет пользователя в список друзей для рейтинга друзей
+func (h *LeaderboardHandler) AddFriend(w http.ResponseWriter, r *http.Request) {
+	userID, friendID, ok := h.friendParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.friendRepo.Add(r.Context(), userID, friendID); err != nil {
+		log.Printf("❌ Failed to add friend: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Friend added", nil, http.StatusOK)
+}
+
+// RemoveFriend убирает пользователя из списка друзей
+func (h *LeaderboardHandler) RemoveFriend(w http.ResponseWriter, r *http.Request) {
+	userID, friendID, ok := h.friendParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.friendRepo.Remove(r.Context(), userID, friendID); err != nil {
+		log.Printf("❌ Failed to remove friend: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Friend removed", nil, http.StatusOK)
+}
+
+func (h *LeaderboardHandler) friendParams(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return "", "", false
+	}
+
+	friendID := r.PathValue("id")
+	if !isUUID(friendID) {
+		sendJSONError(w, "Invalid user id", http.StatusBadRequest)
+		return "", "", false
+	}
+	if friendID == userID {
+		sendJSONError(w, "You can't add yourself as a friend", http.StatusBadRequest)
+		return "", "", false
+	}
+	return userID, friendID, true
+}
+
+// withUsernames дополняет строки рейтинга именами пользователей
+func (h *LeaderboardHandler) withUsernames(r *http.Request, entries []leaderboard.Entry, me *leaderboard.Entry) ([]models.LeaderboardEntry, *models.LeaderboardEntry, error) {
+	ids := make([]string, 0, len(entries)+1)
+	for _, e := range entries {
+		ids = append(ids, e.UserID)
+	}
+	if me != nil {
+		ids = append(ids, me.UserID)
+	}
+
+	names, err := h.lbRepo.Usernames(r.Context(), ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := make([]models.LeaderboardEntry, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, models.LeaderboardEntry{
+			Rank: e.Rank, UserID: e.UserID, Username: names[e.UserID], Score: e.Score,
+		})
+	}
+
+	var meEntry *models.LeaderboardEntry
+	if me != nil {
+		meEntry = &models.LeaderboardEntry{
+			Rank: me.Rank, UserID: me.UserID, Username: names[me.UserID], Score: me.Score,
+		}
+	}
+	return result, meEntry, nil
+}
+
+func pageOf(entries []leaderboard.Entry, offset, limit int64) []leaderboard.Entry {
+	if offset >= int64(len(entries)) {
+		return []leaderboard.Entry{}
+	}
+	end := min(offset+limit, int64(len(entries)))
+	return entries[offset:end]
+}
+
+func findEntry(entries []leaderboard.Entry, userID string) *leaderboard.Entry {
+	for _, e := range entries {
+		if e.UserID == userID {
+			return &e
+		}
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/models"
+)
+
+// ModerationHandler - решения модераторов по видео и комментариям. Доступно ролям moderator и admin
+type ModerationHandler struct {
+	moderationRepo *database.ModerationRepository
+	userRepo       *database.UserRepository
+	twoFactorRepo  *database.TwoFactorRepository
+}
+
+func NewModerationHandler(db *sql.DB) *ModerationHandler {
+	return &ModerationHandler{
+		moderationRepo: database.NewModerationRepository(db),
+		userRepo:       database.NewUserRepository(db),
+		twoFactorRepo:  database.NewTwoFactorRepository(db),
+	}
+}
+
+// PendingComments возвращает очередь комментариев на модерации: ?limit=
+func (h *ModerationHandler) PendingComments(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireModerator(w, r); !ok {
+		return
+	}
+
+	limit, _ := optionalInt(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	queue, err := h.moderationRepo.PendingComments(r.Context(), limit)
+	if err != nil {
+		log.Printf("❌ Failed to load moderation queue: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Moderation queue loaded", queue, http.StatusOK)
+}
+
+// ReportedTargets возвращает видео и комментарии с открытыми жалобами: ?limit=
+func (h *ModerationHandler) ReportedTargets(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireModerator(w, r); !ok {
+		return
+	}
+
+	limit, _ := optionalInt(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	targets, err := h.moderationRepo.ReportedTargets(r.Context(), limit)
+	if err != nil {
+		log.Printf("❌ Failed to load reported content: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Reported content loaded", targets, http.StatusOK)
+}
+
+// DecideComment одобряет или отклоняет комментарий: {"status": "approved"|"rejected"}.
+// Жалобы на комментарий закрываются, пожаловавшиеся получают уведомление
+func (h *ModerationHandler) DecideComment(w http.ResponseWriter, r *http.Request) {
+	moderatorID, ok := h.requireModerator(w, r)
+	if !ok {
+		return
+	}
+
+	commentID := r.PathValue("id")
+	if !isUUID(commentID) {
+		sendJSONError(w, "Invalid comment id", http.StatusBadRequest)
+		return
+	}
+	status, ok := decodeDecision(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.moderationRepo.DecideComment(r.Context(), commentID, moderatorID, status, time.Now())
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to moderate comment %s: %v", commentID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("🛡 Модерация: комментарий %s → %s (moderator=%s)", commentID, status, moderatorID)
+	sendJSONSuccess(w, "Comment moderated", map[string]any{"id": commentID, "status": status}, http.StatusOK)
+}
+
+// DecideVideo одобряет или отклоняет видео: {"status": "approved"|"rejected"}.
+// Жалобы на видео закрываются, пожаловавшиеся получают уведомление
+func (h *ModerationHandler) DecideVideo(w http.ResponseWriter, r *http.Request) {
+	moderatorID, ok := h.requireModerator(w, r)
+	if !ok {
+		return
+	}
+
+	videoID := r.PathValue("id")
+	if !isUUID(videoID) {
+		sendJSONError(w, "Invalid video id", http.StatusBadRequest)
+		return
+	}
+	status, ok := decodeDecision(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.moderationRepo.DecideVideo(r.Context(), videoID, moderatorID, status, time.Now())
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "Video not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to moderate video %s: %v", videoID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("🛡 Модерация: видео %s → %s (moderator=%s)", videoID, status, moderatorID)
+	sendJSONSuccess(w, "Video moderated", map[string]any{"id": videoID, "status": status}, http.StatusOK)
+}
+
+// TwoFactorRequirements - для каких ролей 2FA обязательна
+func (h *ModerationHandler) TwoFactorRequirements(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireModerator(w, r); !ok {
+		return
+	}
+
+	reqs, err := h.twoFactorRepo.Requirements(r.Context())
+	if err != nil {
+		log.Printf("❌ Failed to load 2FA requirements: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Two-factor requirements", reqs, http.StatusOK)
+}
+
+// SetTwoFactorRequirement делает 2FA обязательной для роли или снимает требование:
+// PUT /api/moderation/2fa/roles/{role} {"required": true}. Только для администраторов:
+// иначе модератор мог бы снять требование со своей же роли
+func (h *ModerationHandler) SetTwoFactorRequirement(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	role := models.Role(r.PathValue("role"))
+	if role != models.RoleUser && role != models.RoleModerator && role != models.RoleAdmin {
+		sendJSONError(w, "Unknown role", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Required *bool `json:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if req.Required == nil {
+		sendJSONError(w, "required is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.twoFactorRepo.SetRequired(r.Context(), role, *req.Required, adminID, time.Now()); err != nil {
+		log.Printf("❌ Failed to save 2FA requirement for role %s: %v", role, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("🛡 2FA для роли %s: required=%v (admin=%s)", role, *req.Required, adminID)
+	sendJSONSuccess(w, "Two-factor requirement updated", map[string]any{"role": role, "required": *req.Required}, http.StatusOK)
+}
+
+// requireModerator пропускает только модераторов и администраторов,
+// а если для их роли обязательна 2FA - только с включенной 2FA
+func (h *ModerationHandler) requireModerator(w http.ResponseWriter, r *http.Request) (string, bool) {
+	return h.requireRole(w, r, models.Role.CanModerate, "Moderator role required")
+}
+
+// requireAdmin - то же для действий только администраторов
+func (h *ModerationHandler) requireAdmin(w http.ResponseWriter, r *http.Request) (string, bool) {
+	isAdmin := func(role models.Role) bool { return role == models.RoleAdmin }
+	return h.requireRole(w, r, isAdmin, "Admin role required")
+}
+
+func (h *ModerationHandler) requireRole(w http.ResponseWriter, r *http.Request, allowed func(models.Role) bool, denied string) (string, bool) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return "", false
+	}
+
+	role, err := h.userRepo.Role(r.Context(), userID)
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "User not found", http.StatusUnauthorized)
+		return "", false
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load role for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return "", false
+	}
+	if !allowed(role) {
+		sendJSONError(w, denied, http.StatusForbidden)
+		return "", false
+	}
+
+	unmet, err := h.twoFactorRepo.RequirementUnmet(r.Context(), userID, role)
+	if err != nil {
+		log.Printf("❌ Failed to check 2FA requirement for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return "", false
+	}
+	if unmet {
+		sendJSONErrorCode(w, "Enable two-factor authentication to moderate content", codeTwoFactorRequired, http.StatusForbidden)
+		return "", false
+	}
+
+	return userID, true
+}
+
+func decodeDecision(w http.ResponseWriter, r *http.Request) (models.ModerationStatus, bool) {
+	var d models.ModerationDecision
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return "", false
+	}
+	if d.Status != models.ModerationApproved && d.Status != models.ModerationRejected {
+		sendJSONError(w, "status must be approved or rejected", http.StatusBadRequest)
+		return "", false
+	}
+	return d.Status, true
+}
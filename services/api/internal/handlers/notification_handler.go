@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mindly/api/internal/database"
+)
+
+type NotificationHandler struct {
+	notificationRepo *database.NotificationRepository
+}
+
+func NewNotificationHandler(db *sql.DB) *NotificationHandler {
+	return &NotificationHandler{notificationRepo: database.NewNotificationRepository(db)}
+}
+
+// List возвращает последние уведомления: ?limit= (по умолчанию 20, не больше 100)
+func (h *NotificationHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	limit, _ := optionalInt(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	list, err := h.notificationRepo.List(r.Context(), userID, limit)
+	if err != nil {
+		log.Printf("❌ Failed to load notifications for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Notifications loaded", list, http.StatusOK)
+}
+
+// MarkRead отмечает все уведомления прочитанными
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	marked, err := h.notificationRepo.MarkAllRead(r.Context(), userID, time.Now())
+	if err != nil {
+		log.Printf("❌ Failed to mark notifications read for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Notifications marked as read", map[string]int64{"marked": marked}, http.StatusOK)
+}
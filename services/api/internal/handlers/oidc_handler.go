@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/oidc"
+	"github.com/mindly/api/internal/password"
+	"github.com/mindly/api/internal/tokens"
+)
+
+// OIDCHandler - вход через внешних провайдеров OpenID Connect
+type OIDCHandler struct {
+	providers    *oidc.Registry
+	identityRepo *database.IdentityRepository
+	userRepo     *database.UserRepository
+	// Сессия после входа открывается так же, как при входе по паролю (с учетом 2FA)
+	sessions *SessionHandler
+}
+
+func NewOIDCHandler(db *sql.DB, providers *oidc.Registry, sessions *SessionHandler) *OIDCHandler {
+	return &OIDCHandler{
+		providers:    providers,
+		identityRepo: database.NewIdentityRepository(db),
+		userRepo:     database.NewUserRepository(db),
+		sessions:     sessions,
+	}
+}
+
+// Start начинает вход: возвращает адрес страницы провайдера с state, nonce и PKCE challenge
+func (h *OIDCHandler) Start(w http.ResponseWriter, r *http.Request) {
+	provider, err := h.providers.Get(r.PathValue("provider"))
+	if err != nil {
+		sendJSONError(w, "Unknown identity provider", http.StatusNotFound)
+		return
+	}
+
+	state, err := oidc.RandomString()
+	var nonce, verifier string
+	if err == nil {
+		nonce, err = oidc.RandomString()
+	}
+	if err == nil {
+		verifier, err = oidc.RandomString()
+	}
+	if err != nil {
+		log.Printf("❌ Failed to generate OIDC state: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(r.Context(), state, nonce, verifier)
+	if err != nil {
+		log.Printf("❌ OIDC provider %s is unavailable: %v", provider.Name(), err)
+		sendJSONError(w, "Identity provider is unavailable", http.StatusBadGateway)
+		return
+	}
+
+	now := time.Now()
+	err = h.identityRepo.CreateState(r.Context(), tokens.Hash(state), provider.Name(), verifier, nonce,
+		now.Add(h.providers.StateTTL()), now)
+	if err != nil {
+		log.Printf("❌ Failed to save OIDC state: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Redirect to the identity provider", models.OIDCStart{AuthorizationURL: authURL}, http.StatusOK)
+}
+
+// Callback завершает вход: {"code": "...", "state": "..."} из адреса возврата от провайдера
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	provider, err := h.providers.Get(r.PathValue("provider"))
+	if err != nil {
+		sendJSONError(w, "Unknown identity provider", http.StatusNotFound)
+		return
+	}
+
+	var req models.OIDCCallbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	req.Code, req.State = strings.TrimSpace(req.Code), strings.TrimSpace(req.State)
+	if req.Code == "" || req.State == "" {
+		sendJSONError(w, "code and state are required", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	verifier, nonce, err := h.identityRepo.ConsumeState(ctx, tokens.Hash(req.State), provider.Name(), now)
+	if errors.Is(err, database.ErrTokenInvalid) || errors.Is(err, database.ErrTokenExpired) {
+		sendJSONError(w, "Login attempt is invalid or expired, start again", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load OIDC state: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	claims, err := provider.Exchange(ctx, req.Code, verifier, nonce)
+	if errors.Is(err, oidc.ErrInvalidToken) {
+		log.Printf("⚠️ OIDC %s: ID-токен отклонен: %v", provider.Name(), err)
+		sendJSONError(w, "Identity provider returned an invalid token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ OIDC %s: code exchange failed: %v", provider.Name(), err)
+		sendJSONError(w, "Identity provider rejected the login", http.StatusBadGateway)
+		return
+	}
+
+	identity := models.ExternalIdentity{
+		Provider:      provider.Name(),
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}
+	userID, outcome, err := h.identityRepo.SignIn(ctx, identity, password.NoPassword, now)
+	if errors.Is(err, database.ErrIdentityEmailUnverified) {
+		sendJSONErrorCode(w, "The identity provider has not verified your email", "identity_email_unverified", http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, database.ErrAccountEmailUnverified) {
+		sendJSONErrorCode(w, "An account with this email exists but its email is not verified; "+
+			"log in with your password and verify it first", "account_email_unverified", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ OIDC %s: failed to sign in subject %s: %v", provider.Name(), claims.Subject, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	user, err := h.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		log.Printf("❌ Failed to load user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("🪪 OIDC %s: user=%s (%s)", provider.Name(), userID, outcome)
+	h.sessions.completeLogin(w, r, user, req.DeviceInfo)
+}
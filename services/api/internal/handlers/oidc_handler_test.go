@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/oidc"
+	"github.com/mindly/api/internal/oidc/mockissuer"
+	"github.com/mindly/api/internal/password"
+	"github.com/mindly/api/internal/profile"
+	"github.com/mindly/api/internal/session"
+	"github.com/mindly/api/internal/tokens"
+	"github.com/mindly/api/internal/totp"
+)
+
+// Вход через локальный издатель OIDC от Start до Callback. Нужна база разработки
+// (database.DefaultConfig() со схемой из init.sql); без нее тесты пропускаются
+
+const oidcTestClientID = "mindly-test"
+
+type oidcFlow struct {
+	t       *testing.T
+	db      *sql.DB
+	handler *OIDCHandler
+}
+
+func newOIDCFlow(t *testing.T) *oidcFlow {
+	t.Helper()
+
+	db, err := database.Connect(context.Background(), database.DefaultConfig())
+	if err != nil {
+		t.Skipf("PostgreSQL is not available: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	var issuerHandler http.Handler
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		issuerHandler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	issuer, err := mockissuer.New(srv.URL, oidcTestClientID, mockissuer.Identity{
+		Subject: "mock|default", Email: "default@mindly.test", EmailVerified: true,
+	})
+	if err != nil {
+		t.Fatalf("create mock issuer: %v", err)
+	}
+	issuerHandler = issuer.Handler()
+
+	providers, err := oidc.NewRegistry(oidc.Config{
+		Providers: []oidc.ProviderConfig{{
+			Name:        "mock",
+			Issuer:      srv.URL,
+			ClientID:    oidcTestClientID,
+			RedirectURL: "http://client.test/auth/callback/mock",
+			Scopes:      []string{"openid", "email"},
+		}},
+		StateTTL:    time.Minute,
+		ClockSkew:   time.Minute,
+		HTTPTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("create registry: %v", err)
+	}
+	hasher, err := password.NewHasher(password.DefaultHashConfig())
+	if err != nil {
+		t.Fatalf("create hasher: %v", err)
+	}
+	sessions := NewSessionHandler(db, session.DefaultConfig(), totp.DefaultConfig(), hasher)
+
+	return &oidcFlow{t: t, db: db, handler: NewOIDCHandler(db, providers, sessions)}
+}
+
+// email - уникальный адрес теста; пользователи с ним удаляются после теста
+func (f *oidcFlow) email() string {
+	buf := make([]byte, 5)
+	if _, err := rand.Read(buf); err != nil {
+		f.t.Fatal(err)
+	}
+	email := "oidc_" + hex.EncodeToString(buf) + "@mindly.test"
+	f.t.Cleanup(func() {
+		f.db.Exec(`DELETE FROM users WHERE LOWER(email) = LOWER($1)`, email)
+	})
+	return email
+}
+
+// seedUser создает пользователя с паролем; verified - подтвержден ли его email
+func (f *oidcFlow) seedUser(email string, verified bool) string {
+	f.t.Helper()
+
+	username, _, _ := strings.Cut(email, "@")
+	var verifiedAt *time.Time
+	if verified {
+		now := time.Now()
+		verifiedAt = &now
+	}
+	var userID string
+	err := f.db.QueryRow(`
+		INSERT INTO users (email, username, username_skeleton, password_hash, email_verified_at)
+		VALUES ($1, $2, $3, 'x', $4)
+		RETURNING id::text
+	`, email, username, profile.UsernameSkeleton(username), verifiedAt).Scan(&userID)
+	if err != nil {
+		f.t.Fatalf("seed user: %v", err)
+	}
+	return userID
+}
+
+// start вызывает Start и проходит страницу входа издателя: возвращает code и state
+func (f *oidcFlow) start(extra url.Values) (code, state string) {
+	f.t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/mock/start", nil)
+	req.SetPathValue("provider", "mock")
+	rec := httptest.NewRecorder()
+	f.handler.Start(rec, req)
+	if rec.Code != http.StatusOK {
+		f.t.Fatalf("start returned %d: %s", rec.Code, rec.Body)
+	}
+
+	var resp struct {
+		Data models.OIDCStart `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		f.t.Fatalf("decode start response: %v", err)
+	}
+	authURL := resp.Data.AuthorizationURL
+	if len(extra) > 0 {
+		authURL += "&" + extra.Encode()
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	authResp, err := client.Get(authURL)
+	if err != nil {
+		f.t.Fatalf("authorize: %v", err)
+	}
+	authResp.Body.Close()
+	back, err := url.Parse(authResp.Header.Get("Location"))
+	if err != nil || authResp.StatusCode != http.StatusFound {
+		f.t.Fatalf("authorize returned %d (%v)", authResp.StatusCode, err)
+	}
+	return back.Query().Get("code"), back.Query().Get("state")
+}
+
+// callback вызывает Callback и возвращает статус и ответ
+func (f *oidcFlow) callback(code, state string) (int, models.APIResponse) {
+	f.t.Helper()
+
+	body, _ := json.Marshal(models.OIDCCallbackRequest{Code: code, State: state})
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/oidc/mock/callback", strings.NewReader(string(body)))
+	req.SetPathValue("provider", "mock")
+	rec := httptest.NewRecorder()
+	f.handler.Callback(rec, req)
+
+	var resp models.APIResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		f.t.Fatalf("decode callback response: %v", err)
+	}
+	return rec.Code, resp
+}
+
+// identityUser - пользователь, к которому привязана учетная запись издателя
+func (f *oidcFlow) identityUser(email string) string {
+	f.t.Helper()
+
+	var userID string
+	err := f.db.QueryRow(`
+		SELECT user_id::text FROM user_identities WHERE provider = 'mock' AND subject = $1
+	`, "mock|"+email).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return ""
+	}
+	if err != nil {
+		f.t.Fatalf("load identity: %v", err)
+	}
+	return userID
+}
+
+func TestOIDCCallbackCreatesUser(t *testing.T) {
+	f := newOIDCFlow(t)
+	email := f.email()
+
+	code, state := f.start(url.Values{"login_hint": {email}})
+	status, resp := f.callback(code, state)
+	if status != http.StatusOK {
+		t.Fatalf("callback returned %d: %+v", status, resp)
+	}
+	if f.identityUser(email) == "" {
+		t.Error("identity was not saved")
+	}
+
+	// Повторный вход той же учетной записью находит того же пользователя
+	userID := f.identityUser(email)
+	code, state = f.start(url.Values{"login_hint": {email}})
+	if status, resp := f.callback(code, state); status != http.StatusOK {
+		t.Fatalf("second callback returned %d: %+v", status, resp)
+	}
+	if got := f.identityUser(email); got != userID {
+		t.Errorf("second login signed in user %s, want %s", got, userID)
+	}
+}
+
+func TestOIDCCallbackLinksVerifiedAccount(t *testing.T) {
+	f := newOIDCFlow(t)
+	email := f.email()
+	userID := f.seedUser(email, true)
+
+	code, state := f.start(url.Values{"login_hint": {email}})
+	status, resp := f.callback(code, state)
+	if status != http.StatusOK {
+		t.Fatalf("callback returned %d: %+v", status, resp)
+	}
+	if got := f.identityUser(email); got != userID {
+		t.Errorf("identity linked to %q, want existing user %s", got, userID)
+	}
+}
+
+func TestOIDCCallbackDoesNotLinkUnverifiedAccount(t *testing.T) {
+	f := newOIDCFlow(t)
+	email := f.email()
+	f.seedUser(email, false)
+
+	code, state := f.start(url.Values{"login_hint": {email}})
+	status, resp := f.callback(code, state)
+	if status != http.StatusConflict || resp.Code != "account_email_unverified" {
+		t.Fatalf("callback returned %d %q, want 409 account_email_unverified", status, resp.Code)
+	}
+	if f.identityUser(email) != "" {
+		t.Error("identity was linked to an account with an unverified email")
+	}
+}
+
+func TestOIDCCallbackRejectsUnverifiedProviderEmail(t *testing.T) {
+	f := newOIDCFlow(t)
+	email := f.email()
+	f.seedUser(email, true)
+
+	code, state := f.start(url.Values{"login_hint": {email}, "email_verified": {"false"}})
+	status, resp := f.callback(code, state)
+	if status != http.StatusForbidden || resp.Code != "identity_email_unverified" {
+		t.Fatalf("callback returned %d %q, want 403 identity_email_unverified", status, resp.Code)
+	}
+	if f.identityUser(email) != "" {
+		t.Error("identity with an unverified email was linked")
+	}
+}
+
+func TestOIDCCallbackRejectsVerifierMismatch(t *testing.T) {
+	f := newOIDCFlow(t)
+	email := f.email()
+
+	code, state := f.start(url.Values{"login_hint": {email}})
+	// Подменяем code_verifier начатого входа: издатель должен отклонить обмен code
+	_, err := f.db.Exec(`UPDATE oidc_login_states SET code_verifier = 'tampered' WHERE state_hash = $1`, tokens.Hash(state))
+	if err != nil {
+		t.Fatalf("tamper state: %v", err)
+	}
+
+	status, resp := f.callback(code, state)
+	if status != http.StatusBadGateway {
+		t.Fatalf("callback returned %d: %+v, want 502", status, resp)
+	}
+	if f.identityUser(email) != "" {
+		t.Error("identity was saved after a failed PKCE check")
+	}
+}
+
+func TestOIDCCallbackRejectsReusedState(t *testing.T) {
+	f := newOIDCFlow(t)
+	email := f.email()
+
+	code, state := f.start(url.Values{"login_hint": {email}})
+	if status, resp := f.callback(code, state); status != http.StatusOK {
+		t.Fatalf("callback returned %d: %+v", status, resp)
+	}
+	if status, _ := f.callback(code, state); status != http.StatusBadRequest {
+		t.Errorf("reused state returned %d, want 400", status)
+	}
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/mailer"
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/password"
+	"github.com/mindly/api/internal/profile"
+	"github.com/mindly/api/internal/session"
+	"github.com/mindly/api/internal/tokens"
+)
+
+// Сколько ждать выпуска токена и отправки письма сброса после ответа клиенту
+const resetSendTimeout = 30 * time.Second
+
+type PasswordHandler struct {
+	passwordRepo *database.PasswordRepository
+	userRepo     *database.UserRepository
+	mailer       mailer.Mailer
+	cfg          password.ResetConfig
+	policy       password.Policy
+	hasher       *password.Hasher
+}
+
+func NewPasswordHandler(db *sql.DB, m mailer.Mailer, cfg password.ResetConfig, policy password.Policy, hasher *password.Hasher) *PasswordHandler {
+	return &PasswordHandler{
+		passwordRepo: database.NewPasswordRepository(db),
+		userRepo:     database.NewUserRepository(db),
+		mailer:       m,
+		cfg:          cfg,
+		policy:       policy,
+		hasher:       hasher,
+	}
+}
+
+// Forgot отправляет ссылку сброса пароля: {"email": "..."}.
+// Ответ одинаковый, есть такой пользователь или нет
+func (h *PasswordHandler) Forgot(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	email := profile.NormalizeEmail(req.Email)
+	if !validEmail(email) {
+		sendJSONError(w, "invalid email format", http.StatusBadRequest)
+		return
+	}
+
+	// Поиск пользователя и отправка идут после ответа: время ответа тоже не выдает, есть ли аккаунт
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), resetSendTimeout)
+		defer cancel()
+		h.sendReset(ctx, email, time.Now())
+	}()
+
+	sendJSONSuccess(w, "If an account with this email exists, a reset link has been sent", nil, http.StatusOK)
+}
+
+func (h *PasswordHandler) sendReset(ctx context.Context, email string, now time.Time) {
+	userID, username, lastSentAt, sentToday, err := h.passwordRepo.ResetRecipient(ctx, email, now)
+	if errors.Is(err, database.ErrNotFound) {
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to look up password reset recipient: %v", err)
+		return
+	}
+	if !password.ResetAllowed(h.cfg, lastSentAt, sentToday, now) {
+		log.Printf("⚠️ Сброс пароля для user=%s пропущен: слишком много запросов", userID)
+		return
+	}
+
+	token, hash, err := tokens.Generate()
+	if err != nil {
+		log.Printf("❌ Failed to generate reset token: %v", err)
+		return
+	}
+	if err := h.passwordRepo.CreateResetToken(ctx, userID, hash, now.Add(h.cfg.TokenTTL), now); err != nil {
+		log.Printf("❌ Failed to save reset token for user %s: %v", userID, err)
+		return
+	}
+	if err := h.mailer.Send(ctx, password.ResetEmail(h.cfg, email, username, token)); err != nil {
+		log.Printf("❌ Failed to send reset email to user %s: %v", userID, err)
+	}
+}
+
+// Reset задает новый пароль по токену из письма: {"token": "...", "new_password": "..."}.
+// Все сессии пользователя закрываются
+func (h *PasswordHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	req.Token = strings.TrimSpace(req.Token)
+	if req.Token == "" {
+		sendJSONError(w, "token is required", http.StatusBadRequest)
+		return
+	}
+	tokenHash := tokens.Hash(req.Token)
+
+	username, email, err := h.passwordRepo.ResetTarget(ctx, tokenHash, time.Now())
+	if err == nil {
+		err = h.policy.Check(ctx, req.NewPassword, password.Identity{Username: username, Email: email})
+		if err != nil {
+			sendPasswordPolicyError(w, err)
+			return
+		}
+	}
+
+	var userID string
+	if err == nil {
+		var newHash string
+		newHash, err = h.hasher.Hash(req.NewPassword)
+		if err != nil {
+			log.Printf("❌ Password hashing error: %v", err)
+			sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		userID, err = h.passwordRepo.Reset(ctx, tokenHash, newHash, time.Now())
+	}
+	if errors.Is(err, database.ErrTokenInvalid) {
+		sendJSONError(w, "Reset link is invalid or already used", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, database.ErrTokenExpired) {
+		sendJSONError(w, "Reset link has expired, request a new one", http.StatusGone)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to reset password: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("🔐 Пароль сброшен: user=%s, все сессии закрыты", userID)
+	sendJSONSuccess(w, "Password has been reset, please log in again", nil, http.StatusOK)
+}
+
+// Change меняет пароль текущего пользователя:
+// {"current_password": "...", "new_password": "..."}. Остальные сессии закрываются
+func (h *PasswordHandler) Change(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	user, err := h.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	ok, _, err := h.hasher.Verify(user.PasswordHash, req.CurrentPassword)
+	if err != nil {
+		log.Printf("❌ Failed to verify password of user %s: %v", userID, err)
+	}
+	if !ok {
+		sendJSONError(w, "Current password is incorrect", http.StatusForbidden)
+		return
+	}
+	identity := password.Identity{Username: user.Username, Email: user.Email}
+	if err := h.policy.Check(ctx, req.NewPassword, identity); err != nil {
+		sendPasswordPolicyError(w, err)
+		return
+	}
+
+	newHash, err := h.hasher.Hash(req.NewPassword)
+	if err != nil {
+		log.Printf("❌ Password hashing error: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// Текущая сессия остается, если запрос пришел с токеном
+	var keepSessionID string
+	if s, ok := session.FromContext(ctx); ok {
+		keepSessionID = s.ID
+	}
+
+	revoked, err := h.passwordRepo.Change(ctx, userID, newHash, keepSessionID, time.Now())
+	if err != nil {
+		log.Printf("❌ Failed to change password for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("🔐 Пароль изменен: user=%s, закрыто сессий: %d", userID, revoked)
+	sendJSONSuccess(w, "Password changed", map[string]int64{"revoked_sessions": revoked}, http.StatusOK)
+}
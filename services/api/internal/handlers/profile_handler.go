@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/profile"
+	"github.com/mindly/api/internal/storage"
+	"github.com/mindly/api/internal/tokens"
+)
+
+// ProfileHandler - профиль текущего пользователя: имя, username и аватар
+type ProfileHandler struct {
+	userRepo    *database.UserRepository
+	profileRepo *database.ProfileRepository
+	store       storage.Storage
+	cfg         profile.Config
+}
+
+func NewProfileHandler(db *sql.DB, store storage.Storage, cfg profile.Config) *ProfileHandler {
+	return &ProfileHandler{
+		userRepo:    database.NewUserRepository(db),
+		profileRepo: database.NewProfileRepository(db),
+		store:       store,
+		cfg:         cfg,
+	}
+}
+
+// Get возвращает профиль текущего пользователя
+func (h *ProfileHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.userRepo.GetByID(r.Context(), userID)
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Profile", h.profile(user, time.Now()), http.StatusOK)
+}
+
+// Update меняет переданные поля: {"username": "...", "full_name": "..." | null}
+func (h *ProfileHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var upd models.ProfileUpdate
+	if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if upd.Username == nil && !upd.FullName.Set {
+		sendJSONError(w, "Nothing to update", http.StatusBadRequest)
+		return
+	}
+
+	if upd.Username != nil {
+		username := profile.NormalizeUsername(*upd.Username)
+		if err := profile.ValidateUsername(username); err != nil {
+			sendJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		upd.Username = &username
+	}
+	if upd.FullName.Value != nil {
+		name := strings.TrimSpace(*upd.FullName.Value)
+		if err := profile.ValidateFullName(h.cfg, name); err != nil {
+			sendJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// Пустое имя - то же, что null
+		upd.FullName.Value = &name
+		if name == "" {
+			upd.FullName.Value = nil
+		}
+	}
+
+	now := time.Now()
+	user, err := h.profileRepo.Update(r.Context(), userID, upd, h.cfg.UsernameCooldown, h.cfg.ReservationPeriod, now)
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if sendUserConflict(w, err) {
+		return
+	}
+	if errors.Is(err, database.ErrUsernameCooldown) {
+		sendJSONErrorCode(w, fmt.Sprintf("Username can be changed once every %d days", int(h.cfg.UsernameCooldown.Hours()/24)),
+			"username_cooldown", http.StatusTooManyRequests)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to update profile of user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("👤 Профиль обновлен: user=%s", userID)
+	sendJSONSuccess(w, "Profile updated", h.profile(user, now), http.StatusOK)
+}
+
+// UploadAvatar загружает аватар: multipart/form-data с файлом в поле avatar (JPEG, PNG или WebP)
+func (h *ProfileHandler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	// Запас на заголовки multipart сверх размера самого файла
+	r.Body = http.MaxBytesReader(w, r.Body, h.cfg.AvatarMaxBytes+64<<10)
+	file, _, err := r.FormFile("avatar")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			sendJSONError(w, fmt.Sprintf("Avatar must be at most %d KB", h.cfg.AvatarMaxBytes>>10), http.StatusRequestEntityTooLarge)
+			return
+		}
+		sendJSONError(w, "avatar file is required (multipart/form-data)", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, h.cfg.AvatarMaxBytes+1))
+	if err != nil {
+		sendJSONError(w, "Failed to read avatar", http.StatusBadRequest)
+		return
+	}
+	if int64(len(data)) > h.cfg.AvatarMaxBytes {
+		sendJSONError(w, fmt.Sprintf("Avatar must be at most %d KB", h.cfg.AvatarMaxBytes>>10), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := profile.AvatarExtension(contentType)
+	if !ok {
+		sendJSONError(w, "Avatar must be a JPEG, PNG or WebP image", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// Случайное имя файла: новый аватар не берется из кэша по старому адресу
+	name, _, err := tokens.Generate()
+	if err != nil {
+		log.Printf("❌ Failed to generate avatar name: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	key := fmt.Sprintf("avatars/%s/%s.%s", userID, name[:16], ext)
+	if err := h.store.Put(ctx, key, contentType, bytes.NewReader(data)); err != nil {
+		log.Printf("❌ Failed to store avatar of user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	url := h.store.URL(key)
+	oldKey, err := h.profileRepo.SetAvatar(ctx, userID, &url, &key, time.Now())
+	if err != nil {
+		h.deleteFile(key)
+		if errors.Is(err, database.ErrNotFound) {
+			sendJSONError(w, "User not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("❌ Failed to save avatar of user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	h.deleteFile(oldKey)
+
+	sendJSONSuccess(w, "Avatar updated", map[string]string{"avatar_url": url}, http.StatusOK)
+}
+
+// DeleteAvatar удаляет аватар
+func (h *ProfileHandler) DeleteAvatar(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	oldKey, err := h.profileRepo.SetAvatar(r.Context(), userID, nil, nil, time.Now())
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to remove avatar of user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	h.deleteFile(oldKey)
+
+	sendJSONSuccess(w, "Avatar removed", nil, http.StatusOK)
+}
+
+// deleteFile удаляет файл из хранилища; ошибка только логируется
+func (h *ProfileHandler) deleteFile(key string) {
+	if key == "" {
+		return
+	}
+	if err := h.store.Delete(context.Background(), key); err != nil {
+		log.Printf("⚠️ Failed to delete stored file %s: %v", key, err)
+	}
+}
+
+func (h *ProfileHandler) profile(user models.User, now time.Time) models.Profile {
+	return models.Profile{
+		User:                 user,
+		NextUsernameChangeAt: profile.NextUsernameChange(h.cfg, user.UsernameChangedAt, now),
+	}
+}
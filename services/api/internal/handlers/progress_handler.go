@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mindly/api/internal/achievements"
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/leaderboard"
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/progression"
+)
+
+type ProgressHandler struct {
+	progressRepo *database.ProgressRepository
+	lb           *leaderboard.Service
+	engine       *achievements.Engine
+}
+
+func NewProgressHandler(db *sql.DB, lb *leaderboard.Service) *ProgressHandler {
+	achievementRepo := database.NewAchievementRepository(db)
+	return &ProgressHandler{
+		progressRepo: database.NewProgressRepository(db),
+		lb:           lb,
+		engine:       achievements.NewEngine(achievementRepo, achievementRepo),
+	}
+}
+
+// GetToday возвращает прогресс дневной цели, текущую серию и уровень
+func (h *ProgressHandler) GetToday(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	today, err := h.progressRepo.Today(r.Context(), userID, time.Now())
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load today progress for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Today progress loaded", today, http.StatusOK)
+}
+
+// UpdateGoal задает дневную цель: {"type": "videos"|"correct_answers", "target": N}
+func (h *ProgressHandler) UpdateGoal(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var goal models.DailyGoal
+	if err := json.NewDecoder(r.Body).Decode(&goal); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if err := progression.ValidateGoal(goal); err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	update, err := h.progressRepo.SetGoal(ctx, userID, goal, time.Now())
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to update goal for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	publishStreak(ctx, h.lb, userID, update)
+
+	today, err := h.progressRepo.Today(ctx, userID, time.Now())
+	if err != nil {
+		log.Printf("❌ Failed to load today progress for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("🎯 Дневная цель: user=%s %d %s", userID, goal.Target, goal.Type)
+	sendJSONSuccess(w, "Goal updated", today, http.StatusOK)
+}
+
+// MarkWatched отмечает видео досмотренным и засчитывает его в дневную цель
+func (h *ProgressHandler) MarkWatched(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	videoID := r.PathValue("id")
+	if !isUUID(videoID) {
+		sendJSONError(w, "Invalid video id", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.progressRepo.MarkWatched(ctx, userID, videoID, time.Now())
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "Video not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to mark video %s watched: %v", videoID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	publishStreak(ctx, h.lb, userID, result.GoalUpdate)
+	if result.FirstWatch {
+		result.Achievements = evaluateAchievements(ctx, h.engine, h.lb, userID)
+	}
+
+	sendJSONSuccess(w, "Video marked as watched", result, http.StatusOK)
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/mindly/api/internal/achievements"
+	"github.com/mindly/api/internal/calibration"
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/leaderboard"
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/quiz"
+)
+
+// Ограничение на размер теста к одному видео
+const maxQuestionsPerVideo = 20
+
+type QuizHandler struct {
+	quizRepo   *database.QuizRepository
+	authorRepo *database.AuthorRepository
+	lb         *leaderboard.Service
+	engine     *achievements.Engine
+	cfg        quiz.Config
+}
+
+func NewQuizHandler(db *sql.DB, lb *leaderboard.Service, cfg quiz.Config) *QuizHandler {
+	achievementRepo := database.NewAchievementRepository(db)
+	return &QuizHandler{
+		quizRepo:   database.NewQuizRepository(db),
+		authorRepo: database.NewAuthorRepository(db),
+		lb:         lb,
+		engine:     achievements.NewEngine(achievementRepo, achievementRepo),
+		cfg:        cfg,
+	}
+}
+
+// GetQuiz возвращает вопросы к видео без правильных ответов
+func (h *QuizHandler) GetQuiz(w http.ResponseWriter, r *http.Request) {
+	videoID := r.PathValue("id")
+	if !isUUID(videoID) {
+		sendJSONError(w, "Invalid video id", http.StatusBadRequest)
+		return
+	}
+
+	questions, err := h.quizRepo.GetQuestions(r.Context(), videoID)
+	if err != nil {
+		log.Printf("❌ Failed to load quiz for video %s: %v", videoID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Quiz loaded", questions, http.StatusOK)
+}
+
+// SaveQuiz сохраняет тест к видео целиком; доступно только автору видео.
+// Существующие вопросы передаются со своим id, иначе они удаляются вместе с ответами
+func (h *QuizHandler) SaveQuiz(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	videoID := r.PathValue("id")
+	if !isUUID(videoID) {
+		sendJSONError(w, "Invalid video id", http.StatusBadRequest)
+		return
+	}
+
+	authorUserID, err := h.quizRepo.VideoAuthorUserID(ctx, videoID)
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "Video not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load video author: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if authorUserID != userID {
+		sendJSONError(w, "Only the video author can edit its quiz", http.StatusForbidden)
+		return
+	}
+
+	var req models.SaveQuizRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if len(req.Questions) > maxQuestionsPerVideo {
+		sendJSONError(w, fmt.Sprintf("quiz can have at most %d questions", maxQuestionsPerVideo), http.StatusBadRequest)
+		return
+	}
+
+	questions := make([]models.QuizQuestion, 0, len(req.Questions))
+	for i, in := range req.Questions {
+		if in.ID != "" && !isUUID(in.ID) {
+			sendJSONError(w, fmt.Sprintf("question %d: invalid id", i+1), http.StatusBadRequest)
+			return
+		}
+		q := models.QuizQuestion{
+			ID:            strings.ToLower(in.ID),
+			Type:          in.Type,
+			Question:      in.Question,
+			Options:       in.Options,
+			Answer:        in.Answer,
+			Explanation:   in.Explanation,
+			PointsAwarded: h.cfg.DefaultPoints,
+		}
+		if in.PointsAwarded != nil {
+			q.PointsAwarded = *in.PointsAwarded
+		}
+		// Новый вопрос еще не откалиброван: баллы равны заданным автором
+		q.BasePoints = q.PointsAwarded
+		if err := quiz.Validate(q, h.cfg); err != nil {
+			sendJSONError(w, fmt.Sprintf("question %d: %v", i+1, err), http.StatusBadRequest)
+			return
+		}
+		questions = append(questions, q)
+	}
+
+	saved, err := h.quizRepo.SaveQuestions(ctx, videoID, questions)
+	if errors.Is(err, database.ErrUnknownQuestion) {
+		sendJSONError(w, "Quiz contains a question that does not belong to this video", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, database.ErrAnswerKeyChanged) {
+		sendJSONError(w, "To change the type or answer of a question, add it as a new question without id", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to save quiz for video %s: %v", videoID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("📝 Тест к видео %s сохранен: %d вопросов", videoID, len(saved))
+	sendJSONSuccess(w, "Quiz saved", saved, http.StatusOK)
+}
+
+// SubmitAnswer проверяет ответ на вопрос и возвращает результат с пояснением
+func (h *QuizHandler) SubmitAnswer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	videoID := r.PathValue("id")
+	if !isUUID(videoID) {
+		sendJSONError(w, "Invalid video id", http.StatusBadRequest)
+		return
+	}
+
+	var req models.QuizAnswerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if !isUUID(req.QuestionID) {
+		sendJSONError(w, "Invalid question id", http.StatusBadRequest)
+		return
+	}
+
+	q, err := h.quizRepo.GetQuestion(ctx, videoID, req.QuestionID)
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "Question not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load question %s: %v", req.QuestionID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	correct, err := quiz.Grade(q, req.Answer)
+	if errors.Is(err, quiz.ErrInvalidAnswer) {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to grade question %s: %v", q.ID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	result, err := h.quizRepo.RecordAnswer(ctx, userID, q, req.Answer, correct)
+	if err != nil {
+		log.Printf("❌ Failed to record answer: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	publishPoints(ctx, h.lb, userID, result.PointsEarned, result.AnsweredAt)
+	publishStreak(ctx, h.lb, userID, result.GoalUpdate)
+	result.Achievements = evaluateAchievements(ctx, h.engine, h.lb, userID)
+
+	log.Printf("🧠 Ответ: user=%s question=%s correct=%v points=%d", userID, q.ID, correct, result.PointsEarned)
+	sendJSONSuccess(w, "Answer checked", result, http.StatusOK)
+}
+
+// GetAuthorQuizStats показывает автору калибровку его вопросов:
+// какие слишком легкие, слишком сложные или двусмысленные
+func (h *QuizHandler) GetAuthorQuizStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	authorID := r.PathValue("id")
+	if !isUUID(authorID) {
+		sendJSONError(w, "Invalid author id", http.StatusBadRequest)
+		return
+	}
+
+	authorUserID, err := h.authorRepo.GetUserID(ctx, authorID)
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "Author not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load author %s: %v", authorID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if authorUserID != userID {
+		sendJSONError(w, "Only the author can view these statistics", http.StatusForbidden)
+		return
+	}
+
+	stats, err := h.quizRepo.AuthorQuestionStats(ctx, authorID)
+	if err != nil {
+		log.Printf("❌ Failed to load quiz stats for author %s: %v", authorID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	cfg := calibration.DefaultConfig()
+	for i := range stats {
+		stats[i].Flags = calibration.Flags(cfg, stats[i].QuestionCalibration)
+	}
+
+	sendJSONSuccess(w, "Quiz statistics loaded", stats, http.StatusOK)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/moderation"
+)
+
+type ReportHandler struct {
+	reportRepo *database.ReportRepository
+	cfg        moderation.ReportConfig
+}
+
+func NewReportHandler(db *sql.DB, cfg moderation.ReportConfig) *ReportHandler {
+	return &ReportHandler{reportRepo: database.NewReportRepository(db), cfg: cfg}
+}
+
+// Create принимает жалобу:
+// {"target_type": "video"|"comment", "target_id": "...", "reason": "misinformation"|"spam"|"offensive"|"copyright", "details": "..."}
+func (h *ReportHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	req.Details = strings.TrimSpace(req.Details)
+
+	if req.TargetType != models.ReportTargetVideo && req.TargetType != models.ReportTargetComment {
+		sendJSONError(w, "target_type must be video or comment", http.StatusBadRequest)
+		return
+	}
+	if !isUUID(req.TargetID) {
+		sendJSONError(w, "Invalid target id", http.StatusBadRequest)
+		return
+	}
+	if !models.ValidReportReason(req.Reason) {
+		sendJSONError(w, "reason must be one of: misinformation, spam, offensive, copyright", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(req.Details) > h.cfg.MaxDetailsLength {
+		sendJSONError(w, "details are too long", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.reportRepo.Create(r.Context(), userID, req, h.cfg.AutoHideThreshold, time.Now())
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "Reported content not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to report %s %s: %v", req.TargetType, req.TargetID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if result.Duplicate {
+		sendJSONSuccess(w, "Already reported", result, http.StatusOK)
+		return
+	}
+	if result.Hidden {
+		log.Printf("🚩 %s %s скрыт до решения модератора после жалоб", req.TargetType, req.TargetID)
+	}
+	sendJSONSuccess(w, "Report submitted", result, http.StatusCreated)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+
+	"github.com/mindly/api/internal/session"
+)
+
+var (
+	errNoUser = errors.New("not logged in")
+
+	uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// requestUserID возвращает ID пользователя, от имени которого выполняется запрос:
+// только из сессии (Authorization: Bearer, см. session.Middleware). Заголовок
+// X-User-ID и параметр user_id больше не принимаются - по ним любой клиент мог
+// действовать от имени любого пользователя
+func requestUserID(r *http.Request) (string, error) {
+	if s, ok := session.FromContext(r.Context()); ok {
+		return s.UserID, nil
+	}
+	return "", errNoUser
+}
+
+// viewerID - пользователь для персонализации ленты; без него лента общая
+func viewerID(r *http.Request) string {
+	userID, err := requestUserID(r)
+	if err != nil {
+		return ""
+	}
+	return userID
+}
+
+func isUUID(s string) bool {
+	return uuidPattern.MatchString(s)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/password"
+)
+
+// sendJSONSuccess отправляет успешный ответ в формате models.APIResponse
+func sendJSONSuccess(w http.ResponseWriter, message string, data any, statusCode int) {
+	response := models.APIResponse{
+		Status:  "success",
+		Message: message,
+		Data:    data,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("❌ Error encoding response: %v", err)
+	}
+}
+
+// sendPasswordPolicyError отвечает 400 с кодом нарушенного правила политики паролей;
+// сбой самой проверки - 500
+func sendPasswordPolicyError(w http.ResponseWriter, err error) {
+	pe, ok := password.IsPolicyError(err)
+	if !ok {
+		log.Printf("❌ Password policy check failed: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("❌ Пароль не прошел политику: %s", pe.Code)
+	sendJSONErrorCode(w, pe.Message, pe.Code, http.StatusBadRequest)
+}
+
+// sendJSONErrorCode - как sendJSONError, но с машиночитаемым кодом ошибки
+func sendJSONErrorCode(w http.ResponseWriter, message, code string, statusCode int) {
+	response := models.APIResponse{Status: "error", Error: message, Code: code}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("❌ Error encoding error response: %v", err)
+	}
+}
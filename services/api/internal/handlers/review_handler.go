@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mindly/api/internal/achievements"
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/leaderboard"
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/quiz"
+	"github.com/mindly/api/internal/srs"
+)
+
+type ReviewHandler struct {
+	reviewRepo *database.ReviewRepository
+	quizRepo   *database.QuizRepository
+	lb         *leaderboard.Service
+	engine     *achievements.Engine
+}
+
+func NewReviewHandler(db *sql.DB, lb *leaderboard.Service) *ReviewHandler {
+	achievementRepo := database.NewAchievementRepository(db)
+	return &ReviewHandler{
+		reviewRepo: database.NewReviewRepository(db),
+		quizRepo:   database.NewQuizRepository(db),
+		lb:         lb,
+		engine:     achievements.NewEngine(achievementRepo, achievementRepo),
+	}
+}
+
+// GetDue возвращает видео и вопросы, которые пора повторить
+func (h *ReviewHandler) GetDue(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	items, err := h.reviewRepo.DueReviews(ctx, userID, time.Now(), limit)
+	if err != nil {
+		log.Printf("❌ Failed to load reviews for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	for i := range items {
+		questions, err := h.quizRepo.GetQuestions(ctx, items[i].ID)
+		if err != nil {
+			log.Printf("❌ Failed to load quiz for video %s: %v", items[i].ID, err)
+			sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		items[i].Questions = questions
+	}
+
+	sendJSONSuccess(w, "Reviews loaded", items, http.StatusOK)
+}
+
+// SubmitReview проверяет ответы на весь тест к видео и переносит следующее повторение.
+// Повторение принимается только в день, на который оно назначено, или позже
+func (h *ReviewHandler) SubmitReview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	videoID := r.PathValue("video_id")
+	if !isUUID(videoID) {
+		sendJSONError(w, "Invalid video id", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ReviewSubmitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	state, err := h.reviewRepo.GetState(ctx, userID, videoID)
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "Video is not scheduled for review", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load review state: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	if now.Before(state.DueDate) {
+		sendJSONError(w, "Review is not due until "+state.DueDate.Format("2006-01-02"), http.StatusConflict)
+		return
+	}
+
+	questions, err := h.quizRepo.GetQuestions(ctx, videoID)
+	if err != nil {
+		log.Printf("❌ Failed to load quiz for video %s: %v", videoID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	answers := make(map[string]json.RawMessage, len(req.Answers))
+	for _, a := range req.Answers {
+		answers[a.QuestionID] = a.Answer
+	}
+
+	// Сначала проверяются все ответы: ошибка формата в любом из них отклоняет
+	// повторение целиком, до записи ответов и баллов
+	graded := make([]models.GradedAnswer, 0, len(questions))
+	correctCount := 0
+	for _, q := range questions {
+		// Вопрос без ответа считается отвеченным неправильно
+		answer, ok := answers[q.ID]
+		if !ok {
+			continue
+		}
+
+		correct, err := quiz.Grade(q, answer)
+		if errors.Is(err, quiz.ErrInvalidAnswer) {
+			sendJSONError(w, "question "+q.ID+": "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to grade question %s: %v", q.ID, err)
+			sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if correct {
+			correctCount++
+		}
+		graded = append(graded, models.GradedAnswer{Question: q, Answer: answer, Correct: correct})
+	}
+
+	// Следующее повторение и ответы сохраняются одной транзакцией: повторная отправка
+	// того же повторения (в том числе параллельная) отклоняется и не засчитывается
+	// в цель дня, а после сбоя повторение можно отправить снова
+	result := models.ReviewResult{}
+	result.Quality = srs.QualityFromScore(correctCount, len(questions))
+	next := srs.Review(state, result.Quality, now)
+	result.Results, err = h.reviewRepo.SaveReview(ctx, userID, videoID, state, next, result.Quality, graded)
+	if errors.Is(err, database.ErrReviewNotDue) {
+		sendJSONError(w, "Review has already been submitted", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to save review: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	state = next
+
+	// Живые таблицы лидеров обновляются только после фиксации транзакции
+	for _, answerResult := range result.Results {
+		publishPoints(ctx, h.lb, userID, answerResult.PointsEarned, answerResult.AnsweredAt)
+		publishStreak(ctx, h.lb, userID, answerResult.GoalUpdate)
+	}
+
+	result.NextDueDate = state.DueDate
+	result.IntervalDays = state.IntervalDays
+	result.Achievements = evaluateAchievements(ctx, h.engine, h.lb, userID)
+
+	log.Printf("🔁 Повторение: user=%s video=%s quality=%d next=%s",
+		userID, videoID, result.Quality, state.DueDate.Format("2006-01-02"))
+	sendJSONSuccess(w, "Review recorded", result, http.StatusOK)
+}
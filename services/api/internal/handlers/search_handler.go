@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/models"
+)
+
+const maxSearchQueryLength = 200
+
+type SearchHandler struct {
+	searchRepo *database.SearchRepository
+	topicRepo  *database.TopicRepository
+}
+
+func NewSearchHandler(db *sql.DB) *SearchHandler {
+	return &SearchHandler{
+		searchRepo: database.NewSearchRepository(db),
+		topicRepo:  database.NewTopicRepository(db),
+	}
+}
+
+// Search ищет видео: ?q=текст&tags=go,backend&author_id=&expertise_area=
+// &min_duration=&max_duration=&verified=true&limit=&offset=
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	params := models.SearchParams{
+		Query:         strings.TrimSpace(query.Get("q")),
+		Tags:          parseTags(query["tags"]),
+		AuthorID:      strings.TrimSpace(query.Get("author_id")),
+		ExpertiseArea: strings.TrimSpace(query.Get("expertise_area")),
+		VerifiedOnly:  query.Get("verified") == "true",
+	}
+
+	if utf8.RuneCountInString(params.Query) > maxSearchQueryLength {
+		sendJSONError(w, "Search query is too long", http.StatusBadRequest)
+		return
+	}
+	if params.AuthorID != "" && !isUUID(params.AuthorID) {
+		sendJSONError(w, "Invalid author id", http.StatusBadRequest)
+		return
+	}
+
+	var ok bool
+	if params.MinDuration, ok = optionalInt(query.Get("min_duration")); !ok {
+		sendJSONError(w, "min_duration must be a non-negative number of seconds", http.StatusBadRequest)
+		return
+	}
+	if params.MaxDuration, ok = optionalInt(query.Get("max_duration")); !ok {
+		sendJSONError(w, "max_duration must be a non-negative number of seconds", http.StatusBadRequest)
+		return
+	}
+
+	params.Limit, _ = optionalInt(query.Get("limit"))
+	if params.Limit <= 0 {
+		params.Limit = 20
+	}
+	if params.Limit > 50 {
+		params.Limit = 50
+	}
+	params.Offset, _ = optionalInt(query.Get("offset"))
+
+	// Тег ищется во всех написаниях: ?tags=golang находит и видео с тегом "go"
+	if len(params.Tags) > 0 {
+		spellings, err := tagSpellings(r.Context(), h.topicRepo, params.Tags)
+		if err != nil {
+			log.Printf("❌ Failed to resolve tags %v: %v", params.Tags, err)
+			sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		params.TagSpellings = spellings
+	}
+
+	page, err := h.searchRepo.Search(r.Context(), params)
+	if err != nil {
+		log.Printf("❌ Search failed for %q: %v", params.Query, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Search completed", page, http.StatusOK)
+}
+
+// SuggestTags подсказывает теги при вводе: ?prefix=go&limit=10
+func (h *SearchHandler) SuggestTags(w http.ResponseWriter, r *http.Request) {
+	prefix := strings.TrimSpace(r.URL.Query().Get("prefix"))
+	if prefix == "" {
+		sendJSONError(w, "prefix is required", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(prefix) > maxSearchQueryLength {
+		sendJSONError(w, "prefix is too long", http.StatusBadRequest)
+		return
+	}
+
+	limit, _ := optionalInt(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 20 {
+		limit = 10
+	}
+
+	suggestions, err := h.searchRepo.SuggestTags(r.Context(), prefix, limit)
+	if err != nil {
+		log.Printf("❌ Tag suggestions failed for %q: %v", prefix, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Tags suggested", suggestions, http.StatusOK)
+}
+
+// parseTags собирает теги из ?tags=a,b и повторяющихся ?tags=a&tags=b
+func parseTags(values []string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "" || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// optionalInt разбирает необязательный неотрицательный параметр; пустая строка - 0
+func optionalInt(value string) (int, bool) {
+	if value == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,415 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/password"
+	"github.com/mindly/api/internal/profile"
+	"github.com/mindly/api/internal/session"
+	"github.com/mindly/api/internal/tokens"
+	"github.com/mindly/api/internal/totp"
+)
+
+// Длина device_name в таблице sessions
+const maxDeviceNameLength = 100
+
+type SessionHandler struct {
+	userRepo      *database.UserRepository
+	sessionRepo   *database.SessionRepository
+	twoFactorRepo *database.TwoFactorRepository
+	cfg           session.Config
+	totpCfg       totp.Config
+	hasher        *password.Hasher
+	// Хэш, который проверяется для несуществующего логина, чтобы время ответа
+	// не выдавало, есть ли такой пользователь
+	dummyHash string
+}
+
+func NewSessionHandler(db *sql.DB, cfg session.Config, totpCfg totp.Config, hasher *password.Hasher) *SessionHandler {
+	dummyHash, err := hasher.Hash("mindly-dummy-password")
+	if err != nil {
+		log.Printf("⚠️ Failed to prepare dummy password hash: %v", err)
+	}
+	return &SessionHandler{
+		userRepo:      database.NewUserRepository(db),
+		sessionRepo:   database.NewSessionRepository(db),
+		twoFactorRepo: database.NewTwoFactorRepository(db),
+		cfg:           cfg,
+		totpCfg:       totpCfg,
+		hasher:        hasher,
+		dummyHash:     dummyHash,
+	}
+}
+
+// Login открывает сессию: {"login": "email или username", "password": "..."}.
+// Если у пользователя включена 2FA, вместо сессии выдается challenge_token для LoginTwoFactor
+func (h *SessionHandler) Login(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req models.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	req.Login = profile.NormalizeUsername(req.Login)
+	if req.Login == "" || req.Password == "" {
+		sendJSONError(w, "login and password are required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userRepo.FindByLogin(ctx, req.Login)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		log.Printf("❌ Failed to load user for login: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	// Одинаковый ответ для неизвестного пользователя и неверного пароля
+	if errors.Is(err, database.ErrNotFound) {
+		h.hasher.Verify(h.dummyHash, req.Password)
+		sendJSONError(w, "Invalid login or password", http.StatusUnauthorized)
+		return
+	}
+	ok, needsRehash, err := h.hasher.Verify(user.PasswordHash, req.Password)
+	if err != nil {
+		log.Printf("❌ Failed to verify password of user %s: %v", user.ID, err)
+	}
+	if !ok {
+		sendJSONError(w, "Invalid login or password", http.StatusUnauthorized)
+		return
+	}
+	if needsRehash {
+		h.upgradeHash(ctx, user, req.Password)
+	}
+
+	h.completeLogin(w, r, user, req.DeviceInfo)
+}
+
+// completeLogin завершает вход с проверенным первым фактором (пароль или внешний провайдер):
+// при включенной 2FA выдает challenge_token, иначе открывает сессию. Если роль требует 2FA,
+// а она не включена, сессия годится только для включения 2FA
+func (h *SessionHandler) completeLogin(w http.ResponseWriter, r *http.Request, user models.User, device models.DeviceInfo) {
+	twoFactor, err := h.twoFactorRepo.Enabled(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("❌ Failed to check 2FA for user %s: %v", user.ID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if twoFactor {
+		h.startChallenge(w, r, user)
+		return
+	}
+
+	unmet, err := h.twoFactorRepo.RequirementUnmet(r.Context(), user.ID, user.Role)
+	if err != nil {
+		log.Printf("❌ Failed to check 2FA requirement for user %s: %v", user.ID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	h.startSession(w, r, user, device, unmet)
+}
+
+// LoginTwoFactor - второй шаг входа:
+// {"challenge_token": "...", "code": "код из приложения или резервный код"}
+func (h *SessionHandler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req models.LoginTwoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	req.ChallengeToken = strings.TrimSpace(req.ChallengeToken)
+	if req.ChallengeToken == "" || strings.TrimSpace(req.Code) == "" {
+		sendJSONError(w, "challenge_token and code are required", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	challengeHash := tokens.Hash(req.ChallengeToken)
+	userID, err := h.twoFactorRepo.ChallengeAttempt(ctx, challengeHash, h.totpCfg.MaxAttempts, now)
+	if errors.Is(err, database.ErrTokenInvalid) {
+		sendJSONError(w, "Login attempt is invalid or has too many tries, log in again", http.StatusUnauthorized)
+		return
+	}
+	if errors.Is(err, database.ErrTokenExpired) {
+		sendJSONError(w, "Login attempt has expired, log in again", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load login challenge: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	ok, err := verifySecondFactor(ctx, h.twoFactorRepo, h.totpCfg, userID, req.Code, now)
+	if err != nil {
+		log.Printf("❌ Failed to verify second factor for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		sendJSONError(w, "Invalid two-factor code", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.twoFactorRepo.CompleteChallenge(ctx, challengeHash, now); err != nil {
+		sendJSONError(w, "Login attempt is invalid, log in again", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		log.Printf("❌ Failed to load user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.startSession(w, r, user, req.DeviceInfo, false)
+}
+
+// startChallenge запоминает вход с верным паролем и ждет второй фактор
+func (h *SessionHandler) startChallenge(w http.ResponseWriter, r *http.Request, user models.User) {
+	token, hash, err := tokens.Generate()
+	if err != nil {
+		log.Printf("❌ Failed to generate challenge token: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(h.totpCfg.ChallengeTTL)
+	if err := h.twoFactorRepo.CreateChallenge(r.Context(), user.ID, hash, expiresAt, now); err != nil {
+		log.Printf("❌ Failed to create login challenge for user %s: %v", user.ID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	challenge := models.LoginChallenge{TwoFactorRequired: true, ChallengeToken: token, ExpiresAt: expiresAt}
+	sendJSONSuccess(w, "Two-factor code required", challenge, http.StatusOK)
+}
+
+// startSession открывает сессию на устройстве и отдает ее токены.
+// enrollmentOnly - сессия только для включения обязательной 2FA
+func (h *SessionHandler) startSession(w http.ResponseWriter, r *http.Request, user models.User, device models.DeviceInfo, enrollmentOnly bool) {
+	pair, accessHash, refreshHash, err := h.newTokenPair(time.Now())
+	if err != nil {
+		log.Printf("❌ Failed to generate session tokens: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	device.DeviceName = truncateRunes(strings.TrimSpace(device.DeviceName), maxDeviceNameLength)
+	device.Platform = session.Platform(device.Platform, r.UserAgent())
+
+	input := models.SessionInput{
+		UserID:           user.ID,
+		AccessTokenHash:  accessHash,
+		RefreshTokenHash: refreshHash,
+		Device:           device,
+		UserAgent:        r.UserAgent(),
+		IP:               clientIP(r),
+		AccessExpiresAt:  pair.ExpiresAt,
+		ExpiresAt:        pair.RefreshExpiresAt,
+		EnrollmentOnly:   enrollmentOnly,
+	}
+	if _, err := h.sessionRepo.Create(r.Context(), input, time.Now()); err != nil {
+		log.Printf("❌ Failed to create session for user %s: %v", user.ID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.LoginResponse{TokenPair: pair, User: user, TwoFactorEnrollmentRequired: enrollmentOnly}
+	if enrollmentOnly {
+		log.Printf("🔑 Вход только для включения 2FA: user=%s, role=%s, platform=%s", user.ID, user.Role, device.Platform)
+		sendJSONSuccess(w, "Enable two-factor authentication to continue", resp, http.StatusOK)
+		return
+	}
+
+	log.Printf("🔑 Вход: user=%s, platform=%s", user.ID, device.Platform)
+	sendJSONSuccess(w, "Logged in", resp, http.StatusOK)
+}
+
+// Refresh выдает новую пару токенов по refresh-токену: {"refresh_token": "..."}.
+// Старый refresh-токен перестает действовать; его повторное предъявление отзывает сессию
+func (h *SessionHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	req.RefreshToken = strings.TrimSpace(req.RefreshToken)
+	if req.RefreshToken == "" {
+		sendJSONError(w, "refresh_token is required", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	pair, accessHash, refreshHash, err := h.newTokenPair(now)
+	if err != nil {
+		log.Printf("❌ Failed to generate session tokens: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	res, err := h.sessionRepo.Refresh(r.Context(), tokens.Hash(req.RefreshToken), accessHash, refreshHash,
+		clientIP(r), pair.ExpiresAt, pair.RefreshExpiresAt, now)
+	if errors.Is(err, database.ErrRefreshReused) {
+		log.Printf("🚨 Повторное использование refresh-токена: user=%s, сессия %s отозвана", res.UserID, res.SessionID)
+		sendJSONError(w, "Session has been revoked, log in again", http.StatusUnauthorized)
+		return
+	}
+	if errors.Is(err, database.ErrTokenInvalid) || errors.Is(err, database.ErrTokenExpired) {
+		sendJSONError(w, "Refresh token is invalid or expired, log in again", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to refresh session: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Tokens refreshed", pair, http.StatusOK)
+}
+
+// newTokenPair создает access- и refresh-токены и их хэши для хранения
+func (h *SessionHandler) newTokenPair(now time.Time) (pair models.TokenPair, accessHash, refreshHash string, err error) {
+	pair.Token, accessHash, err = tokens.Generate()
+	if err != nil {
+		return pair, "", "", err
+	}
+	pair.RefreshToken, refreshHash, err = tokens.Generate()
+	if err != nil {
+		return pair, "", "", err
+	}
+	pair.ExpiresAt = now.Add(h.cfg.AccessTTL)
+	pair.RefreshExpiresAt = now.Add(h.cfg.RefreshTTL)
+	return pair, accessHash, refreshHash, nil
+}
+
+// Sessions - устройства, на которых выполнен вход
+func (h *SessionHandler) Sessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var currentID string
+	if s, ok := session.FromContext(r.Context()); ok {
+		currentID = s.ID
+	}
+
+	sessions, err := h.sessionRepo.List(r.Context(), userID, currentID, time.Now())
+	if err != nil {
+		log.Printf("❌ Failed to list sessions for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Sessions", sessions, http.StatusOK)
+}
+
+// RevokeSession завершает сессию на одном устройстве
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	sessionID := r.PathValue("id")
+	if !isUUID(sessionID) {
+		sendJSONError(w, "Invalid session id", http.StatusBadRequest)
+		return
+	}
+
+	err = h.sessionRepo.RevokeOwn(r.Context(), userID, sessionID, time.Now())
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to revoke session %s: %v", sessionID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Session revoked", nil, http.StatusOK)
+}
+
+// RevokeOtherSessions завершает все сессии, кроме текущей
+func (h *SessionHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	s, ok := session.FromContext(r.Context())
+	if !ok {
+		sendJSONError(w, "Not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	revoked, err := h.sessionRepo.RevokeOthers(r.Context(), s.UserID, s.ID, time.Now())
+	if err != nil {
+		log.Printf("❌ Failed to revoke sessions of user %s: %v", s.UserID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("🔒 Закрыты другие сессии: user=%s, %d шт.", s.UserID, revoked)
+	sendJSONSuccess(w, "Other sessions revoked", map[string]int64{"revoked_sessions": revoked}, http.StatusOK)
+}
+
+// upgradeHash пересчитывает хэш пароля текущим алгоритмом; ошибка не мешает входу
+func (h *SessionHandler) upgradeHash(ctx context.Context, user models.User, plain string) {
+	newHash, err := h.hasher.Hash(plain)
+	if err == nil {
+		err = h.userRepo.UpgradePasswordHash(ctx, user.ID, user.PasswordHash, newHash)
+	}
+	if err != nil {
+		log.Printf("⚠️ Failed to upgrade password hash of user %s: %v", user.ID, err)
+		return
+	}
+	log.Printf("🔐 Хэш пароля обновлен: user=%s, %s -> текущий алгоритм", user.ID, password.Algorithm(user.PasswordHash))
+}
+
+// Logout закрывает текущую сессию
+func (h *SessionHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	s, ok := session.FromContext(r.Context())
+	if !ok {
+		sendJSONError(w, "Not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.sessionRepo.Revoke(r.Context(), s.ID, time.Now()); err != nil {
+		log.Printf("❌ Failed to revoke session %s: %v", s.ID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Logged out", nil, http.StatusOK)
+}
+
+// truncateRunes обрезает строку до n символов
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// clientIP - адрес клиента без порта
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/leaderboard"
+	"github.com/mindly/api/internal/streak"
+)
+
+type StreakHandler struct {
+	streakRepo *database.StreakRepository
+	lb         *leaderboard.Service
+}
+
+func NewStreakHandler(db *sql.DB, lb *leaderboard.Service) *StreakHandler {
+	return &StreakHandler{
+		streakRepo: database.NewStreakRepository(db),
+		lb:         lb,
+	}
+}
+
+// GetStreak возвращает серию, заморозки, доступное восстановление и журнал серии
+func (h *StreakHandler) GetStreak(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	// Пропущенные дни закрываются сразу, не дожидаясь фоновой задачи,
+	// чтобы предложение восстановить серию появилось вовремя
+	broken, err := h.streakRepo.RollStreak(ctx, userID, time.Now())
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to roll streak for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if broken {
+		h.publishStreak(ctx, userID, 0)
+	}
+
+	h.sendOverview(w, r, userID, "Streak loaded")
+}
+
+// BuyFreeze покупает заморозку серии за баллы
+func (h *StreakHandler) BuyFreeze(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	cost, broken, err := h.streakRepo.BuyFreeze(ctx, userID, time.Now())
+	if !h.handleStreakError(w, userID, err) {
+		return
+	}
+	if broken {
+		h.publishStreak(ctx, userID, 0)
+	}
+	publishPoints(ctx, h.lb, userID, -cost, time.Now())
+
+	log.Printf("🧊 Заморозка серии куплена: user=%s cost=%d", userID, cost)
+	h.sendOverview(w, r, userID, "Streak freeze purchased")
+}
+
+// Repair восстанавливает недавно прерванную серию за баллы
+func (h *StreakHandler) Repair(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	current, cost, err := h.streakRepo.Repair(ctx, userID, time.Now())
+	if !h.handleStreakError(w, userID, err) {
+		return
+	}
+	h.publishStreak(ctx, userID, current)
+	publishPoints(ctx, h.lb, userID, -cost, time.Now())
+
+	log.Printf("🔥 Серия восстановлена: user=%s streak=%d cost=%d", userID, current, cost)
+	h.sendOverview(w, r, userID, "Streak repaired")
+}
+
+// handleStreakError отвечает клиенту на ошибку покупки или восстановления; true - ошибки нет
+func (h *StreakHandler) handleStreakError(w http.ResponseWriter, userID string, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, database.ErrNotFound):
+		sendJSONError(w, "User not found", http.StatusNotFound)
+	case errors.Is(err, streak.ErrNotEnoughPoints),
+		errors.Is(err, streak.ErrFreezeLimit),
+		errors.Is(err, streak.ErrNothingToRepair),
+		errors.Is(err, streak.ErrRepairExpired):
+		sendJSONError(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("❌ Streak operation failed for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+	}
+	return false
+}
+
+func (h *StreakHandler) sendOverview(w http.ResponseWriter, r *http.Request, userID, message string) {
+	overview, err := h.streakRepo.Overview(r.Context(), userID, time.Now())
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load streak for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, message, overview, http.StatusOK)
+}
+
+func (h *StreakHandler) publishStreak(ctx context.Context, userID string, current int) {
+	if err := h.lb.SetStreak(ctx, userID, current); err != nil {
+		log.Printf("⚠️ Failed to update streak leaderboard for user %s: %v", userID, err)
+	}
+}
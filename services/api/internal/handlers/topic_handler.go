@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/models"
+)
+
+type TopicHandler struct {
+	topicRepo *database.TopicRepository
+	videoRepo *database.VideoRepository
+}
+
+func NewTopicHandler(db *sql.DB) *TopicHandler {
+	return &TopicHandler{
+		topicRepo: database.NewTopicRepository(db),
+		videoRepo: database.NewVideoRepository(db),
+	}
+}
+
+// ListTopics возвращает области экспертизы с числом видео и авторов
+func (h *TopicHandler) ListTopics(w http.ResponseWriter, r *http.Request) {
+	topics, err := h.topicRepo.ExpertiseAreas(r.Context())
+	if err != nil {
+		log.Printf("❌ Failed to load topics: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Topics loaded", topics, http.StatusOK)
+}
+
+// GetTopicFeed - лента видео авторов одной области экспертизы
+func (h *TopicHandler) GetTopicFeed(w http.ResponseWriter, r *http.Request) {
+	area := strings.TrimSpace(r.PathValue("area"))
+	if area == "" {
+		sendJSONError(w, "Topic is required", http.StatusBadRequest)
+		return
+	}
+
+	videos, err := h.videoRepo.GetFilteredFeed(r.Context(), models.FeedFilter{ExpertiseArea: area, ViewerID: viewerID(r)}, feedLimit(r))
+	if err != nil {
+		log.Printf("❌ Failed to load feed for topic %s: %v", area, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Topic feed loaded", videos, http.StatusOK)
+}
+
+// ListTags возвращает таксономию тегов: синонимы и варианты написания сведены к каноническому тегу
+func (h *TopicHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tax, err := h.topicRepo.Taxonomy(ctx)
+	if err != nil {
+		log.Printf("❌ Failed to load tag taxonomy: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	usage, err := h.topicRepo.TagUsage(ctx)
+	if err != nil {
+		log.Printf("❌ Failed to load tag usage: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Tags loaded", tax.Group(usage), http.StatusOK)
+}
+
+// GetTagFeed - лента видео с тегом в любом из его написаний
+func (h *TopicHandler) GetTagFeed(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tag := strings.TrimSpace(r.PathValue("tag"))
+	if tag == "" {
+		sendJSONError(w, "Tag is required", http.StatusBadRequest)
+		return
+	}
+
+	spellings, err := tagSpellings(ctx, h.topicRepo, []string{tag})
+	if err != nil {
+		log.Printf("❌ Failed to resolve tag %s: %v", tag, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	videos, err := h.videoRepo.GetFilteredFeed(ctx, models.FeedFilter{AnyTags: spellings[0], ViewerID: viewerID(r)}, feedLimit(r))
+	if err != nil {
+		log.Printf("❌ Failed to load feed for tag %s: %v", tag, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Tag feed loaded", videos, http.StatusOK)
+}
+
+// tagSpellings возвращает для каждого тега его нормализованные написания по словарю
+// синонимов; видео сопоставляются с ними в запросе, без перебора тегов каталога
+func tagSpellings(ctx context.Context, repo *database.TopicRepository, tags []string) ([][]string, error) {
+	tax, err := repo.Taxonomy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	spellings := make([][]string, len(tags))
+	for i, tag := range tags {
+		spellings[i] = tax.Spellings(tag)
+	}
+	return spellings, nil
+}
+
+// feedLimit разбирает ?limit= так же, как основная лента
+func feedLimit(r *http.Request) int {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	if limit > 50 {
+		limit = 50
+	}
+	return limit
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/session"
+	"github.com/mindly/api/internal/tokens"
+	"github.com/mindly/api/internal/totp"
+)
+
+// Код ошибки, по которому клиент предлагает включить 2FA
+const codeTwoFactorRequired = session.CodeTwoFactorRequired
+
+// TwoFactorHandler - включение и выключение TOTP и резервные коды текущего пользователя
+type TwoFactorHandler struct {
+	twoFactorRepo *database.TwoFactorRepository
+	userRepo      *database.UserRepository
+	cfg           totp.Config
+}
+
+func NewTwoFactorHandler(db *sql.DB, cfg totp.Config) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorRepo: database.NewTwoFactorRepository(db),
+		userRepo:      database.NewUserRepository(db),
+		cfg:           cfg,
+	}
+}
+
+// Enroll выдает новый секрет и otpauth-ссылку. 2FA включается только после Verify
+func (h *TwoFactorHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Printf("❌ Failed to generate TOTP secret: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = h.twoFactorRepo.Enroll(ctx, userID, secret, time.Now())
+	if errors.Is(err, database.ErrTwoFactorEnabled) {
+		sendJSONError(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to save TOTP secret for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	enrollment := models.TwoFactorEnrollment{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(h.cfg, user.Email, secret),
+	}
+	sendJSONSuccess(w, "Scan the code and confirm it with /api/me/2fa/verify", enrollment, http.StatusOK)
+}
+
+// Verify включает 2FA по первому коду из приложения: {"code": "123456"}.
+// В ответе - резервные коды, они показываются один раз
+func (h *TwoFactorHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	secret, lastStep, enabled, err := h.twoFactorRepo.Secret(ctx, userID)
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "Two-factor enrollment not started", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load TOTP secret for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if enabled {
+		sendJSONError(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+
+	now := time.Now()
+	step, ok := totp.Verify(h.cfg, secret, req.Code, now, lastStep)
+	if !ok {
+		sendJSONError(w, "Invalid two-factor code", http.StatusBadRequest)
+		return
+	}
+
+	codes, hashes, err := h.newRecoveryCodes()
+	if err != nil {
+		log.Printf("❌ Failed to generate recovery codes: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var keepSessionID string
+	if s, ok := session.FromContext(ctx); ok {
+		keepSessionID = s.ID
+	}
+
+	revoked, err := h.twoFactorRepo.Enable(ctx, userID, step, hashes, keepSessionID, now)
+	if errors.Is(err, database.ErrNotFound) {
+		// Секрет заменили повторным Enroll или 2FA включили параллельным запросом
+		sendJSONError(w, "Two-factor enrollment changed, start again", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to enable 2FA for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("🛡️ 2FA включена: user=%s, закрыто сессий: %d", userID, revoked)
+	sendJSONSuccess(w, "Two-factor authentication enabled", models.RecoveryCodes{Codes: codes, RevokedSessions: revoked}, http.StatusOK)
+}
+
+// Disable выключает 2FA: {"code": "код из приложения или резервный код"}.
+// Недоступно, если 2FA обязательна для роли пользователя
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	role, err := h.userRepo.Role(ctx, userID)
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load role for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	required, err := h.twoFactorRepo.Required(ctx, role)
+	if err != nil {
+		log.Printf("❌ Failed to load 2FA requirement for role %s: %v", role, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if required {
+		sendJSONErrorCode(w, "Two-factor authentication is required for your role", codeTwoFactorRequired, http.StatusForbidden)
+		return
+	}
+
+	ok, err := verifySecondFactor(ctx, h.twoFactorRepo, h.cfg, userID, req.Code, time.Now())
+	if err != nil {
+		log.Printf("❌ Failed to verify second factor for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		sendJSONError(w, "Invalid two-factor code", http.StatusForbidden)
+		return
+	}
+
+	if err := h.twoFactorRepo.Disable(ctx, userID); err != nil && !errors.Is(err, database.ErrNotFound) {
+		log.Printf("❌ Failed to disable 2FA for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("🛡️ 2FA выключена: user=%s", userID)
+	sendJSONSuccess(w, "Two-factor authentication disabled", nil, http.StatusOK)
+}
+
+// RegenerateRecoveryCodes выдает новые резервные коды вместо старых:
+// {"code": "код из приложения"}
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	// Резервным кодом новые резервные коды не получить
+	if !totp.IsCode(h.cfg, req.Code) {
+		sendJSONError(w, "Code from the authenticator app is required", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	ok, err := verifySecondFactor(ctx, h.twoFactorRepo, h.cfg, userID, req.Code, now)
+	if err != nil {
+		log.Printf("❌ Failed to verify second factor for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		sendJSONError(w, "Invalid two-factor code", http.StatusForbidden)
+		return
+	}
+
+	codes, hashes, err := h.newRecoveryCodes()
+	if err != nil {
+		log.Printf("❌ Failed to generate recovery codes: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.twoFactorRepo.RegenerateRecoveryCodes(ctx, userID, hashes, now); err != nil {
+		log.Printf("❌ Failed to save recovery codes for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "Recovery codes regenerated", models.RecoveryCodes{Codes: codes}, http.StatusOK)
+}
+
+func (h *TwoFactorHandler) newRecoveryCodes() (codes, hashes []string, err error) {
+	codes, err = totp.GenerateRecoveryCodes(h.cfg.RecoveryCodes)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes = make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = tokens.Hash(totp.NormalizeRecoveryCode(c))
+	}
+	return codes, hashes, nil
+}
+
+// verifySecondFactor проверяет код из приложения (с защитой от повтора) или гасит резервный код
+func verifySecondFactor(ctx context.Context, repo *database.TwoFactorRepository, cfg totp.Config, userID, input string, now time.Time) (bool, error) {
+	if totp.IsCode(cfg, input) {
+		secret, lastStep, enabled, err := repo.Secret(ctx, userID)
+		if errors.Is(err, database.ErrNotFound) || (err == nil && !enabled) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		step, ok := totp.Verify(cfg, secret, input, now, lastStep)
+		if !ok {
+			return false, nil
+		}
+		return repo.UseStep(ctx, userID, step)
+	}
+
+	code := totp.NormalizeRecoveryCode(input)
+	if code == "" {
+		return false, nil
+	}
+	return repo.UseRecoveryCode(ctx, userID, tokens.Hash(code), now)
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mindly/api/internal/database"
+	"github.com/mindly/api/internal/mailer"
+	"github.com/mindly/api/internal/tokens"
+	"github.com/mindly/api/internal/verification"
+)
+
+type VerificationHandler struct {
+	verifyRepo *database.VerificationRepository
+	mailer     mailer.Mailer
+	cfg        verification.Config
+}
+
+func NewVerificationHandler(db *sql.DB, m mailer.Mailer, cfg verification.Config) *VerificationHandler {
+	return &VerificationHandler{
+		verifyRepo: database.NewVerificationRepository(db),
+		mailer:     m,
+		cfg:        cfg,
+	}
+}
+
+// Verify подтверждает email: GET ?token=... (ссылка из письма) или POST {"token": "..."}
+func (h *VerificationHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if r.Method == http.MethodPost {
+		var req struct {
+			Token string `json:"token"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		token = req.Token
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		sendJSONError(w, "token is required", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := h.verifyRepo.Verify(r.Context(), tokens.Hash(token), time.Now())
+	if errors.Is(err, database.ErrTokenInvalid) {
+		sendJSONError(w, "Verification link is invalid or already used", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, database.ErrTokenExpired) {
+		sendJSONError(w, "Verification link has expired, request a new one", http.StatusGone)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to verify email: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("✅ Email подтвержден: user=%s", userID)
+	sendJSONSuccess(w, "Email verified", map[string]string{"user_id": userID}, http.StatusOK)
+}
+
+// Resend повторно отправляет письмо с подтверждением текущему пользователю
+func (h *VerificationHandler) Resend(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	err = sendVerificationEmail(r.Context(), h.verifyRepo, h.mailer, h.cfg, userID, time.Now())
+	var throttled *throttledError
+	switch {
+	case err == nil:
+		sendJSONSuccess(w, "Verification email sent", nil, http.StatusOK)
+	case errors.Is(err, database.ErrNotFound):
+		sendJSONError(w, "User not found", http.StatusNotFound)
+	case errors.Is(err, verification.ErrAlreadyVerified):
+		sendJSONError(w, err.Error(), http.StatusConflict)
+	case errors.As(err, &throttled):
+		if throttled.wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.wait.Seconds()))))
+		}
+		sendJSONError(w, throttled.Error(), http.StatusTooManyRequests)
+	default:
+		log.Printf("❌ Failed to resend verification email to user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// throttledError - письмо не отправлено из-за ограничения частоты
+type throttledError struct {
+	err  error
+	wait time.Duration
+}
+
+func (e *throttledError) Error() string { return e.err.Error() }
+func (e *throttledError) Unwrap() error { return e.err }
+
+// sendVerificationEmail выпускает новый токен и отправляет письмо со ссылкой с учетом ограничений частоты
+func sendVerificationEmail(ctx context.Context, repo *database.VerificationRepository, m mailer.Mailer, cfg verification.Config, userID string, now time.Time) error {
+	email, username, state, err := repo.Recipient(ctx, userID, now)
+	if err != nil {
+		return err
+	}
+	wait, err := verification.CheckResend(cfg, state, now)
+	if errors.Is(err, verification.ErrTooSoon) || errors.Is(err, verification.ErrDailyLimit) {
+		return &throttledError{err: err, wait: wait}
+	}
+	if err != nil {
+		return err
+	}
+
+	token, hash, err := tokens.Generate()
+	if err != nil {
+		return err
+	}
+	if err := repo.CreateToken(ctx, userID, email, hash, now.Add(cfg.TokenTTL), now); err != nil {
+		return err
+	}
+	return m.Send(ctx, verification.Email(cfg, email, username, token))
+}
+
+// requireVerifiedEmail пропускает только пользователей с подтвержденным email
+func requireVerifiedEmail(w http.ResponseWriter, r *http.Request, users *database.UserRepository, userID string) bool {
+	verified, err := users.EmailVerified(r.Context(), userID)
+	if errors.Is(err, database.ErrNotFound) {
+		sendJSONError(w, "User not found", http.StatusUnauthorized)
+		return false
+	}
+	if err != nil {
+		log.Printf("❌ Failed to check email verification for user %s: %v", userID, err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return false
+	}
+	if !verified {
+		sendJSONError(w, "Email is not verified", http.StatusForbidden)
+		return false
+	}
+	return true
+}
@@ -3,10 +3,12 @@ package handlers
 import (
     "database/sql"
     "encoding/json"
+    "log"
     "net/http"
     "strconv"
     
     "github.com/mindly/api/internal/database"
+    "github.com/mindly/api/internal/pagination"
 )
 
 type VideoHandler struct {
@@ -20,11 +22,8 @@ func NewVideoHandler(db *sql.DB) *VideoHandler {
 }
 
 func (h *VideoHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
-    // Получаем user_id из query-параметра
-    userID := r.URL.Query().Get("user_id")
-    if userID == "" {
-        userID = "temp_user_id"
-    }
+    // Пользователь нужен только для персонализации: без него лента общая
+    userID := viewerID(r)
     
     // Получаем лимит
     limitStr := r.URL.Query().Get("limit")
@@ -40,6 +39,12 @@ func (h *VideoHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
         limit = 50
     }
     
+    // Лента подписок: только авторы, на которых подписан пользователь, с курсором
+    if r.URL.Query().Get("source") == "following" {
+        h.getFollowingFeed(w, r, limit)
+        return
+    }
+    
     // Получаем видео из репозитория
     videos, err := h.videoRepo.GetFeed(r.Context(), userID, limit)
     if err != nil {
@@ -59,4 +64,39 @@ func (h *VideoHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
         http.Error(w, `{"error": "Ошибка кодирования ответа"}`, http.StatusInternalServerError)
         return
     }
-}
\ No newline at end of file
+}
+
+// getFollowingFeed отдает страницу ленты подписок: ?source=following&cursor=&limit=
+func (h *VideoHandler) getFollowingFeed(w http.ResponseWriter, r *http.Request, limit int) {
+    userID, err := requestUserID(r)
+    if err != nil {
+        sendJSONError(w, err.Error(), http.StatusUnauthorized)
+        return
+    }
+    
+    cursor, err := pagination.Decode(r.URL.Query().Get("cursor"))
+    if err != nil || (cursor != nil && !isUUID(cursor.ID)) {
+        sendJSONError(w, "Invalid cursor", http.StatusBadRequest)
+        return
+    }
+    
+    page, err := h.videoRepo.GetFollowingFeed(r.Context(), userID, cursor, limit)
+    if err != nil {
+        log.Printf("❌ Failed to load following feed for user %s: %v", userID, err)
+        sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+        return
+    }
+    
+    response := map[string]interface{}{
+        "success":     true,
+        "data":        page.Videos,
+        "count":       len(page.Videos),
+        "next_cursor": page.NextCursor,
+    }
+    
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(response); err != nil {
+        http.Error(w, `{"error": "Ошибка кодирования ответа"}`, http.StatusInternalServerError)
+        return
+    }
+}
@@ -0,0 +1,245 @@
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Board - по какому показателю строится рейтинг
+type Board string
+
+const (
+	BoardPoints Board = "points"
+	BoardStreak Board = "streak"
+)
+
+// Window - период рейтинга
+type Window string
+
+const (
+	WindowAll   Window = "all"
+	WindowWeek  Window = "week"
+	WindowMonth Window = "month"
+)
+
+var ErrUnsupported = errors.New("unsupported leaderboard")
+
+// Entry - строка рейтинга; Rank начинается с 1, 0 - пользователя нет в рейтинге
+type Entry struct {
+	Rank   int64   `json:"rank"`
+	UserID string  `json:"user_id"`
+	Score  float64 `json:"score"`
+}
+
+// Рейтинги за неделю и месяц хранятся еще немного после окончания периода
+const windowRetention = 7 * 24 * time.Hour
+
+// Service хранит рейтинги в сортированных множествах Redis
+type Service struct {
+	rdb *redis.Client
+}
+
+func NewService(rdb *redis.Client) *Service {
+	return &Service{rdb: rdb}
+}
+
+// Validate проверяет сочетание рейтинга и периода: серии бывают только текущие
+func Validate(board Board, window Window) error {
+	switch board {
+	case BoardPoints:
+		if window == WindowAll || window == WindowWeek || window == WindowMonth {
+			return nil
+		}
+	case BoardStreak:
+		if window == WindowAll {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s/%s", ErrUnsupported, board, window)
+}
+
+// Key возвращает ключ Redis рейтинга за период, в который попадает момент at
+func Key(board Board, window Window, at time.Time) string {
+	at = at.UTC()
+	switch window {
+	case WindowWeek:
+		year, week := at.ISOWeek()
+		return fmt.Sprintf("lb:%s:week:%d-W%02d", board, year, week)
+	case WindowMonth:
+		return fmt.Sprintf("lb:%s:month:%s", board, at.Format("2006-01"))
+	default:
+		return fmt.Sprintf("lb:%s:all", board)
+	}
+}
+
+// WindowStart возвращает начало периода, в который попадает момент at
+func WindowStart(window Window, at time.Time) time.Time {
+	at = at.UTC()
+	y, m, d := at.Date()
+	switch window {
+	case WindowWeek:
+		offset := (int(at.Weekday()) + 6) % 7 // понедельник - начало ISO-недели
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, time.UTC)
+	case WindowMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Time{}
+	}
+}
+
+func windowEnd(window Window, at time.Time) time.Time {
+	start := WindowStart(window, at)
+	if window == WindowWeek {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 1, 0)
+}
+
+// AddPoints применяет изменение баланса (отрицательное - списание) ко всем рейтингам
+// по баллам, как записи журнала учитываются в Rebuild. Пользователи без положительного
+// счета убираются из рейтинга: Rebuild их тоже не включает
+func (s *Service) AddPoints(ctx context.Context, userID string, points int, at time.Time) error {
+	pipe := s.rdb.TxPipeline()
+	for _, window := range []Window{WindowAll, WindowWeek, WindowMonth} {
+		key := Key(BoardPoints, window, at)
+		pipe.ZIncrBy(ctx, key, float64(points), userID)
+		if points < 0 {
+			pipe.ZRemRangeByScore(ctx, key, "-inf", "0")
+		}
+		if window != WindowAll {
+			pipe.ExpireAt(ctx, key, windowEnd(window, at).Add(windowRetention))
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("add points: %w", err)
+	}
+	return nil
+}
+
+// SetStreak обновляет текущую серию пользователя; прерванная серия убирается из рейтинга
+func (s *Service) SetStreak(ctx context.Context, userID string, streak int) error {
+	key := Key(BoardStreak, WindowAll, time.Now())
+	if streak <= 0 {
+		if err := s.rdb.ZRem(ctx, key, userID).Err(); err != nil {
+			return fmt.Errorf("remove streak: %w", err)
+		}
+		return nil
+	}
+	if err := s.rdb.ZAdd(ctx, key, redis.Z{Score: float64(streak), Member: userID}).Err(); err != nil {
+		return fmt.Errorf("set streak: %w", err)
+	}
+	return nil
+}
+
+// Remove убирает пользователя из всех текущих рейтингов (удаленная учетная запись)
+func (s *Service) Remove(ctx context.Context, userID string) error {
+	now := time.Now()
+	pipe := s.rdb.TxPipeline()
+	for _, window := range []Window{WindowAll, WindowWeek, WindowMonth} {
+		pipe.ZRem(ctx, Key(BoardPoints, window, now), userID)
+	}
+	pipe.ZRem(ctx, Key(BoardStreak, WindowAll, now), userID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("remove user: %w", err)
+	}
+	return nil
+}
+
+// Top возвращает страницу рейтинга
+func (s *Service) Top(ctx context.Context, board Board, window Window, offset, limit int64) ([]Entry, int64, error) {
+	key := Key(board, window, time.Now())
+
+	pipe := s.rdb.Pipeline()
+	rangeCmd := pipe.ZRevRangeWithScores(ctx, key, offset, offset+limit-1)
+	totalCmd := pipe.ZCard(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, 0, fmt.Errorf("top: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(rangeCmd.Val()))
+	for i, z := range rangeCmd.Val() {
+		entries = append(entries, Entry{
+			Rank:   offset + int64(i) + 1,
+			UserID: z.Member.(string),
+			Score:  z.Score,
+		})
+	}
+	return entries, totalCmd.Val(), nil
+}
+
+// Rank возвращает место пользователя в рейтинге
+func (s *Service) Rank(ctx context.Context, board Board, window Window, userID string) (Entry, error) {
+	key := Key(board, window, time.Now())
+	entry := Entry{UserID: userID}
+
+	pipe := s.rdb.Pipeline()
+	rankCmd := pipe.ZRevRank(ctx, key, userID)
+	scoreCmd := pipe.ZScore(ctx, key, userID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return entry, nil
+		}
+		return entry, fmt.Errorf("rank: %w", err)
+	}
+
+	entry.Rank = rankCmd.Val() + 1
+	entry.Score = scoreCmd.Val()
+	return entry, nil
+}
+
+// Among возвращает рейтинг среди указанных пользователей (например, друзей)
+func (s *Service) Among(ctx context.Context, board Board, window Window, userIDs []string) ([]Entry, error) {
+	if len(userIDs) == 0 {
+		return []Entry{}, nil
+	}
+
+	key := Key(board, window, time.Now())
+	scores, err := s.rdb.ZMScore(ctx, key, userIDs...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("friends scores: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(userIDs))
+	for i, id := range userIDs {
+		entries = append(entries, Entry{UserID: id, Score: scores[i]})
+	}
+	sort.SliceStable(entries, func(a, b int) bool {
+		return entries[a].Score > entries[b].Score
+	})
+	for i := range entries {
+		entries[i].Rank = int64(i) + 1
+	}
+	return entries, nil
+}
+
+// Replace атомарно заменяет рейтинг за период, в который попадает at, значениями из scores
+func (s *Service) Replace(ctx context.Context, board Board, window Window, at time.Time, scores map[string]float64) error {
+	key := Key(board, window, at)
+	tmpKey := key + ":rebuild"
+
+	members := make([]redis.Z, 0, len(scores))
+	for userID, score := range scores {
+		members = append(members, redis.Z{Score: score, Member: userID})
+	}
+
+	pipe := s.rdb.TxPipeline()
+	pipe.Del(ctx, tmpKey)
+	if len(members) > 0 {
+		pipe.ZAdd(ctx, tmpKey, members...)
+		pipe.Rename(ctx, tmpKey, key)
+		if window != WindowAll {
+			pipe.ExpireAt(ctx, key, windowEnd(window, at).Add(windowRetention))
+		}
+	} else {
+		pipe.Del(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("replace %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package leaderboard
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Source - данные в Postgres, по которым восстанавливаются рейтинги
+type Source interface {
+	TotalPoints(ctx context.Context) (map[string]float64, error)
+	PointsSince(ctx context.Context, since time.Time) (map[string]float64, error)
+	Streaks(ctx context.Context) (map[string]float64, error)
+}
+
+// Rebuild пересобирает все текущие рейтинги из Postgres, например после потери кэша
+func (s *Service) Rebuild(ctx context.Context, src Source, now time.Time) error {
+	total, err := src.TotalPoints(ctx)
+	if err != nil {
+		return fmt.Errorf("load total points: %w", err)
+	}
+	if err := s.Replace(ctx, BoardPoints, WindowAll, now, total); err != nil {
+		return err
+	}
+	log.Printf("🏆 Рейтинг %s: %d пользователей", Key(BoardPoints, WindowAll, now), len(total))
+
+	for _, window := range []Window{WindowWeek, WindowMonth} {
+		points, err := src.PointsSince(ctx, WindowStart(window, now))
+		if err != nil {
+			return fmt.Errorf("load %s points: %w", window, err)
+		}
+		if err := s.Replace(ctx, BoardPoints, window, now, points); err != nil {
+			return err
+		}
+		log.Printf("🏆 Рейтинг %s: %d пользователей", Key(BoardPoints, window, now), len(points))
+	}
+
+	streaks, err := src.Streaks(ctx)
+	if err != nil {
+		return fmt.Errorf("load streaks: %w", err)
+	}
+	if err := s.Replace(ctx, BoardStreak, WindowAll, now, streaks); err != nil {
+		return err
+	}
+	log.Printf("🏆 Рейтинг %s: %d пользователей", Key(BoardStreak, WindowAll, now), len(streaks))
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+)
+
+// Message - письмо пользователю (только текст)
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+}
+
+// Mailer отправляет письма. В разработке письма пишутся в лог или в файлы,
+// в продакшене уходят через SMTP
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Backend - способ доставки писем
+type Backend string
+
+const (
+	BackendLog  Backend = "log"
+	BackendFile Backend = "file"
+	BackendSMTP Backend = "smtp"
+)
+
+// Config - настройки доставки писем
+type Config struct {
+	Backend Backend
+	From    string
+
+	// Каталог для писем при Backend = file
+	Dir string
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Backend:  BackendLog,
+		From:     "Mindly <no-reply@mindly.ru>",
+		Dir:      "tmp/mail",
+		SMTPPort: 587,
+	}
+}
+
+// New создает отправителя для выбранного способа доставки
+func New(cfg Config) (Mailer, error) {
+	switch cfg.Backend {
+	case BackendLog, "":
+		return NewLogMailer(), nil
+	case BackendFile:
+		return NewFileMailer(cfg.Dir, cfg.From)
+	case BackendSMTP:
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("smtp host is not configured")
+		}
+		return NewSMTPMailer(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown mail backend %q", cfg.Backend)
+	}
+}
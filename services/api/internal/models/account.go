@@ -0,0 +1,105 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ExportStatus - состояние выгрузки персональных данных
+type ExportStatus string
+
+const (
+	ExportPending    ExportStatus = "pending"
+	ExportProcessing ExportStatus = "processing"
+	ExportReady      ExportStatus = "ready"
+	ExportFailed     ExportStatus = "failed"
+	// Архив удален по истечении срока хранения
+	ExportExpired ExportStatus = "expired"
+)
+
+// DataExport - запрошенная пользователем выгрузка его данных
+type DataExport struct {
+	ID        string       `json:"id"`
+	UserID    string       `json:"-"`
+	Status    ExportStatus `json:"status"`
+	FileKey   string       `json:"-"`
+	SizeBytes *int64       `json:"size_bytes,omitempty"`
+	// Адрес для скачивания готового архива (только со своей сессией)
+	DownloadURL string     `json:"download_url,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// AccountData - содержимое архива выгрузки
+type AccountData struct {
+	GeneratedAt time.Time          `json:"generated_at"`
+	Profile     User               `json:"profile"`
+	Progress    []ExportProgress   `json:"progress"`
+	QuizAnswers []ExportQuizAnswer `json:"quiz_answers"`
+	Points      []PointsEntry      `json:"points"`
+	Comments    []ExportComment    `json:"comments"`
+}
+
+// ExportProgress - прогресс по одному видео
+type ExportProgress struct {
+	VideoID         string     `json:"video_id"`
+	VideoTitle      string     `json:"video_title"`
+	IsWatched       bool       `json:"is_watched"`
+	WatchedAt       *time.Time `json:"watched_at,omitempty"`
+	QuizAttempted   bool       `json:"quiz_attempted"`
+	QuizCorrect     bool       `json:"quiz_correct"`
+	PointsEarned    int        `json:"points_earned"`
+	InteractionDate string     `json:"interaction_date"`
+}
+
+// ExportQuizAnswer - одна попытка ответа на вопрос
+type ExportQuizAnswer struct {
+	ID           int64           `json:"id"`
+	QuestionID   string          `json:"question_id"`
+	VideoID      string          `json:"video_id"`
+	Answer       json.RawMessage `json:"answer"`
+	IsCorrect    bool            `json:"is_correct"`
+	PointsEarned int             `json:"points_earned"`
+	AnsweredAt   time.Time       `json:"answered_at"`
+}
+
+// ExportComment - комментарий пользователя, включая удаленные и скрытые модерацией
+type ExportComment struct {
+	ID        string           `json:"id"`
+	VideoID   string           `json:"video_id"`
+	ParentID  string           `json:"parent_id,omitempty"`
+	Body      string           `json:"body"`
+	Status    ModerationStatus `json:"status"`
+	EditedAt  *time.Time       `json:"edited_at,omitempty"`
+	DeletedAt *time.Time       `json:"deleted_at,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// DeleteAccountRequest - DELETE /api/me: текущий пароль, если он задан
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+// AccountDeletion - запланированное удаление учетной записи
+type AccountDeletion struct {
+	ScheduledAt time.Time `json:"deletion_scheduled_at"`
+	// Сколько сессий на других устройствах закрыто
+	RevokedSessions int64 `json:"revoked_sessions"`
+}
+
+// DeletionMode - как удалена учетная запись по истечении срока
+type DeletionMode string
+
+const (
+	// Строка users удалена, связанные данные - каскадом
+	DeletionHard DeletionMode = "deleted"
+	// Строка users обезличена: остаются ответы, прогресс и баллы для общей статистики
+	DeletionAnonymized DeletionMode = "anonymized"
+)
+
+// DeletedAccount - итог удаления: файлы, которые нужно убрать из хранилища
+type DeletedAccount struct {
+	Mode     DeletionMode
+	FileKeys []string
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Achievement - достижение (бейдж); правило выдачи хранится в БД
+type Achievement struct {
+	Code        string          `json:"code"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Icon        string          `json:"icon,omitempty"`
+	Rule        json.RawMessage `json:"-"`
+	Points      int             `json:"points"`
+	// Время получения; nil - достижение еще не получено
+	UnlockedAt *time.Time `json:"unlocked_at"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// ModerationStatus - статус модерации видео и комментариев
+type ModerationStatus string
+
+const (
+	ModerationPending  ModerationStatus = "pending"
+	ModerationApproved ModerationStatus = "approved"
+	ModerationRejected ModerationStatus = "rejected"
+)
+
+// Comment - комментарий к видео или ответ в ветке
+type Comment struct {
+	ID       string           `json:"id"`
+	VideoID  string           `json:"video_id"`
+	ParentID string           `json:"parent_id,omitempty"`
+	UserID   string           `json:"user_id"`
+	Username string           `json:"username"`
+	Body     string           `json:"body"`
+	Status   ModerationStatus `json:"status"`
+	Pinned   bool             `json:"pinned"`
+	// Удаленный комментарий показывается без текста, если у него есть ответы
+	Deleted    bool       `json:"deleted,omitempty"`
+	ReplyCount int        `json:"reply_count"`
+	Replies    []Comment  `json:"replies,omitempty"`
+	EditedAt   *time.Time `json:"edited_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CommentPage - страница комментариев; закрепленные приходят только на первой странице
+type CommentPage struct {
+	Pinned     []Comment `json:"pinned,omitempty"`
+	Comments   []Comment `json:"comments"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// CommentInput - текст нового комментария; ParentID - ответ на комментарий
+type CommentInput struct {
+	Body     string `json:"body"`
+	ParentID string `json:"parent_id,omitempty"`
+}
+
+// PinRequest - закрепить или открепить комментарий
+type PinRequest struct {
+	Pinned bool `json:"pinned"`
+}
+
+// FlaggedComment - комментарий в очереди модерации
+type FlaggedComment struct {
+	Comment
+	VideoTitle      string   `json:"video_title"`
+	FlaggedKeywords []string `json:"flagged_keywords"`
+}
+
+// ModerationDecision - решение модератора: approved или rejected
+type ModerationDecision struct {
+	Status ModerationStatus `json:"status"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Engagement - отношение пользователя к видео после лайка или закладки
+type Engagement struct {
+	VideoID    string `json:"video_id"`
+	Liked      bool   `json:"liked"`
+	Bookmarked bool   `json:"bookmarked"`
+	LikesCount int    `json:"likes_count"`
+}
+
+// SavedVideo - видео из библиотеки сохраненных
+type SavedVideo struct {
+	VideoWithAuthor
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// SavedPage - страница сохраненных видео; NextCursor пуст на последней странице
+type SavedPage struct {
+	Videos     []SavedVideo `json:"videos"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
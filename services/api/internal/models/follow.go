@@ -0,0 +1,14 @@
+package models
+
+// FollowStatus - состояние подписки на автора после подписки или отписки
+type FollowStatus struct {
+	AuthorID       string `json:"author_id"`
+	Following      bool   `json:"following"`
+	FollowersCount int    `json:"followers_count"`
+}
+
+// FollowingFeedPage - страница ленты подписок; NextCursor пуст на последней странице
+type FollowingFeedPage struct {
+	Videos     []VideoWithAuthor `json:"videos"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+// ExternalIdentity - пользователь внешнего провайдера по проверенному ID-токену
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// IdentityOutcome - чем закончился вход через провайдера
+type IdentityOutcome string
+
+const (
+	// Учетная запись провайдера уже была привязана
+	IdentityExisting IdentityOutcome = "existing"
+	// Привязана к пользователю с тем же подтвержденным email
+	IdentityLinked IdentityOutcome = "linked"
+	// Создан новый пользователь
+	IdentityCreated IdentityOutcome = "created"
+)
+
+// OIDCStart - адрес страницы входа провайдера
+type OIDCStart struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// OIDCCallbackRequest - code и state, с которыми провайдер вернул пользователя
+type OIDCCallbackRequest struct {
+	Code  string `json:"code"`
+	State string `json:"state"`
+	DeviceInfo
+}
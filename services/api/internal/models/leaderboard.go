@@ -0,0 +1,19 @@
+package models
+
+// LeaderboardEntry - строка рейтинга для клиента
+type LeaderboardEntry struct {
+	Rank     int64   `json:"rank"`
+	UserID   string  `json:"user_id"`
+	Username string  `json:"username"`
+	Score    float64 `json:"score"`
+}
+
+// LeaderboardPage - страница рейтинга и место текущего пользователя
+type LeaderboardPage struct {
+	Board   string             `json:"board"`
+	Window  string             `json:"window"`
+	Scope   string             `json:"scope"`
+	Total   int64              `json:"total"`
+	Entries []LeaderboardEntry `json:"entries"`
+	Me      *LeaderboardEntry  `json:"me,omitempty"`
+}
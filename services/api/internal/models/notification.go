@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// NotificationType - вид уведомления
+type NotificationType string
+
+const (
+	NotificationNewFollower    NotificationType = "new_follower"
+	NotificationReportResolved NotificationType = "report_resolved"
+)
+
+// Notification - событие для пользователя
+type Notification struct {
+	ID        int64            `json:"id"`
+	Type      NotificationType `json:"type"`
+	ActorID   string           `json:"actor_id,omitempty"`
+	Payload   map[string]any   `json:"payload"`
+	ReadAt    *time.Time       `json:"read_at,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// NotificationList - последние уведомления и число непрочитанных
+type NotificationList struct {
+	Unread        int            `json:"unread"`
+	Notifications []Notification `json:"notifications"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// PointsReason - причина начисления или списания баллов
+type PointsReason string
+
+const (
+	PointsQuizCorrect     PointsReason = "quiz_correct"
+	PointsAchievement     PointsReason = "achievement"
+	PointsStreakBonus     PointsReason = "streak_bonus"
+	PointsAdminAdjustment PointsReason = "admin_adjustment"
+	PointsFreezePurchase  PointsReason = "freeze_purchase"
+	PointsStreakRepair    PointsReason = "streak_repair"
+)
+
+// PointsEntry - запись журнала баллов
+type PointsEntry struct {
+	ID     int64        `json:"id"`
+	UserID string       `json:"user_id"`
+	Amount int          `json:"amount"`
+	Reason PointsReason `json:"reason"`
+	// Reference - за что начислено (ID вопроса, код достижения, день серии); по нему
+	// повторное начисление с той же причиной игнорируется
+	Reference    string    `json:"reference,omitempty"`
+	VideoID      string    `json:"video_id,omitempty"`
+	Note         string    `json:"note,omitempty"`
+	BalanceAfter int       `json:"balance_after"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// PointsDrift - расхождение баланса по журналу с кэшированными счетчиками пользователя
+type PointsDrift struct {
+	UserID        string
+	LedgerBalance int
+	Score         int
+	StatsTotal    int
+}
+
+// VideoPointsDrift - расхождение баллов за видео по журналу с user_video_progress.points_earned
+type VideoPointsDrift struct {
+	UserID  string
+	VideoID string
+	Ledger  int
+	Cached  int
+}
+
+// ReconcileResult - сколько строк кэшей исправлено по журналу
+type ReconcileResult struct {
+	Users  int64
+	Stats  int64
+	Videos int64
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Profile - текущий пользователь со сведениями для редактирования профиля
+type Profile struct {
+	User
+	// Когда можно снова сменить username; отсутствует, если уже можно
+	NextUsernameChangeAt *time.Time `json:"next_username_change_at,omitempty"`
+}
+
+// ProfileUpdate - PATCH /api/me: меняются только переданные поля.
+// full_name: null очищает имя
+type ProfileUpdate struct {
+	Username *string        `json:"username"`
+	FullName NullableString `json:"full_name"`
+}
+
+// NullableString отличает отсутствующее поле (Set=false) от явного null (Set=true, Value=nil)
+type NullableString struct {
+	Set   bool
+	Value *string
+}
+
+func (n *NullableString) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if string(data) == "null" {
+		n.Value = nil
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	n.Value = &s
+	return nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// GoalType - чем измеряется дневная цель
+type GoalType string
+
+const (
+	GoalVideos         GoalType = "videos"
+	GoalCorrectAnswers GoalType = "correct_answers"
+)
+
+// DailyGoal - дневная цель пользователя: Target видео или правильных ответов в день
+type DailyGoal struct {
+	Type   GoalType `json:"type"`
+	Target int      `json:"target"`
+}
+
+// Level - уровень пользователя, вычисляется из суммы баллов
+type Level struct {
+	Level   int `json:"level"`
+	TotalXP int `json:"total_xp"`
+	// Опыт, набранный на текущем уровне, и нужный для перехода на следующий
+	LevelXP     int     `json:"level_xp"`
+	NextLevelXP int     `json:"next_level_xp"`
+	Progress    float64 `json:"progress"`
+}
+
+// TodayProgress - прогресс пользователя за сегодня (GET /api/me/today)
+type TodayProgress struct {
+	Date           string     `json:"date"`
+	Goal           DailyGoal  `json:"goal"`
+	VideosWatched  int        `json:"videos_watched"`
+	CorrectAnswers int        `json:"correct_answers"`
+	GoalProgress   int        `json:"goal_progress"`
+	GoalMet        bool       `json:"goal_met"`
+	GoalMetAt      *time.Time `json:"goal_met_at,omitempty"`
+	CurrentStreak  int        `json:"current_streak"`
+	BestStreak     int        `json:"best_streak"`
+	Level          Level      `json:"level"`
+}
+
+// GoalUpdate - результат учета действия в дневной цели
+type GoalUpdate struct {
+	// Цель выполнена именно этим действием
+	GoalCompleted bool `json:"goal_completed,omitempty"`
+	// Серия после выполнения цели
+	CurrentStreak int `json:"current_streak,omitempty"`
+	// Бонус за круглую дату серии
+	BonusPoints int `json:"bonus_points,omitempty"`
+}
+
+// WatchResult - ответ на отметку о просмотре видео
+type WatchResult struct {
+	VideoID string `json:"video_id"`
+	// Первый просмотр засчитывается в дневную цель, повторный - нет
+	FirstWatch bool `json:"first_watch"`
+	GoalUpdate
+	Achievements []Achievement `json:"achievements,omitempty"`
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// QuestionType - тип вопроса, определяет формат вариантов, ответа и способ проверки
+type QuestionType string
+
+const (
+	// Один правильный вариант. answer: индекс варианта, например 2
+	QuestionSingleChoice QuestionType = "single_choice"
+	// Несколько правильных вариантов. answer: индексы вариантов, например [0, 2]
+	QuestionMultipleSelect QuestionType = "multiple_select"
+	// Верно/неверно. answer: true или false
+	QuestionTrueFalse QuestionType = "true_false"
+	// Расставить варианты по порядку. answer: индексы вариантов в правильном порядке
+	QuestionOrdering QuestionType = "ordering"
+	// Число с допуском. answer: {"value": 3.14, "tolerance": 0.01}
+	QuestionNumeric QuestionType = "numeric"
+	// Короткий текст. answer: {"accepted": ["http", "hypertext transfer protocol"]}
+	QuestionShortText QuestionType = "short_text"
+)
+
+// QuizQuestion - один вопрос теста к видео
+type QuizQuestion struct {
+	ID       string       `json:"id"`
+	VideoID  string       `json:"video_id"`
+	Position int          `json:"position"`
+	Type     QuestionType `json:"type"`
+	Question string       `json:"question"`
+	Options  []string     `json:"options,omitempty"`
+	// Правильный ответ и пояснение не отдаются до ответа пользователя
+	Answer      json.RawMessage `json:"-"`
+	Explanation string          `json:"-"`
+	// Баллы с учетом калиброванной сложности; BasePoints - баллы, заданные автором
+	PointsAwarded int      `json:"points_awarded"`
+	BasePoints    int      `json:"-"`
+	Difficulty    *float64 `json:"difficulty,omitempty"`
+}
+
+// QuizQuestionInput - вопрос в запросе автора на сохранение теста
+type QuizQuestionInput struct {
+	// ID существующего вопроса: он обновляется, сохраняя ответы и калибровку,
+	// поэтому тип и правильный ответ должны остаться прежними. Без ID вопрос добавляется
+	ID          string          `json:"id,omitempty"`
+	Type        QuestionType    `json:"type"`
+	Question    string          `json:"question"`
+	Options     []string        `json:"options,omitempty"`
+	Answer      json.RawMessage `json:"answer"`
+	Explanation string          `json:"explanation"`
+	// Базовые баллы; без поля - значение по умолчанию
+	PointsAwarded *int `json:"points_awarded"`
+}
+
+type SaveQuizRequest struct {
+	Questions []QuizQuestionInput `json:"questions"`
+}
+
+type QuizAnswerRequest struct {
+	QuestionID string          `json:"question_id"`
+	Answer     json.RawMessage `json:"answer"`
+}
+
+// QuizAnswerResult - результат проверки ответа, показывается сразу после ответа
+type QuizAnswerResult struct {
+	QuestionID    string          `json:"question_id"`
+	Correct       bool            `json:"correct"`
+	PointsEarned  int             `json:"points_earned"`
+	CorrectAnswer json.RawMessage `json:"correct_answer"`
+	Explanation   string          `json:"explanation,omitempty"`
+	// Все ли вопросы к видео на текущий момент отвечены правильно
+	QuizCompleted bool      `json:"quiz_completed"`
+	AnsweredAt    time.Time `json:"answered_at"`
+	// Выполнена ли этим ответом дневная цель
+	GoalUpdate
+	// Достижения, полученные благодаря этому ответу
+	Achievements []Achievement `json:"achievements,omitempty"`
+}
+
+// AnswerObservation - первая попытка пользователя ответить на вопрос
+type AnswerObservation struct {
+	UserID     string
+	QuestionID string
+	Correct    bool
+}
+
+// QuestionCalibration - оценка сложности и различающей способности вопроса.
+// Difficulty и Discrimination пусты, пока ответов недостаточно.
+type QuestionCalibration struct {
+	QuestionID     string    `json:"question_id"`
+	Difficulty     *float64  `json:"difficulty"`
+	Discrimination *float64  `json:"discrimination"`
+	Samples        int       `json:"samples"`
+	CorrectRate    float64   `json:"correct_rate"`
+	CalibratedAt   time.Time `json:"calibrated_at"`
+}
+
+// QuestionStats - статистика вопроса для автора
+type QuestionStats struct {
+	QuestionCalibration
+	VideoID       string       `json:"video_id"`
+	VideoTitle    string       `json:"video_title"`
+	Position      int          `json:"position"`
+	Type          QuestionType `json:"type"`
+	Question      string       `json:"question"`
+	BasePoints    int          `json:"base_points"`
+	PointsAwarded int          `json:"points_awarded"`
+	// Замечания: too_easy, too_hard, ambiguous
+	Flags []string `json:"flags"`
+}
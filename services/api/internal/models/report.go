@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// ReportTargetType - на что жалуются
+type ReportTargetType string
+
+const (
+	ReportTargetVideo   ReportTargetType = "video"
+	ReportTargetComment ReportTargetType = "comment"
+)
+
+// ReportReason - категория жалобы
+type ReportReason string
+
+const (
+	ReportMisinformation ReportReason = "misinformation"
+	ReportSpam           ReportReason = "spam"
+	ReportOffensive      ReportReason = "offensive"
+	ReportCopyright      ReportReason = "copyright"
+)
+
+// ValidReportReason - известна ли категория жалобы
+func ValidReportReason(r ReportReason) bool {
+	switch r {
+	case ReportMisinformation, ReportSpam, ReportOffensive, ReportCopyright:
+		return true
+	}
+	return false
+}
+
+// ReportStatus - состояние жалобы
+type ReportStatus string
+
+const (
+	ReportOpen      ReportStatus = "open"
+	ReportUpheld    ReportStatus = "upheld"
+	ReportDismissed ReportStatus = "dismissed"
+)
+
+// ReportRequest - жалоба пользователя
+type ReportRequest struct {
+	TargetType ReportTargetType `json:"target_type"`
+	TargetID   string           `json:"target_id"`
+	Reason     ReportReason     `json:"reason"`
+	Details    string           `json:"details,omitempty"`
+}
+
+// ReportResult - итог подачи жалобы
+type ReportResult struct {
+	ReportID int64 `json:"report_id"`
+	// Пользователь уже жаловался на этот объект: новая жалоба не создана
+	Duplicate bool `json:"duplicate"`
+	// Объект скрыт до решения модератора
+	Hidden bool `json:"hidden"`
+}
+
+// ReportedTarget - объект с открытыми жалобами в очереди модерации
+type ReportedTarget struct {
+	TargetType  ReportTargetType     `json:"target_type"`
+	TargetID    string               `json:"target_id"`
+	Status      ModerationStatus     `json:"status"`
+	Reports     int                  `json:"reports"`
+	Reasons     map[ReportReason]int `json:"reasons"`
+	FirstReport time.Time            `json:"first_report_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ReviewItem - видео с тестом, который пора повторить
+type ReviewItem struct {
+	VideoWithAuthor
+	Questions    []QuizQuestion `json:"questions"`
+	DueDate      time.Time      `json:"due_date"`
+	Repetitions  int            `json:"repetitions"`
+	IntervalDays int            `json:"interval_days"`
+}
+
+// ReviewSubmitRequest - ответы на все вопросы теста при повторении
+type ReviewSubmitRequest struct {
+	Answers []QuizAnswerRequest `json:"answers"`
+}
+
+// GradedAnswer - проверенный ответ на вопрос, который еще предстоит записать
+type GradedAnswer struct {
+	Question QuizQuestion
+	Answer   json.RawMessage
+	Correct  bool
+}
+
+// ReviewResult - итог повторения и следующая дата
+type ReviewResult struct {
+	Results []QuizAnswerResult `json:"results"`
+	// Оценка SM-2 от 0 до 5 по доле правильных ответов
+	Quality      int       `json:"quality"`
+	NextDueDate  time.Time `json:"next_due_date"`
+	IntervalDays int       `json:"interval_days"`
+	// Достижения, полученные по итогам повторения
+	Achievements []Achievement `json:"achievements,omitempty"`
+}
@@ -0,0 +1,44 @@
+package models
+
+// SearchParams - параметры поиска видео (GET /api/search)
+type SearchParams struct {
+	Query string
+	// Видео должно содержать все перечисленные теги; TagSpellings - нормализованные
+	// написания каждого тега по таксономии, подходит любое из них
+	Tags          []string
+	TagSpellings  [][]string
+	AuthorID      string
+	ExpertiseArea string
+	MinDuration   int
+	MaxDuration   int
+	VerifiedOnly  bool
+	Limit         int
+	Offset        int
+}
+
+// SearchHighlight - фрагменты с подсвеченными совпадениями (<mark>...</mark>)
+type SearchHighlight struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// SearchResult - найденное видео с релевантностью и подсветкой
+type SearchResult struct {
+	VideoWithAuthor
+	Rank      float64         `json:"rank"`
+	Highlight SearchHighlight `json:"highlight"`
+}
+
+// SearchPage - страница результатов поиска
+type SearchPage struct {
+	Query   string         `json:"query"`
+	Tags    []string       `json:"tags"`
+	Total   int            `json:"total"`
+	Results []SearchResult `json:"results"`
+}
+
+// TagSuggestion - подсказка тега при вводе
+type TagSuggestion struct {
+	Tag    string `json:"tag"`
+	Videos int    `json:"videos"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// DeviceInfo - как клиент называет устройство при входе; необязательно
+type DeviceInfo struct {
+	DeviceName string `json:"device_name,omitempty"`
+	// web, ios, android, desktop или other; если не указана - определяется по User-Agent
+	Platform string `json:"platform,omitempty"`
+}
+
+// DeviceSession - устройство, на котором выполнен вход
+type DeviceSession struct {
+	ID         string     `json:"id"`
+	DeviceName *string    `json:"device_name,omitempty"`
+	Platform   string     `json:"platform"`
+	UserAgent  *string    `json:"user_agent,omitempty"`
+	IPAddress  *string    `json:"ip_address,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	// Сессия, с которой пришел запрос
+	Current bool `json:"current"`
+}
+
+// RefreshRequest - обмен refresh-токена на новую пару токенов
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenPair - access- и refresh-токены сессии
+type TokenPair struct {
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// SessionInput - данные для открытия сессии на устройстве; токены - только хэши
+type SessionInput struct {
+	UserID           string
+	AccessTokenHash  string
+	RefreshTokenHash string
+	Device           DeviceInfo
+	UserAgent        string
+	IP               string
+	AccessExpiresAt  time.Time
+	ExpiresAt        time.Time
+	// Сессия только для включения обязательной 2FA
+	EnrollmentOnly bool
+}
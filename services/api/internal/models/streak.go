@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// StreakEventType - тип записи в журнале серии
+type StreakEventType string
+
+const (
+	StreakGoalMet         StreakEventType = "goal_met"
+	StreakFreezeEarned    StreakEventType = "freeze_earned"
+	StreakFreezePurchased StreakEventType = "freeze_purchased"
+	StreakFreezeUsed      StreakEventType = "freeze_used"
+	StreakBroken          StreakEventType = "broken"
+	StreakRepaired        StreakEventType = "repaired"
+)
+
+// StreakEvent - запись журнала серии
+type StreakEvent struct {
+	Type         StreakEventType `json:"type"`
+	Date         string          `json:"date"`
+	StreakBefore int             `json:"streak_before"`
+	StreakAfter  int             `json:"streak_after"`
+	FreezesAfter int             `json:"freezes_after"`
+	PointsSpent  int             `json:"points_spent,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// StreakRepair - доступное восстановление прерванной серии
+type StreakRepair struct {
+	LostStreak int       `json:"lost_streak"`
+	Cost       int       `json:"cost"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// StreakOverview - серия, заморозки и последние события (GET /api/me/streak)
+type StreakOverview struct {
+	CurrentStreak int           `json:"current_streak"`
+	BestStreak    int           `json:"best_streak"`
+	Freezes       int           `json:"freezes"`
+	MaxFreezes    int           `json:"max_freezes"`
+	FreezeCost    int           `json:"freeze_cost"`
+	Points        int           `json:"points"`
+	Repair        *StreakRepair `json:"repair,omitempty"`
+	History       []StreakEvent `json:"history"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// Topic - область экспертизы авторов как раздел навигации
+type Topic struct {
+	Name    string `json:"name"`
+	Videos  int    `json:"videos"`
+	Authors int    `json:"authors"`
+}
+
+// TagNode - канонический тег таксономии со всеми написаниями, которые к нему сводятся
+type TagNode struct {
+	Slug     string   `json:"slug"`
+	Name     string   `json:"name"`
+	Variants []string `json:"variants"`
+	Videos   int      `json:"videos"`
+}
+
+// FeedFilter - ограничение ленты разделом: областью экспертизы или набором написаний тега
+type FeedFilter struct {
+	ExpertiseArea string
+	// Видео подходит, если содержит тег в любом из нормализованных написаний
+	// (taxonomy.Normalize)
+	AnyTags []string
+	// Зритель для персонализации ранжирования (пусто - без персонализации)
+	ViewerID string
+}
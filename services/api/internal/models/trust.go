@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Уровни доверия автора (authors.trust_tier), от низшего к высшему
+const (
+	TrustTierBronze   = "bronze"
+	TrustTierSilver   = "silver"
+	TrustTierGold     = "gold"
+	TrustTierPlatinum = "platinum"
+)
+
+// AuthorTrustInputs - измеримые сигналы, из которых считается уровень доверия
+type AuthorTrustInputs struct {
+	AuthorID    string `json:"author_id"`
+	CurrentTier string `json:"current_tier"`
+	ManualTier  bool   `json:"manual_tier"`
+	IsVerified  bool   `json:"is_verified"`
+
+	// Модерация: сколько видео загружено и сколько отклонено
+	VideosTotal    int `json:"videos_total"`
+	VideosRejected int `json:"videos_rejected"`
+
+	// Зрители (уникальные пользователи), просмотры (записи прогресса) и жалобы
+	Viewers int `json:"viewers"`
+	Views   int `json:"views"`
+	Reports int `json:"reports"`
+
+	// Прохождение тестов к видео автора и среднее по платформе
+	QuizAttempts        int     `json:"quiz_attempts"`
+	QuizCorrect         int     `json:"quiz_correct"`
+	PlatformCorrectRate float64 `json:"platform_correct_rate"`
+
+	// Досмотры: записи прогресса с is_watched = true
+	WatchedCount int `json:"watched_count"`
+}
+
+// TrustEvaluation - результат одного пересчета уровня доверия
+type TrustEvaluation struct {
+	AuthorID     string            `json:"author_id"`
+	PreviousTier string            `json:"previous_tier"`
+	Tier         string            `json:"tier"`
+	Score        float64           `json:"score"`
+	Reasons      []string          `json:"reasons"`
+	Inputs       AuthorTrustInputs `json:"inputs"`
+	EvaluatedAt  time.Time         `json:"evaluated_at"`
+}
+
+// Changed сообщает, изменился ли уровень по итогам пересчета
+func (e TrustEvaluation) Changed() bool {
+	return e.PreviousTier != e.Tier
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// TwoFactorEnrollment - секрет для приложения-аутентификатора; 2FA включится после первого кода
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// TwoFactorCodeRequest - код из приложения или резервный код
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// RecoveryCodes показываются пользователю один раз, в базе хранятся только хэши
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+	// Сколько сессий закрыто при включении 2FA
+	RevokedSessions int64 `json:"revoked_sessions,omitempty"`
+}
+
+// LoginChallenge - пароль верный, для входа нужен второй фактор
+type LoginChallenge struct {
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	ChallengeToken    string    `json:"challenge_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+// LoginTwoFactorRequest - второй шаг входа
+type LoginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	DeviceInfo
+}
+
+// TwoFactorRequirement - обязательна ли 2FA для роли
+type TwoFactorRequirement struct {
+	Role      Role      `json:"role"`
+	Required  bool      `json:"required"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -1,22 +1,40 @@
 package models
 
-import (
-	"time"
+import "time"
 
-	"golang.org/x/crypto/bcrypt"
+type User struct {
+	ID            string  `json:"id"`
+	Email         string  `json:"email"`
+	Username      string  `json:"username"`
+	PasswordHash  string  `json:"-"`
+	FullName      *string `json:"full_name,omitempty"`
+	Score         int     `json:"score"`
+	CurrentStreak int     `json:"current_streak"`
+	BestStreak    int     `json:"best_streak"`
+	Role          Role    `json:"role"`
+	// nil - email еще не подтвержден
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	AvatarURL       *string    `json:"avatar_url,omitempty"`
+	// Последняя смена username
+	UsernameChangedAt *time.Time `json:"username_changed_at,omitempty"`
+	// Запрошенное удаление учетной записи: до этого момента его можно отменить
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// Role - роль пользователя
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
 )
 
-type User struct {
-	ID            string    `json:"id"`
-	Email         string    `json:"email"`
-	Username      string    `json:"username"`
-	PasswordHash  string    `json:"-"`
-	FullName      *string   `json:"full_name,omitempty"`
-	Score         int       `json:"score"`
-	CurrentStreak int       `json:"current_streak"`
-	BestStreak    int       `json:"best_streak"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+// CanModerate - может ли роль модерировать видео и комментарии
+func (r Role) CanModerate() bool {
+	return r == RoleModerator || r == RoleAdmin
 }
 
 type RegisterRequest struct {
@@ -26,17 +44,38 @@ type RegisterRequest struct {
 	FullName *string `json:"full_name,omitempty"`
 }
 
+// LoginRequest - вход по email или имени пользователя
+type LoginRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	DeviceInfo
+}
+
+// LoginResponse - токены новой сессии
+type LoginResponse struct {
+	TokenPair
+	User User `json:"user"`
+	// Роль требует 2FA, а она не включена: сессия годится только для ее включения
+	TwoFactorEnrollmentRequired bool `json:"two_factor_enrollment_required,omitempty"`
+}
+
+// ChangePasswordRequest - смена пароля с подтверждением текущего
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// ResetPasswordRequest - новый пароль по токену из письма
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
 type APIResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message"`
 	Data    any    `json:"data,omitempty"`
 	Error   string `json:"error,omitempty"`
-}
-
-func HashPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-	return string(hash), nil
+	// Машиночитаемый код ошибки, например password_breached
+	Code string `json:"code,omitempty"`
 }
@@ -10,6 +10,7 @@ type Video struct {
     ThumbnailURL string   `json:"thumbnail_url,omitempty"`
     DurationSec int       `json:"duration_sec"`
     Tags        []string  `json:"tags"`
+    LikesCount  int       `json:"likes_count"`
     CreatedAt   time.Time `json:"created_at"`
 }
 
@@ -19,6 +20,7 @@ type Author struct {
     ExpertiseArea string `json:"expertise_area"`
     TrustTier    string `json:"trust_tier"`
     IsVerified   bool   `json:"is_verified"`
+    FollowersCount int  `json:"followers_count"`
 }
 
 // VideoWithAuthor - объединенные данные для ленты
@@ -26,10 +28,3 @@ type VideoWithAuthor struct {
     Video  `json:",inline"`
     Author Author `json:"author"`
 }
-
-type Quiz struct {
-    Question      string   `json:"question"`
-    CorrectAnswer string   `json:"correct_answer"`
-    WrongAnswers  []string `json:"wrong_answers"`
-    PointsAwarded int      `json:"points_awarded"`
-}
\ No newline at end of file
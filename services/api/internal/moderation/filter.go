@@ -0,0 +1,72 @@
+package moderation
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Фильтр по ключевым словам: комментарий с совпадением не публикуется сразу,
+// а уходит модератору. Слова сравниваются целиком без учета регистра и ё/е,
+// поэтому "казино" не срабатывает на "показино", а фраза ищется как последовательность слов.
+
+// Config - список слов и фраз, при которых комментарий ждет модератора
+type Config struct {
+	Keywords []string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Keywords: []string{
+			"казино",
+			"ставки на спорт",
+			"заработок без вложений",
+			"быстрый заработок",
+			"пассивный доход",
+			"casino",
+			"betting",
+			"crypto giveaway",
+			"free money",
+			"t.me",
+		},
+	}
+}
+
+// Filter проверяет текст по списку слов
+type Filter struct {
+	keywords []string
+}
+
+func NewFilter(cfg Config) *Filter {
+	f := &Filter{}
+	for _, kw := range cfg.Keywords {
+		if kw = normalize(kw); kw != "" {
+			f.keywords = append(f.keywords, kw)
+		}
+	}
+	return f
+}
+
+// Check возвращает сработавшие слова; пустой результат - текст можно публиковать
+func (f *Filter) Check(text string) []string {
+	padded := " " + normalize(text) + " "
+	var matched []string
+	for _, kw := range f.keywords {
+		if strings.Contains(padded, " "+kw+" ") {
+			matched = append(matched, kw)
+		}
+	}
+	return matched
+}
+
+// normalize оставляет слова из букв и цифр (точка внутри слова сохраняется для ссылок),
+// приводит их к нижнему регистру и разделяет одиночными пробелами
+func normalize(text string) string {
+	text = strings.ReplaceAll(strings.ToLower(text), "ё", "е")
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.'
+	})
+	for i, w := range words {
+		words[i] = strings.Trim(w, ".")
+	}
+	return strings.Join(strings.Fields(strings.Join(words, " ")), " ")
+}
@@ -0,0 +1,16 @@
+package moderation
+
+// ReportConfig - когда жалобы скрывают контент до решения модератора
+type ReportConfig struct {
+	// Число открытых жалоб от разных пользователей, после которого объект скрывается
+	AutoHideThreshold int
+	// Максимальная длина пояснения к жалобе
+	MaxDetailsLength int
+}
+
+func DefaultReportConfig() ReportConfig {
+	return ReportConfig{
+		AutoHideThreshold: 3,
+		MaxDetailsLength:  1000,
+	}
+}
@@ -0,0 +1,64 @@
+package oidc
+
+import (
+	"errors"
+	"time"
+)
+
+// Вход через внешних провайдеров OpenID Connect: authorization code + PKCE (S256),
+// ID-токен проверяется по ключам JWKS провайдера (RS256).
+
+var (
+	// ErrUnknownProvider - провайдер с таким именем не настроен
+	ErrUnknownProvider = errors.New("unknown identity provider")
+	// ErrInvalidToken - ID-токен не прошел проверку подписи или claims
+	ErrInvalidToken = errors.New("invalid id token")
+)
+
+// ProviderConfig - настройки одного провайдера. Адреса endpoint'ов берутся
+// из /.well-known/openid-configuration издателя
+type ProviderConfig struct {
+	// Имя в URL: /api/auth/oidc/{name}/...
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// Страница клиента, куда провайдер вернет code и state
+	RedirectURL string
+	Scopes      []string
+}
+
+// Config - провайдеры и сроки
+type Config struct {
+	Providers []ProviderConfig
+	// Сколько ждать возврата пользователя от провайдера
+	StateTTL time.Duration
+	// Допустимое расхождение часов при проверке exp/iat
+	ClockSkew   time.Duration
+	HTTPTimeout time.Duration
+}
+
+// DefaultConfig - для разработки настроен локальный издатель из cmd/mock-oidc
+func DefaultConfig() Config {
+	return Config{
+		Providers: []ProviderConfig{{
+			Name:        "mock",
+			Issuer:      "http://localhost:9090",
+			ClientID:    "mindly-dev",
+			RedirectURL: "http://localhost:3000/auth/callback/mock",
+			Scopes:      []string{"openid", "email", "profile"},
+		}},
+		StateTTL:    10 * time.Minute,
+		ClockSkew:   time.Minute,
+		HTTPTimeout: 10 * time.Second,
+	}
+}
+
+// Claims - то, что нужно из ID-токена для входа
+type Claims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
@@ -0,0 +1,220 @@
+package mockissuer
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mindly/api/internal/oidc"
+)
+
+// Локальный издатель OpenID Connect для разработки и проверки входа без сети.
+// Страницы входа нет: /authorize сразу возвращает code для пользователя из login_hint
+// (или пользователя по умолчанию). PKCE (S256), nonce и одноразовость code проверяются
+// так же строго, как у настоящих провайдеров.
+
+const (
+	keyID   = "mock-key-1"
+	codeTTL = time.Minute
+	idTTL   = 5 * time.Minute
+)
+
+// Identity - пользователь, от имени которого издатель выдает ID-токены
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type authCode struct {
+	clientID    string
+	redirectURI string
+	challenge   string
+	nonce       string
+	identity    Identity
+	expiresAt   time.Time
+}
+
+// Issuer - издатель с собственным RSA-ключом, создаваемым при запуске
+type Issuer struct {
+	issuer   string
+	clientID string
+	identity Identity
+	key      *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]authCode
+}
+
+// New создает издателя. issuer - внешний адрес, под которым он доступен клиентам
+func New(issuer, clientID string, identity Identity) (*Issuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return &Issuer{
+		issuer:   strings.TrimSuffix(issuer, "/"),
+		clientID: clientID,
+		identity: identity,
+		key:      key,
+		codes:    make(map[string]authCode),
+	}, nil
+}
+
+// Handler - endpoint'ы издателя
+func (i *Issuer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", i.discovery)
+	mux.HandleFunc("GET /authorize", i.authorize)
+	mux.HandleFunc("POST /token", i.token)
+	mux.HandleFunc("GET /jwks", i.jwks)
+	return mux
+}
+
+func (i *Issuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                i.issuer,
+		"authorization_endpoint":                i.issuer + "/authorize",
+		"token_endpoint":                        i.issuer + "/token",
+		"jwks_uri":                              i.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (i *Issuer) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []oidc.JWK{oidc.PublicJWK(keyID, &i.key.PublicKey)},
+	})
+}
+
+// authorize сразу "входит" и перенаправляет на redirect_uri с code и state.
+// ?login_hint=email выбирает пользователя, ?email_verified=false - неподтвержденный email
+func (i *Issuer) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("response_type") != "code" || q.Get("client_id") != i.clientID {
+		http.Error(w, "unsupported response_type or unknown client_id", http.StatusBadRequest)
+		return
+	}
+	if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+		return
+	}
+	redirectURI, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || !redirectURI.IsAbs() {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	identity := i.identity
+	if hint := strings.ToLower(strings.TrimSpace(q.Get("login_hint"))); hint != "" {
+		identity = Identity{Subject: "mock|" + hint, Email: hint, EmailVerified: true, Name: hint}
+	}
+	if q.Get("email_verified") == "false" {
+		identity.EmailVerified = false
+	}
+
+	code, err := oidc.RandomString()
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	i.mu.Lock()
+	i.codes[code] = authCode{
+		clientID:    i.clientID,
+		redirectURI: q.Get("redirect_uri"),
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		identity:    identity,
+		expiresAt:   time.Now().Add(codeTTL),
+	}
+	i.mu.Unlock()
+
+	back := redirectURI.Query()
+	back.Set("code", code)
+	back.Set("state", q.Get("state"))
+	redirectURI.RawQuery = back.Encode()
+
+	log.Printf("🪪 mock-oidc: вход %s (%s)", identity.Email, identity.Subject)
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+// token меняет code на ID-токен после проверки PKCE
+func (i *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type")
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	i.mu.Lock()
+	ac, ok := i.codes[code]
+	delete(i.codes, code) // code одноразовый даже при неудачной попытке
+	i.mu.Unlock()
+
+	switch {
+	case !ok || time.Now().After(ac.expiresAt):
+		tokenError(w, "invalid_grant")
+		return
+	case r.PostForm.Get("client_id") != ac.clientID || r.PostForm.Get("redirect_uri") != ac.redirectURI:
+		tokenError(w, "invalid_grant")
+		return
+	case oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != ac.challenge:
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	idToken, err := oidc.SignRS256(i.key, keyID, map[string]any{
+		"iss":            i.issuer,
+		"sub":            ac.identity.Subject,
+		"aud":            ac.clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(idTTL).Unix(),
+		"nonce":          ac.nonce,
+		"email":          ac.identity.Email,
+		"email_verified": ac.identity.EmailVerified,
+		"name":           ac.identity.Name,
+	})
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	accessToken, err := oidc.RandomString()
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(idTTL / time.Second),
+		"id_token":     idToken,
+	})
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("❌ mock-oidc: error encoding response: %v", err)
+	}
+}
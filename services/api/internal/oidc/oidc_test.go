@@ -0,0 +1,318 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mindly/api/internal/oidc"
+	"github.com/mindly/api/internal/oidc/mockissuer"
+)
+
+const (
+	testClientID = "mindly-test"
+	testRedirect = "http://client.test/auth/callback/mock"
+)
+
+// startMockIssuer поднимает локальный издатель и провайдера, настроенного на него
+func startMockIssuer(t *testing.T) *oidc.Provider {
+	t.Helper()
+
+	var handler http.Handler
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	issuer, err := mockissuer.New(srv.URL, testClientID, mockissuer.Identity{
+		Subject:       "mock|default",
+		Email:         "default@mindly.test",
+		EmailVerified: true,
+		Name:          "Default",
+	})
+	if err != nil {
+		t.Fatalf("create mock issuer: %v", err)
+	}
+	handler = issuer.Handler()
+
+	return newProvider(t, srv.URL)
+}
+
+func newProvider(t *testing.T, issuer string) *oidc.Provider {
+	t.Helper()
+
+	reg, err := oidc.NewRegistry(oidc.Config{
+		Providers: []oidc.ProviderConfig{{
+			Name:        "mock",
+			Issuer:      issuer,
+			ClientID:    testClientID,
+			RedirectURL: testRedirect,
+			Scopes:      []string{"openid", "email", "profile"},
+		}},
+		StateTTL:    time.Minute,
+		ClockSkew:   time.Minute,
+		HTTPTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("create registry: %v", err)
+	}
+	p, err := reg.Get("mock")
+	if err != nil {
+		t.Fatalf("get provider: %v", err)
+	}
+	return p
+}
+
+// authorize проходит страницу входа издателя и возвращает code из адреса возврата
+func authorize(t *testing.T, p *oidc.Provider, state, nonce, verifier string, extra url.Values) string {
+	t.Helper()
+
+	authURL, err := p.AuthCodeURL(context.Background(), state, nonce, verifier)
+	if err != nil {
+		t.Fatalf("auth code url: %v", err)
+	}
+	if len(extra) > 0 {
+		authURL += "&" + extra.Encode()
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize returned %d, want 302", resp.StatusCode)
+	}
+
+	back, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("parse redirect: %v", err)
+	}
+	if !strings.HasPrefix(back.String(), testRedirect) {
+		t.Fatalf("redirected to %s, want %s", back, testRedirect)
+	}
+	if got := back.Query().Get("state"); got != state {
+		t.Fatalf("state = %q, want %q", got, state)
+	}
+	code := back.Query().Get("code")
+	if code == "" {
+		t.Fatal("no code in redirect")
+	}
+	return code
+}
+
+func randomString(t *testing.T) string {
+	t.Helper()
+	s, err := oidc.RandomString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestExchange(t *testing.T) {
+	p := startMockIssuer(t)
+	state, nonce, verifier := randomString(t), randomString(t), randomString(t)
+
+	code := authorize(t, p, state, nonce, verifier, url.Values{"login_hint": {"Ann@Mindly.test"}})
+	claims, err := p.Exchange(context.Background(), code, verifier, nonce)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	if claims.Subject != "mock|ann@mindly.test" || claims.Email != "ann@mindly.test" || !claims.EmailVerified {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+
+	// code одноразовый
+	if _, err := p.Exchange(context.Background(), code, verifier, nonce); err == nil {
+		t.Error("second exchange of the same code succeeded")
+	}
+}
+
+func TestExchangeUnverifiedEmail(t *testing.T) {
+	p := startMockIssuer(t)
+	nonce, verifier := randomString(t), randomString(t)
+
+	code := authorize(t, p, randomString(t), nonce, verifier, url.Values{"email_verified": {"false"}})
+	claims, err := p.Exchange(context.Background(), code, verifier, nonce)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	if claims.EmailVerified {
+		t.Error("email_verified=false reported as verified")
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	p := startMockIssuer(t)
+	nonce, verifier := randomString(t), randomString(t)
+
+	code := authorize(t, p, randomString(t), nonce, verifier, nil)
+	_, err := p.Exchange(context.Background(), code, randomString(t), nonce)
+	if err == nil {
+		t.Fatal("exchange with a wrong code_verifier succeeded")
+	}
+	if !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("error = %v, want invalid_grant from the token endpoint", err)
+	}
+}
+
+func TestExchangeRejectsWrongNonce(t *testing.T) {
+	p := startMockIssuer(t)
+	verifier := randomString(t)
+
+	code := authorize(t, p, randomString(t), randomString(t), verifier, nil)
+	_, err := p.Exchange(context.Background(), code, verifier, randomString(t))
+	if !errors.Is(err, oidc.ErrInvalidToken) {
+		t.Errorf("error = %v, want ErrInvalidToken", err)
+	}
+}
+
+// keyIssuer - издатель с известным тесту ключом: ID-токены подписываются в самом тесте
+type keyIssuer struct {
+	url string
+	kid string
+	key *rsa.PrivateKey
+}
+
+func startKeyIssuer(t *testing.T) *keyIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ki := &keyIssuer{kid: "test-key", key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 ki.url,
+			"authorization_endpoint": ki.url + "/authorize",
+			"token_endpoint":         ki.url + "/token",
+			"jwks_uri":               ki.url + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []oidc.JWK{oidc.PublicJWK(ki.kid, &key.PublicKey)}})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	ki.url = srv.URL
+	return ki
+}
+
+func (ki *keyIssuer) claims(now time.Time, nonce string) map[string]any {
+	return map[string]any{
+		"iss":            ki.url,
+		"sub":            "subject-1",
+		"aud":            testClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          nonce,
+		"email":          "user@mindly.test",
+		"email_verified": true,
+	}
+}
+
+// unsignedToken собирает токен с произвольным заголовком и подписью
+func unsignedToken(t *testing.T, header, claims any, sig string) string {
+	t.Helper()
+	h, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c) + "." + sig
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	ki := startKeyIssuer(t)
+	p := newProvider(t, ki.url)
+	now := time.Now()
+	const nonce = "nonce-1"
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(key *rsa.PrivateKey, kid string, claims map[string]any) string {
+		raw, err := oidc.SignRS256(key, kid, claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	with := func(name string, value any) map[string]any {
+		c := ki.claims(now, nonce)
+		c[name] = value
+		return c
+	}
+
+	valid := sign(ki.key, ki.kid, ki.claims(now, nonce))
+	claims, err := p.VerifyIDToken(context.Background(), valid, nonce, now)
+	if err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+	if claims.Subject != "subject-1" || claims.Email != "user@mindly.test" || !claims.EmailVerified {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+
+	parts := strings.Split(valid, ".")
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"bad signature", sign(otherKey, ki.kid, ki.claims(now, nonce))},
+		{"tampered payload", parts[0] + "." + strings.Split(sign(ki.key, ki.kid, with("sub", "admin")), ".")[1] + "." + parts[2]},
+		{"unknown kid", sign(ki.key, "other-key", ki.claims(now, nonce))},
+		{"alg none", unsignedToken(t, map[string]string{"alg": "none", "kid": ki.kid}, ki.claims(now, nonce), "")},
+		{"alg HS256", unsignedToken(t, map[string]string{"alg": "HS256", "kid": ki.kid}, ki.claims(now, nonce), parts[2])},
+		{"wrong audience", sign(ki.key, ki.kid, with("aud", "another-client"))},
+		{"wrong issuer", sign(ki.key, ki.kid, with("iss", "https://evil.test"))},
+		{"wrong nonce", sign(ki.key, ki.kid, with("nonce", "nonce-2"))},
+		{"expired", sign(ki.key, ki.kid, with("exp", now.Add(-2*time.Minute).Unix()))},
+		{"issued in the future", sign(ki.key, ki.kid, with("iat", now.Add(10*time.Minute).Unix()))},
+		{"malformed", "not-a-jwt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.VerifyIDToken(context.Background(), tt.token, nonce, now)
+			if !errors.Is(err, oidc.ErrInvalidToken) {
+				t.Errorf("error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenAudienceList(t *testing.T) {
+	ki := startKeyIssuer(t)
+	p := newProvider(t, ki.url)
+	now := time.Now()
+
+	c := ki.claims(now, "n")
+	c["aud"] = []string{"another-client", testClientID}
+	raw, err := oidc.SignRS256(ki.key, ki.kid, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.VerifyIDToken(context.Background(), raw, "n", now); err != nil {
+		t.Errorf("token with client in aud list rejected: %v", err)
+	}
+}
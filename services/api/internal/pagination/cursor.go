@@ -0,0 +1,54 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Курсорная пагинация по паре (created_at, id): в отличие от offset, новые записи
+// не сдвигают страницы и не дают дублей при прокрутке.
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Формат временной метки в курсоре: колонки TIMESTAMP хранят микросекунды без часового пояса
+const timestampLayout = "2006-01-02T15:04:05.999999"
+
+// Cursor - позиция последней выданной записи
+type Cursor struct {
+	At time.Time
+	ID string
+}
+
+// Encode упаковывает курсор в непрозрачную строку для клиента
+func (c Cursor) Encode() string {
+	raw := c.At.UTC().Format(timestampLayout) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Timestamp - время курсора в виде, пригодном для сравнения с колонкой TIMESTAMP ($n::timestamp)
+func (c Cursor) Timestamp() string {
+	return c.At.UTC().Format(timestampLayout)
+}
+
+// Decode разбирает строку курсора; пустая строка означает первую страницу (nil)
+func Decode(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	at, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, ErrInvalidCursor
+	}
+	t, err := time.Parse(timestampLayout, at)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{At: t, ID: id}, nil
+}
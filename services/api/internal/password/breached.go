@@ -0,0 +1,79 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"strings"
+	"sync"
+)
+
+// BreachChecker отдает суффиксы SHA-1 утекших паролей по префиксу из 5 hex-символов
+// (k-anonymity, как range API HIBP): сам пароль и его полный хэш наружу не уходят
+type BreachChecker interface {
+	Range(ctx context.Context, prefix string) ([]string, error)
+}
+
+const breachPrefixLen = 5
+
+// IsBreached проверяет пароль по списку утечек
+func IsBreached(ctx context.Context, c BreachChecker, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:breachPrefixLen], hash[breachPrefixLen:]
+
+	suffixes, err := c.Range(ctx, prefix)
+	if err != nil {
+		return false, err
+	}
+	for _, s := range suffixes {
+		if s == suffix {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+//go:embed breached_sha1.txt
+var breachedSHA1 string
+
+// rangeList - список утечек в памяти: префикс -> суффиксы
+type rangeList map[string][]string
+
+func (l rangeList) Range(_ context.Context, prefix string) ([]string, error) {
+	return l[strings.ToUpper(prefix)], nil
+}
+
+var (
+	embeddedOnce sync.Once
+	embedded     rangeList
+)
+
+// EmbeddedBreachList - офлайн-список из breached_sha1.txt, собранного вместе с сервисом.
+// Строки вида PREFIX:SUFFIX, # - комментарий
+func EmbeddedBreachList() BreachChecker {
+	embeddedOnce.Do(func() {
+		embedded = parseRangeList(breachedSHA1)
+	})
+	return embedded
+}
+
+func parseRangeList(data string) rangeList {
+	list := rangeList{}
+	sc := bufio.NewScanner(strings.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		prefix, suffix, ok := strings.Cut(line, ":")
+		if !ok || len(prefix) != breachPrefixLen {
+			continue
+		}
+		prefix = strings.ToUpper(prefix)
+		list[prefix] = append(list[prefix], strings.ToUpper(suffix))
+	}
+	return list
+}
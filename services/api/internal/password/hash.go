@@ -0,0 +1,204 @@
+package password
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Алгоритмы хэширования; алгоритм сохраненного хэша определяется по его виду
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+	// Несоленый MD5 из старого scripts/seed.go; только проверка, новые хэши так не создаются
+	AlgorithmLegacyMD5 = "md5"
+)
+
+// NoPassword - значение password_hash у пользователей без пароля (вход только через
+// внешнего провайдера): ни один пароль с ним не совпадает
+const NoPassword = "!"
+
+// ErrUnknownHash - сохраненный хэш не похож ни на один поддерживаемый формат
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// Argon2Params - параметры argon2id; память в KiB
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  int
+	KeyLength   uint32
+}
+
+// HashConfig - каким алгоритмом и с какой стоимостью хэшировать новые пароли.
+// Хэши со старым алгоритмом или параметрами пересчитываются при входе
+type HashConfig struct {
+	Algorithm  string
+	Argon2     Argon2Params
+	BcryptCost int
+}
+
+func DefaultHashConfig() HashConfig {
+	return HashConfig{
+		Algorithm: AlgorithmArgon2id,
+		Argon2: Argon2Params{
+			Memory:      64 * 1024,
+			Iterations:  3,
+			Parallelism: 2,
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+		BcryptCost: bcrypt.DefaultCost,
+	}
+}
+
+// MaxPasswordBytes - предел длины пароля в байтах для текущего алгоритма (0 - нет):
+// bcrypt молча обрезает пароль после 72 байт
+func (c HashConfig) MaxPasswordBytes() int {
+	if c.Algorithm == AlgorithmBcrypt {
+		return 72
+	}
+	return 0
+}
+
+// Hasher создает хэши паролей и проверяет хэши любого поддерживаемого формата
+type Hasher struct {
+	cfg HashConfig
+}
+
+func NewHasher(cfg HashConfig) (*Hasher, error) {
+	switch cfg.Algorithm {
+	case AlgorithmArgon2id, AlgorithmBcrypt:
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm %q", cfg.Algorithm)
+	}
+	return &Hasher{cfg: cfg}, nil
+}
+
+// Hash хэширует пароль текущим алгоритмом
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.cfg.Algorithm == AlgorithmBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cfg.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	}
+
+	p := h.cfg.Argon2
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("generate salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	// Формат PHC, как у эталонной реализации argon2
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify проверяет пароль по сохраненному хэшу. needsRehash - пароль верный,
+// но хэш сделан другим алгоритмом или с другими параметрами и его стоит пересчитать
+func (h *Hasher) Verify(encoded, password string) (ok, needsRehash bool, err error) {
+	if encoded == NoPassword {
+		return false, false, nil
+	}
+
+	switch Algorithm(encoded) {
+	case AlgorithmArgon2id:
+		p, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return false, false, err
+		}
+		got := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(got, key) != 1 {
+			return false, false, nil
+		}
+		cur := h.cfg.Argon2
+		stale := h.cfg.Algorithm != AlgorithmArgon2id ||
+			p.Memory != cur.Memory || p.Iterations != cur.Iterations || p.Parallelism != cur.Parallelism ||
+			len(salt) != cur.SaltLength || uint32(len(key)) != cur.KeyLength
+		return true, stale, nil
+
+	case AlgorithmBcrypt:
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return false, false, err
+		}
+		return true, h.cfg.Algorithm != AlgorithmBcrypt || cost != h.cfg.BcryptCost, nil
+
+	case AlgorithmLegacyMD5:
+		sum := md5.Sum([]byte(password))
+		if subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(strings.ToLower(encoded))) != 1 {
+			return false, false, nil
+		}
+		return true, true, nil
+	}
+	return false, false, ErrUnknownHash
+}
+
+// Algorithm определяет алгоритм по виду сохраненного хэша; "" - формат неизвестен
+func Algorithm(encoded string) string {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return AlgorithmArgon2id
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return AlgorithmBcrypt
+	case len(encoded) == md5.Size*2 && isHex(encoded):
+		return AlgorithmLegacyMD5
+	}
+	return ""
+}
+
+func decodeArgon2id(encoded string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("unsupported argon2 version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, fmt.Errorf("parse argon2 params: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("decode salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("decode key: %w", err)
+	}
+	if len(key) == 0 {
+		return p, nil, nil, ErrUnknownHash
+	}
+	p.SaltLength = len(salt)
+	p.KeyLength = uint32(len(key))
+	return p, salt, key, nil
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,51 @@
+package password
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/mindly/api/internal/mailer"
+)
+
+// ResetConfig - срок жизни ссылки сброса и ограничения на число писем
+type ResetConfig struct {
+	TokenTTL time.Duration
+	// Минимальная пауза между письмами одному пользователю
+	Cooldown time.Duration
+	// Сколько писем можно отправить за последние 24 часа
+	MaxPerDay int
+	// Страница сброса пароля в клиенте; токен добавляется параметром token
+	LinkBaseURL string
+}
+
+func DefaultResetConfig() ResetConfig {
+	return ResetConfig{
+		TokenTTL:    time.Hour,
+		Cooldown:    time.Minute,
+		MaxPerDay:   5,
+		LinkBaseURL: "http://localhost:3000/reset-password",
+	}
+}
+
+// ResetAllowed - можно ли отправить еще одно письмо сброса
+func ResetAllowed(cfg ResetConfig, lastSentAt *time.Time, sentToday int, now time.Time) bool {
+	if lastSentAt != nil && now.Before(lastSentAt.Add(cfg.Cooldown)) {
+		return false
+	}
+	return sentToday < cfg.MaxPerDay
+}
+
+// ResetEmail собирает письмо со ссылкой сброса пароля
+func ResetEmail(cfg ResetConfig, to, username, token string) mailer.Message {
+	link := cfg.LinkBaseURL + "?token=" + url.QueryEscape(token)
+	return mailer.Message{
+		To:      to,
+		Subject: "Сброс пароля в Mindly",
+		Text: fmt.Sprintf(
+			"Здравствуйте, %s!\n\nЧтобы задать новый пароль, откройте ссылку:\n%s\n\n"+
+				"Ссылка одноразовая и действует %d мин. Если вы не запрашивали сброс, "+
+				"проигнорируйте письмо: пароль останется прежним.\n",
+			username, link, int(cfg.TokenTTL/time.Minute)),
+	}
+}
@@ -0,0 +1,123 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+)
+
+// Коды ошибок политики паролей; клиент показывает по ним подсказку
+const (
+	CodeRequired         = "password_required"
+	CodeTooShort         = "password_too_short"
+	CodeTooLong          = "password_too_long"
+	CodeContainsIdentity = "password_contains_identity"
+	CodeBreached         = "password_breached"
+)
+
+// PolicyError - пароль не прошел политику
+type PolicyError struct {
+	Code    string
+	Message string
+}
+
+func (e *PolicyError) Error() string { return e.Message }
+
+// Policy - требования к новому паролю
+type Policy struct {
+	// Длина в символах (рунах), а не в байтах
+	MinLength int
+	MaxLength int
+	// Ограничение в байтах UTF-8, см. HashConfig.MaxPasswordBytes. 0 - без ограничения
+	MaxBytes int
+	// Запрещать пароли, содержащие имя пользователя или email
+	ForbidIdentity bool
+	// Проверка по утекшим паролям; nil - не проверять
+	Breached BreachChecker
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		MinLength:      6,
+		MaxLength:      64,
+		ForbidIdentity: true,
+		Breached:       EmbeddedBreachList(),
+	}
+}
+
+// Identity - данные аккаунта, которые не должны входить в пароль
+type Identity struct {
+	Username string
+	Email    string
+}
+
+// Части логина короче этого не проверяются: иначе запрещается слишком много паролей
+const minIdentityPart = 3
+
+// Check проверяет пароль по политике. Ошибка политики - *PolicyError,
+// остальные ошибки - сбой проверки утечек
+func (p Policy) Check(ctx context.Context, password string, id Identity) error {
+	if strings.TrimSpace(password) == "" {
+		return &PolicyError{Code: CodeRequired, Message: "password is required"}
+	}
+
+	n := utf8.RuneCountInString(password)
+	if n < p.MinLength {
+		return &PolicyError{Code: CodeTooShort,
+			Message: fmt.Sprintf("password must be at least %d characters", p.MinLength)}
+	}
+	if (p.MaxLength > 0 && n > p.MaxLength) || (p.MaxBytes > 0 && len(password) > p.MaxBytes) {
+		return &PolicyError{Code: CodeTooLong, Message: p.tooLongMessage()}
+	}
+
+	if p.ForbidIdentity && containsIdentity(password, id) {
+		return &PolicyError{Code: CodeContainsIdentity,
+			Message: "password must not contain your username or email"}
+	}
+
+	if p.Breached != nil {
+		breached, err := IsBreached(ctx, p.Breached, password)
+		if err != nil {
+			return fmt.Errorf("breach check: %w", err)
+		}
+		if breached {
+			return &PolicyError{Code: CodeBreached,
+				Message: "this password has appeared in a data breach, choose another one"}
+		}
+	}
+	return nil
+}
+
+func (p Policy) tooLongMessage() string {
+	if p.MaxBytes > 0 {
+		return fmt.Sprintf("password must be at most %d characters (%d bytes)", p.MaxLength, p.MaxBytes)
+	}
+	return fmt.Sprintf("password must be at most %d characters", p.MaxLength)
+}
+
+func containsIdentity(password string, id Identity) bool {
+	lower := strings.ToLower(password)
+	parts := []string{id.Username, id.Email}
+	if addr, err := mail.ParseAddress(id.Email); err == nil {
+		if at := strings.LastIndex(addr.Address, "@"); at > 0 {
+			parts = append(parts, addr.Address[:at])
+		}
+	}
+	for _, part := range parts {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if utf8.RuneCountInString(part) >= minIdentityPart && strings.Contains(lower, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPolicyError возвращает ошибку политики, если err - она
+func IsPolicyError(err error) (*PolicyError, bool) {
+	var pe *PolicyError
+	ok := errors.As(err, &pe)
+	return pe, ok
+}
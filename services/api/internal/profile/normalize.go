@@ -0,0 +1,100 @@
+package profile
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeEmail приводит адрес к виду, в котором он хранится: NFKC и нижний регистр.
+// Уникальность в базе проверяется по LOWER(email), поэтому адреса, отличающиеся
+// только регистром, считаются одним
+func NormalizeEmail(email string) string {
+	return strings.ToLower(norm.NFKC.String(strings.TrimSpace(email)))
+}
+
+// NormalizeUsername приводит username к NFKC: полноширинные буквы, лигатуры и
+// составные символы хранятся в одном каноническом виде. Регистр сохраняется,
+// уникальность в базе - по LOWER(username)
+func NormalizeUsername(username string) string {
+	return norm.NFKC.String(strings.TrimSpace(username))
+}
+
+// Символы, которые выглядят как латинские буквы и цифры (по мотивам Unicode TR39).
+// Ключи - уже в нижнем регистре
+var confusables = map[rune]rune{
+	// Кириллица
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p',
+	'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'ѕ': 's', 'і': 'l', 'ї': 'l', 'ј': 'j', 'ԁ': 'd',
+	'һ': 'h', 'ԛ': 'q', 'ԝ': 'w', 'ӏ': 'l', 'ь': 'b',
+	// Греческий
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'l', 'κ': 'k', 'μ': 'u', 'ν': 'v', 'ο': 'o',
+	'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w',
+	// Латиница и цифры
+	'ı': 'l', 'i': 'l', '1': 'l', '0': 'o', 'ɑ': 'a', 'ɡ': 'g',
+}
+
+// Последовательности, неотличимые от одной буквы
+var confusableSequences = strings.NewReplacer("rn", "m", "vv", "w")
+
+// UsernameSkeleton - вид username, в котором похожие символы совпадают:
+// "Admin", "аdmin" (с кириллической "а"), "adm1n" и "admln" дают один скелет.
+// Скелет уникален в базе, так что имя, похожее на занятое до неразличимости, занять нельзя
+func UsernameSkeleton(username string) string {
+	s := strings.ToLower(NormalizeUsername(username))
+	var b strings.Builder
+	for _, r := range s {
+		if c, ok := confusables[r]; ok {
+			r = c
+		}
+		b.WriteRune(r)
+	}
+	return confusableSequences.Replace(b.String())
+}
+
+// Письменности, буквы которых нельзя смешивать в одном username. Иероглифы,
+// кана и хангыль - одна группа: в японских и корейских именах они встречаются вместе
+var scriptGroups = []struct {
+	name   string
+	tables []*unicode.RangeTable
+}{
+	{"latin", []*unicode.RangeTable{unicode.Latin}},
+	{"cyrillic", []*unicode.RangeTable{unicode.Cyrillic}},
+	{"greek", []*unicode.RangeTable{unicode.Greek}},
+	{"armenian", []*unicode.RangeTable{unicode.Armenian}},
+	{"georgian", []*unicode.RangeTable{unicode.Georgian}},
+	{"hebrew", []*unicode.RangeTable{unicode.Hebrew}},
+	{"arabic", []*unicode.RangeTable{unicode.Arabic}},
+	{"cjk", []*unicode.RangeTable{unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul}},
+}
+
+// letterScript - группа письменности буквы; "" - прочие письменности
+func letterScript(r rune) string {
+	for _, g := range scriptGroups {
+		if unicode.In(r, g.tables...) {
+			return g.name
+		}
+	}
+	return ""
+}
+
+// mixedScripts - есть ли в строке буквы разных письменностей
+func mixedScripts(s string) bool {
+	first := ""
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		script := letterScript(r)
+		if script == "" {
+			script = "other"
+		}
+		if first == "" {
+			first = script
+		} else if script != first {
+			return true
+		}
+	}
+	return false
+}
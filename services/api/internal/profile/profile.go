@@ -0,0 +1,92 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Config - правила редактирования профиля
+type Config struct {
+	// Минимальный промежуток между сменами username
+	UsernameCooldown time.Duration
+	// Сколько старый username закреплен за прежним владельцем
+	ReservationPeriod time.Duration
+	MaxFullNameLength int
+	AvatarMaxBytes    int64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		UsernameCooldown:  30 * 24 * time.Hour,
+		ReservationPeriod: 90 * 24 * time.Hour,
+		MaxFullNameLength: 100,
+		AvatarMaxBytes:    2 << 20,
+	}
+}
+
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 50
+)
+
+// ValidateUsername проверяет username, уже приведенный NormalizeUsername: длина
+// в символах, допустимые символы (буквы, цифры 0-9, "_", "." и "-") и буквы
+// только одной письменности
+func ValidateUsername(username string) error {
+	n := utf8.RuneCountInString(username)
+	if n < minUsernameLength {
+		return fmt.Errorf("username must be at least %d characters", minUsernameLength)
+	}
+	if n > maxUsernameLength {
+		return fmt.Errorf("username must be at most %d characters", maxUsernameLength)
+	}
+	for _, r := range username {
+		if !unicode.IsLetter(r) && !(r >= '0' && r <= '9') && r != '_' && r != '.' && r != '-' {
+			return errors.New("username may contain only letters, digits, '_', '.' and '-'")
+		}
+	}
+	if mixedScripts(username) {
+		return errors.New("username must not mix letters from different alphabets")
+	}
+	return nil
+}
+
+// ValidateFullName - не длиннее лимита и без управляющих символов
+func ValidateFullName(cfg Config, name string) error {
+	if utf8.RuneCountInString(name) > cfg.MaxFullNameLength {
+		return fmt.Errorf("full_name must be at most %d characters", cfg.MaxFullNameLength)
+	}
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return errors.New("full_name must not contain control characters")
+	}
+	return nil
+}
+
+// NextUsernameChange - когда username можно сменить снова; nil - уже можно
+func NextUsernameChange(cfg Config, changedAt *time.Time, now time.Time) *time.Time {
+	if changedAt == nil {
+		return nil
+	}
+	next := changedAt.Add(cfg.UsernameCooldown)
+	if !now.Before(next) {
+		return nil
+	}
+	return &next
+}
+
+// Форматы аватаров: тип определяется по содержимому файла, а не по заголовку запроса
+var avatarExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/webp": "webp",
+}
+
+// AvatarExtension - расширение файла для поддерживаемого типа изображения
+func AvatarExtension(contentType string) (string, bool) {
+	ext, ok := avatarExtensions[contentType]
+	return ext, ok
+}
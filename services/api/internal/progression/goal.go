@@ -0,0 +1,37 @@
+package progression
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mindly/api/internal/models"
+)
+
+const maxGoalTarget = 50
+
+// DefaultGoal - цель новых пользователей: три видео в день
+var DefaultGoal = models.DailyGoal{Type: models.GoalVideos, Target: 3}
+
+// ValidateGoal проверяет дневную цель, заданную пользователем
+func ValidateGoal(goal models.DailyGoal) error {
+	if goal.Type != models.GoalVideos && goal.Type != models.GoalCorrectAnswers {
+		return errors.New("goal type must be videos or correct_answers")
+	}
+	if goal.Target < 1 || goal.Target > maxGoalTarget {
+		return fmt.Errorf("goal target must be between 1 and %d", maxGoalTarget)
+	}
+	return nil
+}
+
+// Progress возвращает значение показателя, которым измеряется цель
+func Progress(goal models.DailyGoal, videosWatched, correctAnswers int) int {
+	if goal.Type == models.GoalCorrectAnswers {
+		return correctAnswers
+	}
+	return videosWatched
+}
+
+// Met сообщает, выполнена ли цель
+func Met(goal models.DailyGoal, videosWatched, correctAnswers int) bool {
+	return Progress(goal, videosWatched, correctAnswers) >= goal.Target
+}
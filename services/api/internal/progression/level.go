@@ -0,0 +1,43 @@
+package progression
+
+import (
+	"math"
+
+	"github.com/mindly/api/internal/models"
+)
+
+// Кривая уровней: чтобы перейти с уровня L на L+1, нужно 50·L баллов опыта,
+// то есть для уровня L всего нужно 25·L·(L-1) баллов (1 → 0, 2 → 50, 3 → 150, 4 → 300 ...)
+const xpStep = 50
+
+// XPForLevel возвращает суммарный опыт, нужный для уровня level
+func XPForLevel(level int) int {
+	if level <= 1 {
+		return 0
+	}
+	return xpStep * level * (level - 1) / 2
+}
+
+// LevelFor вычисляет уровень по сумме баллов
+func LevelFor(totalXP int) models.Level {
+	if totalXP < 0 {
+		totalXP = 0
+	}
+	// Обратная функция к XPForLevel с поправкой на ошибки округления
+	level := int((1 + math.Sqrt(1+8*float64(totalXP)/xpStep)) / 2)
+	for XPForLevel(level+1) <= totalXP {
+		level++
+	}
+	for level > 1 && XPForLevel(level) > totalXP {
+		level--
+	}
+
+	start, next := XPForLevel(level), XPForLevel(level+1)
+	return models.Level{
+		Level:       level,
+		TotalXP:     totalXP,
+		LevelXP:     totalXP - start,
+		NextLevelXP: next - start,
+		Progress:    float64(totalXP-start) / float64(next-start),
+	}
+}
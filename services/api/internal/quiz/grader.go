@@ -0,0 +1,88 @@
+package quiz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mindly/api/internal/models"
+)
+
+var (
+	ErrUnknownType   = errors.New("unknown question type")
+	ErrInvalidAnswer = errors.New("invalid answer format")
+)
+
+// Config - ограничения на тест, задаваемые автором
+type Config struct {
+	// Базовые баллы за вопрос по умолчанию (если автор их не указал)
+	DefaultPoints int
+	// Максимум базовых баллов за вопрос: иначе автор мог бы поднять себя в рейтинге
+	// вопросами к своим же видео
+	MaxPoints int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		DefaultPoints: 1,
+		MaxPoints:     10,
+	}
+}
+
+// Grader проверяет вопросы одного типа.
+// Новый тип вопроса добавляется реализацией Grader и вызовом Register.
+type Grader interface {
+	// Validate проверяет вопрос при сохранении автором: варианты и правильный ответ
+	Validate(q models.QuizQuestion) error
+	// Grade сравнивает ответ пользователя с правильным
+	Grade(q models.QuizQuestion, answer json.RawMessage) (bool, error)
+}
+
+var graders = map[models.QuestionType]Grader{}
+
+// Register регистрирует проверку для типа вопроса
+func Register(t models.QuestionType, g Grader) {
+	graders[t] = g
+}
+
+func graderFor(t models.QuestionType) (Grader, error) {
+	g, ok := graders[t]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, t)
+	}
+	return g, nil
+}
+
+// Validate проверяет вопрос проверкой его типа
+func Validate(q models.QuizQuestion, cfg Config) error {
+	if strings.TrimSpace(q.Question) == "" {
+		return errors.New("question text is required")
+	}
+	if q.PointsAwarded < 0 || q.PointsAwarded > cfg.MaxPoints {
+		return fmt.Errorf("points_awarded must be between 0 and %d", cfg.MaxPoints)
+	}
+	g, err := graderFor(q.Type)
+	if err != nil {
+		return err
+	}
+	return g.Validate(q)
+}
+
+// Grade проверяет ответ пользователя проверкой типа вопроса
+func Grade(q models.QuizQuestion, answer json.RawMessage) (bool, error) {
+	g, err := graderFor(q.Type)
+	if err != nil {
+		return false, err
+	}
+	return g.Grade(q, answer)
+}
+
+func init() {
+	Register(models.QuestionSingleChoice, singleChoiceGrader{})
+	Register(models.QuestionMultipleSelect, multipleSelectGrader{})
+	Register(models.QuestionTrueFalse, trueFalseGrader{})
+	Register(models.QuestionOrdering, orderingGrader{})
+	Register(models.QuestionNumeric, numericGrader{})
+	Register(models.QuestionShortText, shortTextGrader{})
+}
@@ -0,0 +1,279 @@
+package quiz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/mindly/api/internal/models"
+)
+
+// decode разбирает JSON ответа в v, оборачивая ошибку в ErrInvalidAnswer
+func decode(raw json.RawMessage, v any) error {
+	if len(raw) == 0 {
+		return fmt.Errorf("%w: empty answer", ErrInvalidAnswer)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAnswer, err)
+	}
+	return nil
+}
+
+func checkIndex(q models.QuizQuestion, i int) error {
+	if i < 0 || i >= len(q.Options) {
+		return fmt.Errorf("%w: option index %d out of range", ErrInvalidAnswer, i)
+	}
+	return nil
+}
+
+// --- single_choice ---
+
+type singleChoiceGrader struct{}
+
+func (singleChoiceGrader) Validate(q models.QuizQuestion) error {
+	if len(q.Options) < 2 {
+		return errors.New("single_choice needs at least 2 options")
+	}
+	var correct int
+	if err := decode(q.Answer, &correct); err != nil {
+		return err
+	}
+	return checkIndex(q, correct)
+}
+
+func (singleChoiceGrader) Grade(q models.QuizQuestion, answer json.RawMessage) (bool, error) {
+	var correct, given int
+	if err := decode(q.Answer, &correct); err != nil {
+		return false, err
+	}
+	if err := decode(answer, &given); err != nil {
+		return false, err
+	}
+	if err := checkIndex(q, given); err != nil {
+		return false, err
+	}
+	return given == correct, nil
+}
+
+// --- multiple_select ---
+
+type multipleSelectGrader struct{}
+
+// indexSet разбирает список индексов вариантов без повторов
+func indexSet(q models.QuizQuestion, raw json.RawMessage) (map[int]bool, error) {
+	var idx []int
+	if err := decode(raw, &idx); err != nil {
+		return nil, err
+	}
+	set := make(map[int]bool, len(idx))
+	for _, i := range idx {
+		if err := checkIndex(q, i); err != nil {
+			return nil, err
+		}
+		set[i] = true
+	}
+	return set, nil
+}
+
+func (multipleSelectGrader) Validate(q models.QuizQuestion) error {
+	if len(q.Options) < 2 {
+		return errors.New("multiple_select needs at least 2 options")
+	}
+	set, err := indexSet(q, q.Answer)
+	if err != nil {
+		return err
+	}
+	if len(set) == 0 {
+		return errors.New("multiple_select needs at least 1 correct option")
+	}
+	return nil
+}
+
+func (multipleSelectGrader) Grade(q models.QuizQuestion, answer json.RawMessage) (bool, error) {
+	correct, err := indexSet(q, q.Answer)
+	if err != nil {
+		return false, err
+	}
+	given, err := indexSet(q, answer)
+	if err != nil {
+		return false, err
+	}
+	// Засчитываем только точное совпадение множеств
+	if len(given) != len(correct) {
+		return false, nil
+	}
+	for i := range correct {
+		if !given[i] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// --- true_false ---
+
+type trueFalseGrader struct{}
+
+func (trueFalseGrader) Validate(q models.QuizQuestion) error {
+	var correct bool
+	return decode(q.Answer, &correct)
+}
+
+func (trueFalseGrader) Grade(q models.QuizQuestion, answer json.RawMessage) (bool, error) {
+	var correct, given bool
+	if err := decode(q.Answer, &correct); err != nil {
+		return false, err
+	}
+	if err := decode(answer, &given); err != nil {
+		return false, err
+	}
+	return given == correct, nil
+}
+
+// --- ordering ---
+
+type orderingGrader struct{}
+
+// permutation разбирает порядок вариантов: каждый индекс ровно один раз
+func permutation(q models.QuizQuestion, raw json.RawMessage) ([]int, error) {
+	var order []int
+	if err := decode(raw, &order); err != nil {
+		return nil, err
+	}
+	if len(order) != len(q.Options) {
+		return nil, fmt.Errorf("%w: expected %d items, got %d", ErrInvalidAnswer, len(q.Options), len(order))
+	}
+	sorted := append([]int(nil), order...)
+	sort.Ints(sorted)
+	for i, v := range sorted {
+		if v != i {
+			return nil, fmt.Errorf("%w: order must list every option exactly once", ErrInvalidAnswer)
+		}
+	}
+	return order, nil
+}
+
+func (orderingGrader) Validate(q models.QuizQuestion) error {
+	if len(q.Options) < 2 {
+		return errors.New("ordering needs at least 2 options")
+	}
+	_, err := permutation(q, q.Answer)
+	return err
+}
+
+func (orderingGrader) Grade(q models.QuizQuestion, answer json.RawMessage) (bool, error) {
+	correct, err := permutation(q, q.Answer)
+	if err != nil {
+		return false, err
+	}
+	given, err := permutation(q, answer)
+	if err != nil {
+		return false, err
+	}
+	for i := range correct {
+		if given[i] != correct[i] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// --- numeric ---
+
+type numericGrader struct{}
+
+type numericAnswer struct {
+	Value     *float64 `json:"value"`
+	Tolerance float64  `json:"tolerance"`
+}
+
+func (numericGrader) Validate(q models.QuizQuestion) error {
+	var a numericAnswer
+	if err := decode(q.Answer, &a); err != nil {
+		return err
+	}
+	if a.Value == nil {
+		return errors.New("numeric answer needs a value")
+	}
+	if a.Tolerance < 0 {
+		return errors.New("numeric tolerance must not be negative")
+	}
+	return nil
+}
+
+func (numericGrader) Grade(q models.QuizQuestion, answer json.RawMessage) (bool, error) {
+	var correct numericAnswer
+	if err := decode(q.Answer, &correct); err != nil {
+		return false, err
+	}
+	if correct.Value == nil {
+		return false, errors.New("numeric answer needs a value")
+	}
+	var given float64
+	if err := decode(answer, &given); err != nil {
+		return false, err
+	}
+	return math.Abs(given-*correct.Value) <= correct.Tolerance, nil
+}
+
+// --- short_text ---
+
+type shortTextGrader struct{}
+
+type shortTextAnswer struct {
+	Accepted []string `json:"accepted"`
+}
+
+func (shortTextGrader) Validate(q models.QuizQuestion) error {
+	var a shortTextAnswer
+	if err := decode(q.Answer, &a); err != nil {
+		return err
+	}
+	for _, s := range a.Accepted {
+		if NormalizeText(s) != "" {
+			return nil
+		}
+	}
+	return errors.New("short_text needs at least 1 accepted answer")
+}
+
+func (shortTextGrader) Grade(q models.QuizQuestion, answer json.RawMessage) (bool, error) {
+	var correct shortTextAnswer
+	if err := decode(q.Answer, &correct); err != nil {
+		return false, err
+	}
+	var given string
+	if err := decode(answer, &given); err != nil {
+		return false, err
+	}
+	normalized := NormalizeText(given)
+	if normalized == "" {
+		return false, nil
+	}
+	for _, s := range correct.Accepted {
+		if NormalizeText(s) == normalized {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// edgePunct - знаки препинания, которые отбрасываются по краям слов ответа.
+// Символы ("C++", "C#", "50%") и знаки внутри слова ("3.14", "TCP/IP") значимы
+const edgePunct = ".,;:!?…\"'«»“”„‘’()[]{}–—"
+
+// NormalizeText приводит короткий ответ к сравнимому виду:
+// нижний регистр, ё → е, без пунктуации по краям слов и лишних пробелов
+func NormalizeText(s string) string {
+	s = strings.ReplaceAll(strings.ToLower(s), "ё", "е")
+	words := make([]string, 0, 4)
+	for _, w := range strings.Fields(s) {
+		if w = strings.Trim(w, edgePunct); w != "" {
+			words = append(words, w)
+		}
+	}
+	return strings.Join(words, " ")
+}
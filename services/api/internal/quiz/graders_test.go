@@ -0,0 +1,167 @@
+package quiz_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mindly/api/internal/models"
+	"github.com/mindly/api/internal/quiz"
+)
+
+func question(t models.QuestionType, answer string, options ...string) models.QuizQuestion {
+	return models.QuizQuestion{
+		ID:            "q1",
+		Type:          t,
+		Question:      "?",
+		Options:       options,
+		Answer:        json.RawMessage(answer),
+		PointsAwarded: 1,
+	}
+}
+
+func TestGrade(t *testing.T) {
+	single := question(models.QuestionSingleChoice, `1`, "a", "b", "c")
+	multiple := question(models.QuestionMultipleSelect, `[0, 2]`, "a", "b", "c")
+	trueFalse := question(models.QuestionTrueFalse, `true`)
+	ordering := question(models.QuestionOrdering, `[2, 0, 1]`, "a", "b", "c")
+	numeric := question(models.QuestionNumeric, `{"value": 3.14, "tolerance": 0.01}`)
+	exact := question(models.QuestionNumeric, `{"value": 42}`)
+	text := question(models.QuestionShortText, `{"accepted": ["C++", "Ёлка", "3.14", "hypertext transfer protocol"]}`)
+
+	tests := []struct {
+		name    string
+		q       models.QuizQuestion
+		answer  string
+		correct bool
+		invalid bool
+	}{
+		{"single correct", single, `1`, true, false},
+		{"single wrong", single, `0`, false, false},
+		{"single out of range", single, `3`, false, true},
+		{"single not a number", single, `"1"`, false, true},
+
+		{"multiple exact", multiple, `[2, 0]`, true, false},
+		{"multiple duplicates", multiple, `[0, 2, 2]`, true, false},
+		{"multiple subset", multiple, `[0]`, false, false},
+		{"multiple superset", multiple, `[0, 1, 2]`, false, false},
+		{"multiple out of range", multiple, `[0, 5]`, false, true},
+
+		{"true_false correct", trueFalse, `true`, true, false},
+		{"true_false wrong", trueFalse, `false`, false, false},
+		{"true_false not a bool", trueFalse, `"true"`, false, true},
+
+		{"ordering correct", ordering, `[2, 0, 1]`, true, false},
+		{"ordering wrong", ordering, `[0, 1, 2]`, false, false},
+		{"ordering too short", ordering, `[2, 0]`, false, true},
+		{"ordering repeated", ordering, `[2, 2, 1]`, false, true},
+
+		{"numeric within tolerance", numeric, `3.145`, true, false},
+		{"numeric outside tolerance", numeric, `3.2`, false, false},
+		{"numeric without tolerance", exact, `42`, true, false},
+		{"numeric without tolerance off", exact, `42.001`, false, false},
+		{"numeric not a number", numeric, `"3.14"`, false, true},
+
+		{"text exact", text, `"C++"`, true, false},
+		{"text case and spaces", text, `"  HyperText   Transfer Protocol "`, true, false},
+		{"text ё", text, `"елка"`, true, false},
+		{"text edge punctuation", text, `"«ёлка»!"`, true, false},
+		{"text symbols matter", text, `"C#"`, false, false},
+		{"text symbols not stripped", text, `"C"`, false, false},
+		{"text decimal point kept", text, `"3.14"`, true, false},
+		{"text decimal point matters", text, `"314"`, false, false},
+		{"text empty", text, `"  ..."`, false, false},
+		{"text not a string", text, `314`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			correct, err := quiz.Grade(tt.q, json.RawMessage(tt.answer))
+			if tt.invalid {
+				if !errors.Is(err, quiz.ErrInvalidAnswer) {
+					t.Fatalf("error = %v, want ErrInvalidAnswer", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("grade: %v", err)
+			}
+			if correct != tt.correct {
+				t.Errorf("correct = %v, want %v", correct, tt.correct)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := quiz.DefaultConfig()
+	points := func(q models.QuizQuestion, p int) models.QuizQuestion {
+		q.PointsAwarded = p
+		return q
+	}
+
+	tests := []struct {
+		name  string
+		q     models.QuizQuestion
+		valid bool
+	}{
+		{"single", question(models.QuestionSingleChoice, `0`, "a", "b"), true},
+		{"single one option", question(models.QuestionSingleChoice, `0`, "a"), false},
+		{"single answer out of range", question(models.QuestionSingleChoice, `2`, "a", "b"), false},
+		{"multiple", question(models.QuestionMultipleSelect, `[1]`, "a", "b"), true},
+		{"multiple no correct option", question(models.QuestionMultipleSelect, `[]`, "a", "b"), false},
+		{"true_false", question(models.QuestionTrueFalse, `false`), true},
+		{"true_false not a bool", question(models.QuestionTrueFalse, `1`), false},
+		{"ordering", question(models.QuestionOrdering, `[1, 0]`, "a", "b"), true},
+		{"ordering not a permutation", question(models.QuestionOrdering, `[0, 0]`, "a", "b"), false},
+		{"numeric", question(models.QuestionNumeric, `{"value": 0}`), true},
+		{"numeric no value", question(models.QuestionNumeric, `{"tolerance": 1}`), false},
+		{"numeric negative tolerance", question(models.QuestionNumeric, `{"value": 1, "tolerance": -1}`), false},
+		{"short_text", question(models.QuestionShortText, `{"accepted": ["http"]}`), true},
+		{"short_text only punctuation", question(models.QuestionShortText, `{"accepted": ["...", " "]}`), false},
+		{"unknown type", question("essay", `""`), false},
+		{"empty question", func() models.QuizQuestion {
+			q := question(models.QuestionTrueFalse, `true`)
+			q.Question = " "
+			return q
+		}(), false},
+		{"zero points", points(question(models.QuestionTrueFalse, `true`), 0), true},
+		{"max points", points(question(models.QuestionTrueFalse, `true`), cfg.MaxPoints), true},
+		{"too many points", points(question(models.QuestionTrueFalse, `true`), cfg.MaxPoints+1), false},
+		{"negative points", points(question(models.QuestionTrueFalse, `true`), -1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := quiz.Validate(tt.q, cfg)
+			if tt.valid && err != nil {
+				t.Errorf("valid question rejected: %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("invalid question accepted")
+			}
+		})
+	}
+}
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"  Hello,   World! ", "hello world"},
+		{"Ёжик", "ежик"},
+		{"«Война и мир»", "война и мир"},
+		{"C++", "c++"},
+		{"C#", "c#"},
+		{"3.14", "3.14"},
+		{"3.14.", "3.14"},
+		{"TCP/IP", "tcp/ip"},
+		{"50%", "50%"},
+		{"-5", "-5"},
+		{"так — и есть", "так и есть"},
+		{"...", ""},
+	}
+	for _, tt := range tests {
+		if got := quiz.NormalizeText(tt.in); got != tt.want {
+			t.Errorf("NormalizeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package ranking
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/mindly/api/internal/trust"
+)
+
+// Features - признаки видео-кандидата для ранжирования ленты
+type Features struct {
+	CreatedAt time.Time
+	TrustTier string
+	// Число лайков видео
+	Likes int
+	// Доля лайков зрителя, отданных автору видео (0..1)
+	AuthorAffinity float64
+}
+
+// Weights - веса признаков в итоговом балле
+type Weights struct {
+	Recency  float64
+	Trust    float64
+	Likes    float64
+	Affinity float64
+	// Период полураспада свежести видео
+	RecencyHalfLife time.Duration
+	// Число лайков, при котором признак популярности достигает 1
+	LikesSaturation int
+}
+
+func DefaultWeights() Weights {
+	return Weights{
+		Recency:         0.55,
+		Trust:           0.2,
+		Likes:           0.15,
+		Affinity:        0.1,
+		RecencyHalfLife: 48 * time.Hour,
+		LikesSaturation: 100,
+	}
+}
+
+// Score считает балл видео: чем выше, тем раньше оно в ленте
+func Score(w Weights, f Features, now time.Time) float64 {
+	age := now.Sub(f.CreatedAt)
+	if age < 0 {
+		age = 0
+	}
+	recency := math.Pow(0.5, float64(age)/float64(w.RecencyHalfLife))
+
+	return w.Recency*recency +
+		w.Trust*trust.TierWeight(f.TrustTier) +
+		w.Likes*popularity(f.Likes, w.LikesSaturation) +
+		w.Affinity*math.Min(math.Max(f.AuthorAffinity, 0), 1)
+}
+
+// popularity переводит число лайков в 0..1 по логарифмической шкале:
+// первые лайки значат больше, чем разница между 500 и 510
+func popularity(likes, saturation int) float64 {
+	if likes <= 0 || saturation <= 0 {
+		return 0
+	}
+	return math.Min(math.Log1p(float64(likes))/math.Log1p(float64(saturation)), 1)
+}
+
+// Sort упорядочивает элементы по убыванию балла; features возвращает признаки i-го элемента
+func Sort[T any](w Weights, items []T, features func(T) Features, now time.Time) {
+	scores := make(map[int]float64, len(items))
+	idx := make([]int, len(items))
+	for i := range items {
+		idx[i] = i
+		scores[i] = Score(w, features(items[i]), now)
+	}
+
+	sort.SliceStable(idx, func(a, b int) bool {
+		return scores[idx[a]] > scores[idx[b]]
+	})
+
+	sorted := make([]T, len(items))
+	for i, j := range idx {
+		sorted[i] = items[j]
+	}
+	copy(items, sorted)
+}
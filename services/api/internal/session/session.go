@@ -0,0 +1,159 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+)
+
+// Сессии входа: клиент получает короткоживущий access-токен и передает его в заголовке
+// Authorization: Bearer <token>, а по refresh-токену получает новую пару.
+// В базе хранятся только хэши токенов.
+
+// ErrInvalid - токен неизвестен, отозван или истек
+var ErrInvalid = errors.New("invalid or expired session")
+
+// CodeTwoFactorRequired - код ошибки, по которому клиент предлагает включить 2FA
+const CodeTwoFactorRequired = "two_factor_required"
+
+// Session - активная сессия запроса
+type Session struct {
+	ID     string
+	UserID string
+	// Роль требует 2FA, а она не включена: доступны только маршруты RestrictEnrollment
+	EnrollmentOnly bool
+}
+
+// Config - сроки жизни токенов
+type Config struct {
+	AccessTTL time.Duration
+	// Сессия без обновления токенов дольше этого срока завершается
+	RefreshTTL time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		AccessTTL:  15 * time.Minute,
+		RefreshTTL: 30 * 24 * time.Hour,
+	}
+}
+
+// Платформы устройств
+const (
+	PlatformWeb     = "web"
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformDesktop = "desktop"
+	PlatformOther   = "other"
+)
+
+// Platform проверяет платформу, указанную клиентом, а если ее нет - угадывает по User-Agent
+func Platform(declared, userAgent string) string {
+	switch p := strings.ToLower(strings.TrimSpace(declared)); p {
+	case PlatformWeb, PlatformIOS, PlatformAndroid, PlatformDesktop, PlatformOther:
+		return p
+	}
+
+	ua := strings.ToLower(userAgent)
+	switch {
+	case strings.Contains(ua, "android"):
+		return PlatformAndroid
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"), strings.Contains(ua, "cfnetwork"):
+		return PlatformIOS
+	case strings.Contains(ua, "electron"):
+		return PlatformDesktop
+	case strings.Contains(ua, "mozilla"):
+		return PlatformWeb
+	}
+	return PlatformOther
+}
+
+// Store находит сессию по хэшу токена
+type Store interface {
+	Authenticate(ctx context.Context, tokenHash string, now time.Time) (Session, error)
+}
+
+type contextKey struct{}
+
+// WithSession кладет сессию в контекст запроса
+func WithSession(ctx context.Context, s Session) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext возвращает сессию запроса, если он пришел с токеном
+func FromContext(ctx context.Context) (Session, bool) {
+	s, ok := ctx.Value(contextKey{}).(Session)
+	return s, ok
+}
+
+// BearerToken достает токен из заголовка Authorization
+func BearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// Middleware проверяет токен из заголовка Authorization и кладет сессию в контекст.
+// Запросы без токена проходят как есть; с недействительным токеном - получают 401
+func Middleware(store Store, hash func(string) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := BearerToken(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		s, err := store.Authenticate(r.Context(), hash(token), time.Now())
+		if errors.Is(err, ErrInvalid) {
+			writeError(w, ErrInvalid.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to authenticate session: %v", err)
+			writeError(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithSession(r.Context(), s)))
+	})
+}
+
+// RestrictEnrollment пропускает запросы сессии EnrollmentOnly только к маршрутам allowed
+// ("POST /api/me/2fa/enroll"); остальные получают 403 с кодом two_factor_required.
+// Ставится после Middleware
+func RestrictEnrollment(allowed []string, next http.Handler) http.Handler {
+	routes := make(map[string]bool, len(allowed))
+	for _, route := range allowed {
+		routes[route] = true
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, ok := FromContext(r.Context())
+		if ok && s.EnrollmentOnly && !routes[r.Method+" "+r.URL.Path] {
+			writeErrorCode(w, "Enable two-factor authentication to continue", CodeTwoFactorRequired, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeError(w http.ResponseWriter, message string, code int) {
+	writeErrorCode(w, message, "", code)
+}
+
+func writeErrorCode(w http.ResponseWriter, message, errorCode string, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(models.APIResponse{Status: "error", Error: message, Code: errorCode}); err != nil {
+		log.Printf("❌ Error encoding error response: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package srs
+
+import (
+	"math"
+	"time"
+)
+
+// Алгоритм SM-2 (SuperMemo 2): после каждого повторения пересчитывается интервал
+// до следующего повторения и "легкость" материала для пользователя.
+
+const (
+	DefaultEaseFactor = 2.5
+	minEaseFactor     = 1.3
+
+	// Оценка качества ответа по шкале SM-2: 0..5, меньше 3 - материал не усвоен
+	MaxQuality  = 5
+	passQuality = 3
+)
+
+// State - состояние повторения одного теста для пользователя
+type State struct {
+	Repetitions  int
+	EaseFactor   float64
+	IntervalDays int
+	DueDate      time.Time
+}
+
+// New возвращает состояние для только что проваленного теста: повторить завтра
+func New(today time.Time) State {
+	return State{
+		Repetitions:  0,
+		EaseFactor:   DefaultEaseFactor,
+		IntervalDays: 1,
+		DueDate:      dateOnly(today).AddDate(0, 0, 1),
+	}
+}
+
+// QualityFromScore переводит долю правильных ответов (0..1) в оценку SM-2
+func QualityFromScore(correct, total int) int {
+	if total <= 0 {
+		return 0
+	}
+	return int(math.Round(float64(MaxQuality) * float64(correct) / float64(total)))
+}
+
+// Review применяет результат повторения с оценкой quality (0..5) к состоянию
+func Review(s State, quality int, today time.Time) State {
+	quality = max(0, min(MaxQuality, quality))
+	if s.EaseFactor == 0 {
+		s.EaseFactor = DefaultEaseFactor
+	}
+
+	if quality >= passQuality {
+		switch s.Repetitions {
+		case 0:
+			s.IntervalDays = 1
+		case 1:
+			s.IntervalDays = 6
+		default:
+			s.IntervalDays = int(math.Round(float64(s.IntervalDays) * s.EaseFactor))
+		}
+		s.Repetitions++
+	} else {
+		// Провал: начинаем цепочку повторений заново
+		s.Repetitions = 0
+		s.IntervalDays = 1
+	}
+
+	q := float64(MaxQuality - quality)
+	s.EaseFactor = math.Max(minEaseFactor, s.EaseFactor+0.1-q*(0.08+q*0.02))
+	s.DueDate = dateOnly(today).AddDate(0, 0, s.IntervalDays)
+	return s
+}
+
+func dateOnly(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrInvalidKey - ключ пустой или выходит за пределы хранилища
+	ErrInvalidKey = errors.New("invalid storage key")
+	// ErrNotExist - файла с таким ключом нет
+	ErrNotExist = errors.New("file does not exist")
+)
+
+// Storage хранит загруженные пользователями файлы (аватары) и архивы выгрузок.
+// Ключ - относительный путь вида "avatars/<user>/<file>"; публичный адрес (URL)
+// есть только у аватаров, архивы читаются через Open
+type Storage interface {
+	Put(ctx context.Context, key, contentType string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// Backend - где лежат файлы
+type Backend string
+
+const (
+	BackendLocal Backend = "local"
+)
+
+// Config - настройки хранилища
+type Config struct {
+	Backend Backend
+	// Каталог для BackendLocal
+	Dir string
+	// Адрес, по которому API раздает файлы (см. LocalStorage.Handler)
+	BaseURL string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Backend: BackendLocal,
+		Dir:     "tmp/uploads",
+		BaseURL: "http://localhost:8081/uploads",
+	}
+}
+
+// New создает хранилище для выбранного способа
+func New(cfg Config) (Storage, error) {
+	switch cfg.Backend {
+	case BackendLocal, "":
+		return NewLocalStorage(cfg.Dir, cfg.BaseURL)
+	}
+	return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+}
@@ -0,0 +1,67 @@
+package streak
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Store - хранилище серий пользователей
+type Store interface {
+	// RollStreaks закрывает пропущенные до today дни у всех активных серий
+	// и возвращает ID пользователей, чьи серии прервались
+	RollStreaks(ctx context.Context, today time.Time) ([]string, error)
+}
+
+// Publisher получает новые значения серий (рейтинг серий)
+type Publisher interface {
+	SetStreak(ctx context.Context, userID string, streak int) error
+}
+
+// Job закрывает пропущенные дни: тратит заморозки, а без них прерывает серии
+type Job struct {
+	store     Store
+	publisher Publisher
+}
+
+func NewJob(store Store, publisher Publisher) *Job {
+	return &Job{store: store, publisher: publisher}
+}
+
+// RunOnce проверяет серии и возвращает число прерванных
+func (j *Job) RunOnce(ctx context.Context) (int, error) {
+	ids, err := j.store.RollStreaks(ctx, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("roll streaks: %w", err)
+	}
+
+	for _, id := range ids {
+		// Рейтинг восстанавливается командой leaderboard-rebuild, поэтому ошибка не фатальна
+		if err := j.publisher.SetStreak(ctx, id, 0); err != nil {
+			log.Printf("⚠️ Failed to reset streak leaderboard for user %s: %v", id, err)
+		}
+	}
+	return len(ids), nil
+}
+
+// Start запускает проверку сразу и затем с заданным интервалом, пока не отменен ctx
+func (j *Job) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		broken, err := j.RunOnce(ctx)
+		if err != nil {
+			log.Printf("⚠️ Streak job error: %v", err)
+		} else if broken > 0 {
+			log.Printf("🔥 Streak job: прервано серий: %d", broken)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package streak
+
+import (
+	"errors"
+	"time"
+)
+
+// Серия (streak) - число дней подряд, в которые пользователь выполнил дневную цель.
+// Пропущенный день закрывается заморозкой, если она есть; иначе серия прерывается,
+// и в течение RepairWindow ее можно восстановить за баллы.
+
+var (
+	ErrFreezeLimit     = errors.New("freeze limit reached")
+	ErrNotEnoughPoints = errors.New("not enough points")
+	ErrNothingToRepair = errors.New("no broken streak to repair")
+	ErrRepairExpired   = errors.New("streak repair window has expired")
+)
+
+// Config - правила заморозок и восстановления серии
+type Config struct {
+	// Сколько заморозок можно держать одновременно
+	MaxFreezes int
+	// Цена заморозки в баллах
+	FreezeCost int
+	// За каждые EarnEvery дней серии выдается заморозка и MilestoneBonus баллов
+	EarnEvery      int
+	MilestoneBonus int
+	// Сколько времени после обрыва серию можно восстановить
+	RepairWindow time.Duration
+	// Цена восстановления: RepairCostPerDay за день серии, но не меньше MinRepairCost
+	RepairCostPerDay int
+	MinRepairCost    int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MaxFreezes:       2,
+		FreezeCost:       100,
+		EarnEvery:        7,
+		MilestoneBonus:   25,
+		RepairWindow:     48 * time.Hour,
+		RepairCostPerDay: 10,
+		MinRepairCost:    50,
+	}
+}
+
+// RepairCost возвращает цену восстановления серии длиной lost
+func (c Config) RepairCost(lost int) int {
+	return max(lost*c.RepairCostPerDay, c.MinRepairCost)
+}
+
+// Bonus возвращает бонусные баллы за день серии current (0 - не круглая дата)
+func (c Config) Bonus(current int) int {
+	if c.EarnEvery <= 0 || current <= 0 || current%c.EarnEvery != 0 {
+		return 0
+	}
+	return c.MilestoneBonus
+}
+
+// State - состояние серии пользователя
+type State struct {
+	Current int
+	Best    int
+	// Последний день, засчитанный в серию: цель выполнена или день заморожен (zero - ни разу)
+	LastDay time.Time
+	Freezes int
+}
+
+// Day приводит момент времени к дню серии (UTC)
+func Day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Rollover - итог проверки пропущенных дней
+type Rollover struct {
+	// Дни, закрытые заморозками
+	Frozen []time.Time
+	// Серия прервалась в день BrokenOn; Lost - длина потерянной серии
+	Broken   bool
+	BrokenOn time.Time
+	Lost     int
+}
+
+// Roll закрывает дни, пропущенные до today (сам today еще не закончился):
+// на каждый пропущенный день тратится заморозка, а когда заморозки кончаются, серия прерывается
+func Roll(s State, today time.Time) (State, Rollover) {
+	var r Rollover
+	if s.Current == 0 || s.LastDay.IsZero() {
+		return s, r
+	}
+
+	today = Day(today)
+	for day := Day(s.LastDay).AddDate(0, 0, 1); day.Before(today); day = day.AddDate(0, 0, 1) {
+		if s.Freezes == 0 {
+			r.Broken = true
+			r.BrokenOn = day
+			r.Lost = s.Current
+			s.Current = 0
+			return s, r
+		}
+		s.Freezes--
+		s.LastDay = day
+		r.Frozen = append(r.Frozen, day)
+	}
+	return s, r
+}
+
+// GoalMet продлевает серию: цель выполнена в день day. Пропущенные дни
+// нужно закрыть через Roll до вызова. Возвращает true, если за этот день выдана заморозка.
+func GoalMet(cfg Config, s State, day time.Time) (State, bool) {
+	day = Day(day)
+	last := Day(s.LastDay)
+
+	switch {
+	case !s.LastDay.IsZero() && !day.After(last):
+		// День уже засчитан
+		return s, false
+	case s.Current > 0 && last.AddDate(0, 0, 1).Equal(day):
+		s.Current++
+	default:
+		s.Current = 1
+	}
+
+	s.LastDay = day
+	if s.Current > s.Best {
+		s.Best = s.Current
+	}
+
+	earned := cfg.EarnEvery > 0 && s.Current%cfg.EarnEvery == 0 && s.Freezes < cfg.MaxFreezes
+	if earned {
+		s.Freezes++
+	}
+	return s, earned
+}
+
+// Repair восстанавливает серию длиной lost, прерванную не раньше, чем RepairWindow назад.
+// Дни, выполненные после обрыва, прибавляются к восстановленной серии.
+func Repair(cfg Config, s State, lost int, brokenAt, now time.Time) (State, error) {
+	if lost <= 0 {
+		return s, ErrNothingToRepair
+	}
+	if now.Sub(brokenAt) > cfg.RepairWindow {
+		return s, ErrRepairExpired
+	}
+
+	yesterday := Day(now).AddDate(0, 0, -1)
+	s.Current += lost
+	if s.LastDay.Before(yesterday) {
+		s.LastDay = yesterday
+	}
+	if s.Current > s.Best {
+		s.Best = s.Current
+	}
+	return s, nil
+}
+
+// Effective возвращает длину серии на день today с учетом заморозок, которые будут потрачены
+func Effective(s State, today time.Time) int {
+	s, _ = Roll(s, today)
+	return s.Current
+}
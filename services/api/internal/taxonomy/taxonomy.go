@@ -0,0 +1,117 @@
+package taxonomy
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mindly/api/internal/models"
+)
+
+// Таксономия тегов: авторы пишут один и тот же тег по-разному ("Go", "golang", "голанг").
+// Тег нормализуется (регистр, пробелы, ё) и по словарю синонимов сводится к каноническому slug.
+// Теги без записи в словаре остаются самостоятельными под своей нормализованной формой.
+
+// Normalize приводит тег к нормализованной форме для сравнения
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	tag = strings.ReplaceAll(tag, "ё", "е")
+	return strings.Join(strings.Fields(tag), " ")
+}
+
+// Taxonomy - словарь канонических тегов и их синонимов
+type Taxonomy struct {
+	// нормализованный синоним → slug
+	aliases map[string]string
+	// slug → отображаемое название
+	names map[string]string
+}
+
+// New строит таксономию из канонических тегов (slug → название) и синонимов (синоним → slug)
+func New(names map[string]string, aliases map[string]string) *Taxonomy {
+	t := &Taxonomy{aliases: map[string]string{}, names: map[string]string{}}
+	for slug, name := range names {
+		slug = Normalize(slug)
+		t.names[slug] = name
+		t.aliases[slug] = slug
+	}
+	for alias, slug := range aliases {
+		t.aliases[Normalize(alias)] = Normalize(slug)
+	}
+	return t
+}
+
+// Canonical возвращает slug канонического тега для написания tag
+func (t *Taxonomy) Canonical(tag string) string {
+	n := Normalize(tag)
+	if slug, ok := t.aliases[n]; ok {
+		return slug
+	}
+	return n
+}
+
+// Name возвращает отображаемое название тега
+func (t *Taxonomy) Name(slug string) string {
+	if name, ok := t.names[slug]; ok {
+		return name
+	}
+	return slug
+}
+
+// Spellings возвращает нормализованные написания, которые сводятся к тегу tag:
+// slug и все его синонимы. Тег вне словаря - только его нормализованная форма
+func (t *Taxonomy) Spellings(tag string) []string {
+	slug := t.Canonical(tag)
+	spellings := []string{slug}
+	for alias, s := range t.aliases {
+		if s == slug && alias != slug {
+			spellings = append(spellings, alias)
+		}
+	}
+	sort.Strings(spellings[1:])
+	return spellings
+}
+
+// Group объединяет написания тегов в канонические теги.
+// usage - ID видео для каждого написания тега; одно видео с "go" и "golang" считается один раз.
+func (t *Taxonomy) Group(usage map[string][]string) []models.TagNode {
+	type group struct {
+		variants map[string]bool
+		videos   map[string]bool
+	}
+	groups := map[string]*group{}
+	for raw, videoIDs := range usage {
+		slug := t.Canonical(raw)
+		g, ok := groups[slug]
+		if !ok {
+			g = &group{variants: map[string]bool{}, videos: map[string]bool{}}
+			groups[slug] = g
+		}
+		g.variants[raw] = true
+		for _, id := range videoIDs {
+			g.videos[id] = true
+		}
+	}
+
+	nodes := make([]models.TagNode, 0, len(groups))
+	for slug, g := range groups {
+		variants := make([]string, 0, len(g.variants))
+		for v := range g.variants {
+			variants = append(variants, v)
+		}
+		sort.Strings(variants)
+		nodes = append(nodes, models.TagNode{
+			Slug:     slug,
+			Name:     t.Name(slug),
+			Variants: variants,
+			Videos:   len(g.videos),
+		})
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Videos != nodes[j].Videos {
+			return nodes[i].Videos > nodes[j].Videos
+		}
+		return nodes[i].Slug < nodes[j].Slug
+	})
+	return nodes
+}
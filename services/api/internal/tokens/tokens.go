@@ -0,0 +1,30 @@
+package tokens
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// Одноразовые токены для ссылок из писем. Клиент получает сам токен,
+// в базе хранится только его SHA-256: утечка таблицы не дает рабочих ссылок.
+
+const tokenBytes = 32
+
+// Generate создает случайный токен и его хэш для хранения
+func Generate() (token, hash string, err error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("generate token: %w", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, Hash(token), nil
+}
+
+// Hash возвращает хэш токена для поиска в базе
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,36 @@
+package totp
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+)
+
+// Резервные коды: 10 символов base32 в двух группах, например "k7m2q-x9fd4".
+// В базе хранится только хэш нормализованного кода
+
+const recoveryCodeBytes = 7
+
+// GenerateRecoveryCodes создает n резервных кодов
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	buf := make([]byte, recoveryCodeBytes)
+	for i := 0; i < n; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("generate recovery code: %w", err)
+		}
+		s := strings.ToLower(b32.EncodeToString(buf))[:10]
+		codes = append(codes, s[:5]+"-"+s[5:])
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode убирает регистр, пробелы и дефисы, чтобы код можно было ввести как угодно
+func NormalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+}
@@ -0,0 +1,69 @@
+package trust
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+)
+
+// Store - хранилище входных сигналов и результатов пересчета
+type Store interface {
+	ListTrustInputs(ctx context.Context) ([]models.AuthorTrustInputs, error)
+	SaveTrustEvaluation(ctx context.Context, e models.TrustEvaluation) error
+}
+
+// Job периодически пересчитывает уровни доверия всех авторов
+type Job struct {
+	store Store
+	cfg   Config
+}
+
+func NewJob(store Store, cfg Config) *Job {
+	return &Job{store: store, cfg: cfg}
+}
+
+// RunOnce пересчитывает уровни и возвращает число изменившихся
+func (j *Job) RunOnce(ctx context.Context) (int, error) {
+	inputs, err := j.store.ListTrustInputs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("load trust inputs: %w", err)
+	}
+
+	now := time.Now().UTC()
+	changed := 0
+	for _, in := range inputs {
+		e := Evaluate(j.cfg, in, now)
+		if err := j.store.SaveTrustEvaluation(ctx, e); err != nil {
+			return changed, fmt.Errorf("save evaluation for author %s: %w", in.AuthorID, err)
+		}
+		if e.Changed() {
+			changed++
+			log.Printf("🏅 Автор %s: %s → %s (балл %.1f)", e.AuthorID, e.PreviousTier, e.Tier, e.Score)
+		}
+	}
+	return changed, nil
+}
+
+// Start запускает пересчет сразу и затем с заданным интервалом, пока не отменен ctx
+func (j *Job) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		changed, err := j.RunOnce(ctx)
+		if err != nil {
+			log.Printf("⚠️ Trust tier job error: %v", err)
+		} else {
+			log.Printf("🏅 Trust tier job: пересчет завершен, изменено уровней: %d", changed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package trust
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/mindly/api/internal/models"
+)
+
+// Config - пороги и веса для расчета уровня доверия
+type Config struct {
+	// Минимум зрителей, после которого учитываются поведенческие сигналы
+	MinViewers int
+	// Минимум попыток теста для расчета прироста правильных ответов
+	MinQuizAttempts int
+
+	// Пороговые баллы для уровней
+	PlatinumScore float64
+	GoldScore     float64
+	SilverScore   float64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MinViewers:      30,
+		MinQuizAttempts: 20,
+		PlatinumScore:   85,
+		GoldScore:       70,
+		SilverScore:     45,
+	}
+}
+
+// Базовый балл автора без каких-либо сигналов соответствует уровню silver,
+// как и значение по умолчанию в схеме
+const baseScore = 50.0
+
+// Evaluate считает балл и уровень доверия автора и объясняет каждое слагаемое
+func Evaluate(cfg Config, in models.AuthorTrustInputs, now time.Time) models.TrustEvaluation {
+	score := baseScore
+	var reasons []string
+
+	if in.IsVerified {
+		score += 20
+		reasons = append(reasons, "+20: автор верифицирован")
+	}
+
+	// Доля отклоненных модерацией видео
+	if in.VideosTotal > 0 && in.VideosRejected > 0 {
+		rate := float64(in.VideosRejected) / float64(in.VideosTotal)
+		penalty := math.Min(40, rate*80)
+		score -= penalty
+		reasons = append(reasons, fmt.Sprintf("-%.1f: отклонено модерацией %d из %d видео (%.0f%%)",
+			penalty, in.VideosRejected, in.VideosTotal, rate*100))
+	}
+
+	if in.Viewers < cfg.MinViewers {
+		reasons = append(reasons, fmt.Sprintf("поведенческие сигналы не учтены: %d зрителей, нужно не меньше %d",
+			in.Viewers, cfg.MinViewers))
+	} else {
+		// Жалобы зрителей на 100 зрителей
+		if in.Reports > 0 && in.Viewers > 0 {
+			per100 := float64(in.Reports) * 100 / float64(in.Viewers)
+			penalty := math.Min(30, per100*10)
+			score -= penalty
+			reasons = append(reasons, fmt.Sprintf("-%.1f: %d жалоб на %d зрителей", penalty, in.Reports, in.Viewers))
+		}
+
+		// Досматриваемость видео; без просмотров сигнал не учитывается
+		if in.Views > 0 {
+			completion := float64(in.WatchedCount) / float64(in.Views)
+			delta := (completion - 0.5) * 30
+			score += delta
+			reasons = append(reasons, fmt.Sprintf("%+.1f: досматривают %.0f%% зрителей", delta, completion*100))
+		}
+
+		// Насколько после видео автора отвечают правильнее, чем в среднем по платформе
+		if in.QuizAttempts >= cfg.MinQuizAttempts {
+			correct := float64(in.QuizCorrect) / float64(in.QuizAttempts)
+			lift := correct - in.PlatformCorrectRate
+			delta := math.Max(-15, math.Min(15, lift*100))
+			score += delta
+			reasons = append(reasons, fmt.Sprintf("%+.1f: правильных ответов %.0f%% при среднем %.0f%%",
+				delta, correct*100, in.PlatformCorrectRate*100))
+		}
+	}
+
+	score = math.Max(0, math.Min(100, score))
+	tier := tierForScore(cfg, score, in.IsVerified)
+
+	if in.ManualTier {
+		reasons = append(reasons, fmt.Sprintf("уровень закреплен вручную, расчетный уровень: %s", tier))
+		tier = in.CurrentTier
+	}
+
+	return models.TrustEvaluation{
+		AuthorID:     in.AuthorID,
+		PreviousTier: in.CurrentTier,
+		Tier:         tier,
+		Score:        math.Round(score*10) / 10,
+		Reasons:      reasons,
+		Inputs:       in,
+		EvaluatedAt:  now,
+	}
+}
+
+func tierForScore(cfg Config, score float64, verified bool) string {
+	switch {
+	// platinum доступен только верифицированным авторам
+	case score >= cfg.PlatinumScore && verified:
+		return models.TrustTierPlatinum
+	case score >= cfg.GoldScore:
+		return models.TrustTierGold
+	case score >= cfg.SilverScore:
+		return models.TrustTierSilver
+	default:
+		return models.TrustTierBronze
+	}
+}
+
+// TierWeight переводит уровень доверия в признак для ранжирования ленты (0..1)
+func TierWeight(tier string) float64 {
+	switch tier {
+	case models.TrustTierPlatinum:
+		return 1.0
+	case models.TrustTierGold:
+		return 0.75
+	case models.TrustTierSilver:
+		return 0.5
+	case models.TrustTierBronze:
+		return 0.2
+	default:
+		return 0.5
+	}
+}
@@ -0,0 +1,83 @@
+package verification
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/mindly/api/internal/mailer"
+)
+
+// Подтверждение email: после регистрации пользователь получает ссылку с одноразовым токеном.
+// Повторная отправка ограничена паузой между письмами и суточным лимитом.
+
+var (
+	ErrAlreadyVerified = errors.New("email is already verified")
+	ErrTooSoon         = errors.New("verification email was sent recently")
+	ErrDailyLimit      = errors.New("too many verification emails today")
+)
+
+// Config - срок жизни ссылки и ограничения повторной отправки
+type Config struct {
+	TokenTTL time.Duration
+	// Минимальная пауза между письмами одному пользователю
+	ResendCooldown time.Duration
+	// Сколько писем можно отправить за последние 24 часа
+	MaxPerDay int
+	// Адрес страницы подтверждения; токен добавляется параметром token
+	LinkBaseURL string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		TokenTTL:       24 * time.Hour,
+		ResendCooldown: time.Minute,
+		MaxPerDay:      5,
+		LinkBaseURL:    "http://localhost:8081/api/auth/verify-email",
+	}
+}
+
+// SendState - история отправок для проверки ограничений
+type SendState struct {
+	Verified   bool
+	LastSentAt *time.Time
+	SentToday  int
+}
+
+// CheckResend проверяет, можно ли отправить письмо сейчас.
+// При ErrTooSoon возвращает, сколько осталось ждать
+func CheckResend(cfg Config, s SendState, now time.Time) (time.Duration, error) {
+	if s.Verified {
+		return 0, ErrAlreadyVerified
+	}
+	if s.LastSentAt != nil {
+		if wait := s.LastSentAt.Add(cfg.ResendCooldown).Sub(now); wait > 0 {
+			return wait, ErrTooSoon
+		}
+	}
+	if s.SentToday >= cfg.MaxPerDay {
+		return 0, ErrDailyLimit
+	}
+	return 0, nil
+}
+
+// Email собирает письмо со ссылкой подтверждения
+func Email(cfg Config, to, username, token string) mailer.Message {
+	link := cfg.LinkBaseURL + "?token=" + url.QueryEscape(token)
+	return mailer.Message{
+		To:      to,
+		Subject: "Подтвердите email в Mindly",
+		Text: fmt.Sprintf(
+			"Здравствуйте, %s!\n\nЧтобы подтвердить адрес, откройте ссылку:\n%s\n\n"+
+				"Ссылка действует %s. Если вы не регистрировались в Mindly, просто проигнорируйте это письмо.\n",
+			username, link, humanDuration(cfg.TokenTTL)),
+	}
+}
+
+func humanDuration(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		return fmt.Sprintf("%d ч", int(d/time.Hour))
+	}
+	return fmt.Sprintf("%d мин", int(d/time.Minute))
+}
@@ -2,15 +2,16 @@ package main
 
 import (
 	"context"
-	"crypto/md5"
 	"database/sql"
-	"encoding/hex"
 	"fmt"
 	"log"
 	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
+
+	"github.com/mindly/api/internal/password"
+	"github.com/mindly/api/internal/profile"
 )
 
 func safeShortID(id string, length int) string {
@@ -23,9 +24,23 @@ func safeShortID(id string, length int) string {
 	return id
 }
 
-func generatePasswordHash(password string) string {
-	hash := md5.Sum([]byte(password))
-	return hex.EncodeToString(hash[:])
+// Сид хэширует пароли тем же алгоритмом, что и API, чтобы с ними можно было войти
+var hasher = mustHasher()
+
+func mustHasher() *password.Hasher {
+	h, err := password.NewHasher(password.DefaultHashConfig())
+	if err != nil {
+		log.Fatalf("❌ Не удалось настроить хэширование паролей: %v", err)
+	}
+	return h
+}
+
+func generatePasswordHash(plain string) string {
+	hash, err := hasher.Hash(plain)
+	if err != nil {
+		log.Fatalf("❌ Не удалось захэшировать пароль: %v", err)
+	}
+	return hash
 }
 
 func main() {
@@ -65,18 +80,21 @@ func main() {
 			currentTime := time.Now()
 
 			// Пытаемся создать пользователя со всеми обязательными полями
+			// Демо-пользователь создается с уже подтвержденным email ($8 - и created_at)
 			err = db.QueryRowContext(ctx,
 				`INSERT INTO users (
 					email, 
 					username, 
+					username_skeleton,
 					password_hash, 
 					full_name,
 					score,
 					current_streak,
 					best_streak,
+					email_verified_at,
 					created_at,
 					updated_at
-				) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) 
+				) VALUES ($1, $2, $10, $3, $4, $5, $6, $7, $8, $8, $9) 
 				RETURNING id::text`,
 				"demo@mindly.ru",
 				"demo_user",
@@ -87,6 +105,7 @@ func main() {
 				0, // best_streak
 				currentTime,
 				currentTime,
+				profile.UsernameSkeleton("demo_user"),
 			).Scan(&userID)
 
 			if err != nil {
@@ -97,16 +116,18 @@ func main() {
 					`INSERT INTO users (
 						email, 
 						username, 
+						username_skeleton,
 						password_hash,
 						created_at,
 						updated_at
-					) VALUES ($1, $2, $3, $4, $5) 
+					) VALUES ($1, $2, $6, $3, $4, $5) 
 					RETURNING id::text`,
 					"test@mindly.ru",
 					"test_user",
 					generatePasswordHash("test123"),
 					currentTime,
 					currentTime,
+					profile.UsernameSkeleton("test_user"),
 				).Scan(&userID)
 
 				if err != nil {
@@ -117,16 +138,18 @@ func main() {
 						`INSERT INTO users (
 							email, 
 							username, 
+							username_skeleton,
 							password_hash,
 							created_at,
 							updated_at
-						) VALUES ($1, $2, $3, $4, $5) 
+						) VALUES ($1, $2, $6, $3, $4, $5) 
 						RETURNING id::text`,
 						"admin@mindly.ru",
 						"admin",
 						generatePasswordHash("admin123"),
 						currentTime,
 						currentTime,
+						profile.UsernameSkeleton("admin"),
 					).Scan(&userID)
 
 					if err != nil {
@@ -356,12 +379,32 @@ func main() {
 		}
 	}
 
-	// 4. ДОБАВЛЯЕМ ТЕСТЫ К ВИДЕО (если есть таблица quizzes)
-	fmt.Println("\n4. Проверяем таблицу quizzes...")
+	// 4. ДОБАВЛЯЕМ ТЕСТЫ К ВИДЕО (если есть таблица quiz_questions)
+	fmt.Println("\n4. Проверяем таблицу quiz_questions...")
 
 	var tableExists bool
 	db.QueryRowContext(ctx,
-		"SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'quizzes')").Scan(&tableExists)
+		"SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'quiz_questions')").Scan(&tableExists)
+
+	// Вопросы, которые добавляются к каждому видео
+	testQuestions := []struct {
+		questionType, question, options, answer, explanation string
+	}{
+		{
+			"single_choice",
+			"Был ли этот материал полезен?",
+			`["Да, узнал что-то новое", "Уже знал это", "Слишком сложно", "Не по теме"]`,
+			`0`,
+			"Спасибо за ответ! Он помогает авторам делать видео лучше.",
+		},
+		{
+			"true_false",
+			"В видео было больше одной ключевой идеи?",
+			`[]`,
+			`true`,
+			"Короткие видео обычно раскрывают одну главную мысль и пару деталей к ней.",
+		},
+	}
 
 	testsAdded := 0
 	if tableExists && len(videoIDs) > 0 {
@@ -372,35 +415,39 @@ func main() {
 				continue
 			}
 
-			quizQuery := `
-				INSERT INTO quizzes (
-					video_id, 
-					question, 
-					correct_answer, 
-					wrong_answers,
-					created_at,
-					updated_at
-				) VALUES ($1, $2, $3, $4, $5, $6)
-				ON CONFLICT (video_id) DO NOTHING
-			`
-
-			_, err := db.ExecContext(ctx, quizQuery,
-				videoID,
-				"Был ли этот материал полезен?",
-				"Да, узнал что-то новое",
-				`{"Уже знал это", "Слишком сложно", "Не по теме"}`,
-				currentTime,
-				currentTime,
-			)
+			for i, q := range testQuestions {
+				quizQuery := `
+					INSERT INTO quiz_questions (
+						video_id,
+						position,
+						question_type,
+						question,
+						options,
+						answer,
+						explanation
+					) VALUES ($1, $2, $3, $4, $5, $6, $7)
+					ON CONFLICT (video_id, position) DO NOTHING
+				`
+
+				_, err := db.ExecContext(ctx, quizQuery,
+					videoID,
+					i+1,
+					q.questionType,
+					q.question,
+					q.options,
+					q.answer,
+					q.explanation,
+				)
 
-			if err != nil {
-				log.Printf("⚠️ Ошибка при добавлении теста для видео %s: %v", safeShortID(videoID, 8), err)
-			} else {
-				testsAdded++
+				if err != nil {
+					log.Printf("⚠️ Ошибка при добавлении вопроса %d для видео %s: %v", i+1, safeShortID(videoID, 8), err)
+				} else {
+					testsAdded++
+				}
 			}
 		}
 	} else {
-		fmt.Println("   ⚠️ Таблица quizzes не существует или нет видео")
+		fmt.Println("   ⚠️ Таблица quiz_questions не существует или нет видео")
 	}
 
 	// 5. ФИНАЛЬНАЯ ПРОВЕРКА
@@ -415,7 +462,7 @@ func main() {
 	fmt.Printf("   📊 В БД теперь: %d видео, %d авторов\n", videoCount, authorCount)
 
 	if videosAdded > 0 {
-		fmt.Printf("\n🎉 УСПЕХ! Загружено %d видео и %d тестов.\n", videosAdded, testsAdded)
+		fmt.Printf("\n🎉 УСПЕХ! Загружено %d видео и %d вопросов.\n", videosAdded, testsAdded)
 		fmt.Println("🔗 Проверьте API: http://localhost:8081/api/feed?limit=5")
 		fmt.Println("📺 Пример видео URL: https://commondatastorage.googleapis.com/gtv-videos-bucket/sample/BigBuckBunny.mp4")
 